@@ -0,0 +1,185 @@
+package archive
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/stellar/go/support/historyarchive"
+	"github.com/stellar/go/xdr"
+
+	"github.com/astroband/astrologer/db"
+)
+
+// checkpointFrequency number of ledgers in the checkpoint
+const checkpointFrequency = 64
+
+// cacheSize number of checkpoints kept in memory
+const cacheSize = 64
+
+// checkpoint holds ledgers and transactions of the checkpoint files
+type checkpoint struct {
+	headers map[uint32]db.LedgerHeaderRow
+	txs     map[uint32][]db.TxHistoryRow
+}
+
+// checkpoint returns the checkpoint containing the ledger, loading it if not cached
+func (c *Client) checkpoint(seq int) (*checkpoint, error) {
+	chk := uint32(seq/checkpointFrequency*checkpointFrequency + checkpointFrequency - 1)
+
+	c.mutex.Lock()
+	cp, ok := c.cache[chk]
+	c.mutex.Unlock()
+
+	if ok {
+		return cp, nil
+	}
+
+	cp, err := c.load(chk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %d: %v", chk, err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.cache[chk]; !ok {
+		c.cache[chk] = cp
+		c.loaded = append(c.loaded, chk)
+
+		if len(c.loaded) > cacheSize {
+			delete(c.cache, c.loaded[0])
+			c.loaded = c.loaded[1:]
+		}
+	}
+
+	return cp, nil
+}
+
+// load reads ledger, transactions and results files of the checkpoint
+func (c *Client) load(chk uint32) (*checkpoint, error) {
+	cp := &checkpoint{
+		headers: make(map[uint32]db.LedgerHeaderRow),
+		txs:     make(map[uint32][]db.TxHistoryRow),
+	}
+
+	envelopes := make(map[uint32]map[xdr.Hash]xdr.TransactionEnvelope)
+
+	err := c.read("ledger", chk, func(s *historyarchive.XdrStream) error {
+		var entry xdr.LedgerHeaderHistoryEntry
+
+		if err := s.ReadOne(&entry); err != nil {
+			return err
+		}
+
+		cp.headers[uint32(entry.Header.LedgerSeq)] = db.LedgerHeaderRow{
+			Hash:           hex.EncodeToString(entry.Hash[:]),
+			PrevHash:       hex.EncodeToString(entry.Header.PreviousLedgerHash[:]),
+			BucketListHash: hex.EncodeToString(entry.Header.BucketListHash[:]),
+			LedgerSeq:      int(entry.Header.LedgerSeq),
+			CloseTime:      int64(entry.Header.ScpValue.CloseTime),
+			Data:           entry.Header,
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.read("transactions", chk, func(s *historyarchive.XdrStream) error {
+		var entry xdr.TransactionHistoryEntry
+
+		if err := s.ReadOne(&entry); err != nil {
+			return err
+		}
+
+		byHash := make(map[xdr.Hash]xdr.TransactionEnvelope, len(entry.TxSet.Txs))
+
+		for _, envelope := range entry.TxSet.Txs {
+			hash, err := hashTransaction(envelope, c.passphrase)
+			if err != nil {
+				return err
+			}
+
+			byHash[hash] = envelope
+		}
+
+		envelopes[uint32(entry.LedgerSeq)] = byHash
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.read("results", chk, func(s *historyarchive.XdrStream) error {
+		var entry xdr.TransactionHistoryResultEntry
+
+		if err := s.ReadOne(&entry); err != nil {
+			return err
+		}
+
+		seq := uint32(entry.LedgerSeq)
+		txs := make([]db.TxHistoryRow, len(entry.TxResultSet.Results))
+
+		for n, result := range entry.TxResultSet.Results {
+			envelope, ok := envelopes[seq][result.TransactionHash]
+			if !ok {
+				return fmt.Errorf("ledger %d: no envelope for transaction %x, check --network-passphrase", seq, result.TransactionHash)
+			}
+
+			txs[n] = db.TxHistoryRow{
+				ID:        hex.EncodeToString(result.TransactionHash[:]),
+				LedgerSeq: int(seq),
+				Index:     n + 1,
+				Envelope:  envelope,
+				Result:    result,
+				Meta:      emptyMeta(envelope),
+			}
+		}
+
+		cp.txs[seq] = txs
+
+		return nil
+	})
+
+	return cp, err
+}
+
+// read calls fn for every entry of the checkpoint file of the category
+func (c *Client) read(category string, chk uint32, fn func(s *historyarchive.XdrStream) error) error {
+	started := time.Now()
+
+	s, err := c.archive.GetXdrStream(historyarchive.CategoryCheckpointPath(category, chk))
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	for {
+		err := fn(s)
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Checkpoint %d %s read in %s", chk, category, time.Since(started).Round(time.Millisecond))
+
+	return nil
+}
+
+// emptyMeta returns meta without changes, serializers expect operation meta for every operation
+func emptyMeta(envelope xdr.TransactionEnvelope) xdr.TransactionMeta {
+	return xdr.TransactionMeta{
+		V:  1,
+		V1: &xdr.TransactionMetaV1{Operations: make([]xdr.OperationMeta, len(envelope.Operations()))},
+	}
+}
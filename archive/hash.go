@@ -0,0 +1,53 @@
+package archive
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/stellar/go/xdr"
+)
+
+// hashTransaction returns the transaction hash, which is the hash of its signature payload
+func hashTransaction(envelope xdr.TransactionEnvelope, passphrase string) (xdr.Hash, error) {
+	var b bytes.Buffer
+
+	payload := xdr.TransactionSignaturePayload{NetworkId: sha256.Sum256([]byte(passphrase))}
+
+	switch envelope.Type {
+	case xdr.EnvelopeTypeEnvelopeTypeTxV0:
+		// V0 transactions are hashed as V1 transactions with ed25519 source account
+		v0 := envelope.V0.Tx
+		source := v0.SourceAccountEd25519
+
+		payload.TaggedTransaction = xdr.TransactionSignaturePayloadTaggedTransaction{
+			Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+			Tx: &xdr.Transaction{
+				SourceAccount: xdr.MuxedAccount{Type: xdr.CryptoKeyTypeKeyTypeEd25519, Ed25519: &source},
+				Fee:           v0.Fee,
+				SeqNum:        v0.SeqNum,
+				TimeBounds:    v0.TimeBounds,
+				Memo:          v0.Memo,
+				Operations:    v0.Operations,
+			},
+		}
+	case xdr.EnvelopeTypeEnvelopeTypeTx:
+		payload.TaggedTransaction = xdr.TransactionSignaturePayloadTaggedTransaction{
+			Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+			Tx:   &envelope.V1.Tx,
+		}
+	case xdr.EnvelopeTypeEnvelopeTypeTxFeeBump:
+		payload.TaggedTransaction = xdr.TransactionSignaturePayloadTaggedTransaction{
+			Type:    xdr.EnvelopeTypeEnvelopeTypeTxFeeBump,
+			FeeBump: &envelope.FeeBump.Tx,
+		}
+	default:
+		return xdr.Hash{}, fmt.Errorf("unknown tx envelope type %s", envelope.Type)
+	}
+
+	if _, err := xdr.Marshal(&b, payload); err != nil {
+		return xdr.Hash{}, err
+	}
+
+	return sha256.Sum256(b.Bytes()), nil
+}
@@ -0,0 +1,285 @@
+// Package archive reads ledgers from Stellar history archives as an export source
+package archive
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/support/historyarchive"
+
+	"github.com/astroband/astrologer/db"
+)
+
+// latestCheckInterval how often the archive root is polled for a new checkpoint
+const latestCheckInterval = time.Minute
+
+// Client reads ledger headers, transactions and results from checkpoint files.
+// Archives have no transaction meta, so documents derived from ledger entry changes are not produced.
+type Client struct {
+	readOnlyAdapter
+
+	archive    *historyarchive.Archive
+	passphrase string
+
+	mutex   sync.Mutex
+	cache   map[uint32]*checkpoint
+	loaded  []uint32
+	last    int
+	checked time.Time
+}
+
+// Connect connects to the history archive at archive+https://, archive+s3:// or archive:// (https) URL
+func Connect(u *url.URL, passphrase string) (*Client, error) {
+	target := *u
+	target.Scheme = strings.TrimPrefix(u.Scheme, "archive+")
+
+	if target.Scheme == "archive" {
+		target.Scheme = "https"
+	}
+
+	query := target.Query()
+	target.RawQuery = ""
+
+	archive, err := historyarchive.Connect(target.String(), historyarchive.ConnectOptions{
+		S3Region:   query.Get("region"),
+		S3Endpoint: query.Get("endpoint"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to history archive %s: %v", target.Host, err)
+	}
+
+	log.Println("Reading ledgers from history archive", target.String())
+
+	return &Client{archive: archive, passphrase: passphrase, cache: make(map[uint32]*checkpoint)}, nil
+}
+
+// LedgerHeaderRowCount returns the number of ledgers in the range published to the archive
+func (c *Client) LedgerHeaderRowCount(first int, last int) (int, error) {
+	latest, err := c.latest()
+	if err != nil {
+		return 0, err
+	}
+
+	if first < 1 {
+		first = 1
+	}
+
+	if last > latest {
+		last = latest
+	}
+
+	if last < first {
+		return 0, nil
+	}
+
+	return last - first + 1, nil
+}
+
+// LedgerHeaderRowFetchBatch returns the batch of ledgers
+func (c *Client) LedgerHeaderRowFetchBatch(n int, start int, batchSize int) ([]db.LedgerHeaderRow, error) {
+	first := start + n*batchSize
+	return c.LedgerHeaderRowFetchRange(first, first+batchSize-1)
+}
+
+// LedgerHeaderRowFetchRange returns ledgers of the range in order
+func (c *Client) LedgerHeaderRowFetchRange(low int, high int) (rows []db.LedgerHeaderRow, err error) {
+	latest, err := c.latest()
+	if err != nil {
+		return nil, err
+	}
+
+	if high > latest {
+		high = latest
+	}
+
+	for seq := low; seq <= high; seq++ {
+		cp, err := c.checkpoint(seq)
+		if err != nil {
+			return nil, err
+		}
+
+		if row, ok := cp.headers[uint32(seq)]; ok {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows, nil
+}
+
+// LedgerHeaderLastRow returns the last ledger published to the archive
+func (c *Client) LedgerHeaderLastRow() (*db.LedgerHeaderRow, error) {
+	latest, err := c.latest()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ledger(latest)
+}
+
+// LedgerHeaderFirstRow returns the genesis ledger
+func (c *Client) LedgerHeaderFirstRow() (*db.LedgerHeaderRow, error) {
+	return c.ledger(1)
+}
+
+// LedgerHeaderNext returns the ledger following seq or nil if it is not published yet
+func (c *Client) LedgerHeaderNext(seq int) (*db.LedgerHeaderRow, error) {
+	return c.ledger(seq + 1)
+}
+
+// LedgerHeaderClosedAfter returns the first ledger closed at or after t, close times grow with seq so ledgers are bisected
+func (c *Client) LedgerHeaderClosedAfter(t time.Time) (*db.LedgerHeaderRow, error) {
+	latest, err := c.latest()
+	if err != nil {
+		return nil, err
+	}
+
+	low, high := 1, latest+1
+
+	for low < high {
+		mid := (low + high) / 2
+
+		row, err := c.ledger(mid)
+		if err != nil {
+			return nil, err
+		}
+
+		if row != nil && row.CloseTime >= t.Unix() {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+
+	return c.ledger(low)
+}
+
+// LedgerHeaderGaps returns nothing, archives have no gaps
+func (c *Client) LedgerHeaderGaps() ([]db.Gap, error) {
+	return nil, nil
+}
+
+// TxHistoryRowForSeq returns transactions of the ledger in apply order
+func (c *Client) TxHistoryRowForSeq(seq int) ([]db.TxHistoryRow, error) {
+	cp, err := c.checkpoint(seq)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := cp.txs[uint32(seq)]
+	if txs == nil {
+		return []db.TxHistoryRow{}, nil
+	}
+
+	return txs, nil
+}
+
+// TxCountsInRange returns the number of transactions per ledger
+func (c *Client) TxCountsInRange(first int, last int) (map[int]int, error) {
+	counts := make(map[int]int)
+
+	err := c.eachLedger(first, last, func(seq int, txs []db.TxHistoryRow) {
+		if len(txs) > 0 {
+			counts[seq] = len(txs)
+		}
+	})
+
+	return counts, err
+}
+
+// TxStatsInRange counts transactions and operations of the range, archives have no database storage size
+func (c *Client) TxStatsInRange(first int, last int) (s db.TxStats, err error) {
+	err = c.eachLedger(first, last, func(seq int, txs []db.TxHistoryRow) {
+		for n := range txs {
+			s.Add(&txs[n])
+		}
+	})
+
+	return s, err
+}
+
+// TxFeeHistoryRowsForRows returns fee rows without changes, archives have no fee meta
+func (c *Client) TxFeeHistoryRowsForRows(rows []db.TxHistoryRow) ([]db.TxFeeHistoryRow, error) {
+	fees := make([]db.TxFeeHistoryRow, len(rows))
+
+	for n, row := range rows {
+		fees[n] = db.TxFeeHistoryRow{TxID: row.ID, LedgerSeq: row.LedgerSeq, Index: row.Index}
+	}
+
+	return fees, nil
+}
+
+// ReplicationLag returns false, archive is not a replica
+func (c *Client) ReplicationLag() (lag time.Duration, ok bool, err error) {
+	return 0, false, nil
+}
+
+// Session returns the Client itself, archive reads are not routed
+func (c *Client) Session() db.Adapter {
+	return c
+}
+
+// eachLedger calls fn with transactions of every published ledger of the range
+func (c *Client) eachLedger(first int, last int, fn func(seq int, txs []db.TxHistoryRow)) error {
+	latest, err := c.latest()
+	if err != nil {
+		return err
+	}
+
+	for seq := first; seq <= last && seq <= latest; seq++ {
+		cp, err := c.checkpoint(seq)
+		if err != nil {
+			return err
+		}
+
+		fn(seq, cp.txs[uint32(seq)])
+	}
+
+	return nil
+}
+
+func (c *Client) ledger(seq int) (*db.LedgerHeaderRow, error) {
+	latest, err := c.latest()
+	if err != nil {
+		return nil, err
+	}
+
+	if seq < 1 || seq > latest {
+		return nil, nil
+	}
+
+	cp, err := c.checkpoint(seq)
+	if err != nil {
+		return nil, err
+	}
+
+	row, ok := cp.headers[uint32(seq)]
+	if !ok {
+		return nil, nil
+	}
+
+	return &row, nil
+}
+
+// latest returns the last ledger published to the archive, checked once per latestCheckInterval
+func (c *Client) latest() (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if time.Since(c.checked) < latestCheckInterval {
+		return c.last, nil
+	}
+
+	has, err := c.archive.GetRootHAS()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read history archive state: %v", err)
+	}
+
+	c.last = int(has.CurrentLedger)
+	c.checked = time.Now()
+
+	return c.last, nil
+}
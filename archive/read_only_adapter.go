@@ -0,0 +1,26 @@
+package archive
+
+import (
+	"fmt"
+
+	"github.com/astroband/astrologer/db"
+)
+
+// readOnlyAdapter covers db.Adapter methods reading current ledger state, which archives do not have
+type readOnlyAdapter struct{}
+
+func (readOnlyAdapter) AccountRowForID(id string) (*db.AccountRow, error) {
+	return nil, unsupported("account lookup")
+}
+
+func (readOnlyAdapter) TrustLineRowsForAccount(id string) ([]db.TrustLineRow, error) {
+	return nil, unsupported("trust line lookup")
+}
+
+func (readOnlyAdapter) MemoRequiredAccounts() ([]string, error) {
+	return nil, unsupported("--memo-required, use --memo-required-accounts file")
+}
+
+func unsupported(what string) error {
+	return fmt.Errorf("history archive source does not support %s", what)
+}
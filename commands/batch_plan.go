@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"github.com/astroband/astrologer/config"
+)
+
+// planBatches splits the range into batches of BatchSize ledgers
+func (cmd *ExportCommand) planBatches(r config.LedgerRange, count int) (batches config.LedgerRanges) {
+	for i := 0; i < cmd.blockCount(count); i++ {
+		low := r.First + i*cmd.Config.BatchSize
+		batches = append(batches, config.LedgerRange{First: low, Last: min(low+cmd.Config.BatchSize-1, r.Last)})
+	}
+
+	return batches
+}
+
+// min returns the smaller of the ledger sequences, go.mod predates the builtin
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// planWeightedBatches splits the range into the same number of batches as planBatches,
+// but balances them by estimated document count instead of ledger count
+func (cmd *ExportCommand) planWeightedBatches(r config.LedgerRange, count int) (batches config.LedgerRanges, err error) {
+	blocks := cmd.blockCount(count)
+
+	txCounts, err := cmd.DB.TxCountsInRange(r.First, r.Last)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every ledger produces a header document, every transaction produces at least a transaction document
+	total := r.Last - r.First + 1
+	for _, c := range txCounts {
+		total += c
+	}
+
+	target := total / blocks
+	if total%blocks > 0 {
+		target++
+	}
+
+	low, weight := r.First, 0
+
+	for seq := r.First; seq <= r.Last; seq++ {
+		weight += 1 + txCounts[seq]
+
+		if weight >= target || seq == r.Last {
+			batches = append(batches, config.LedgerRange{First: low, Last: seq})
+			low, weight = seq+1, 0
+		}
+	}
+
+	return batches, nil
+}
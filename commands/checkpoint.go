@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/astroband/astrologer/config"
+)
+
+// exportCheckpoint tracks export progress in the file, so the crashed export can be continued with --resume
+type exportCheckpoint struct {
+	path  string
+	mutex sync.Mutex
+
+	// Ranges requested by the first run, Done batches are fully indexed
+	Ranges    string    `json:"ranges"`
+	Done      string    `json:"done"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// readCheckpoint loads the checkpoint file, returns nil if it does not exist
+func readCheckpoint(path string) (*exportCheckpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := &exportCheckpoint{path: path}
+
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// newCheckpoint creates the checkpoint for ranges, nothing is written until the first batch is done
+func newCheckpoint(path string, ranges config.LedgerRanges) *exportCheckpoint {
+	return &exportCheckpoint{path: path, Ranges: ranges.String()}
+}
+
+// ranges returns requested and done ranges
+func (c *exportCheckpoint) ranges() (requested, done config.LedgerRanges, err error) {
+	if err := requested.Set(c.Ranges); err != nil {
+		return nil, nil, err
+	}
+
+	if err := done.Set(c.Done); err != nil {
+		return nil, nil, err
+	}
+
+	return requested, done, nil
+}
+
+// pending returns requested ranges not done yet
+func (c *exportCheckpoint) pending() (config.LedgerRanges, error) {
+	requested, done, err := c.ranges()
+	if err != nil {
+		return nil, err
+	}
+
+	return subtractRanges(requested, done), nil
+}
+
+// markDone records the batch as fully indexed and rewrites the file
+func (c *exportCheckpoint) markDone(batch config.LedgerRange) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	_, done, err := c.ranges()
+	if err != nil {
+		return err
+	}
+
+	done = mergeRanges(append(done, batch))
+
+	c.Done = done.String()
+	c.UpdatedAt = time.Now().UTC()
+
+	return c.write()
+}
+
+// write replaces the file atomically, so a crash never leaves it truncated
+func (c *exportCheckpoint) write() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, c.path)
+}
+
+// remove deletes the file once the export is complete
+func (c *exportCheckpoint) remove() error {
+	err := os.Remove(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// mergeRanges sorts ranges and joins overlapping and adjacent ones
+func mergeRanges(ranges config.LedgerRanges) (merged config.LedgerRanges) {
+	sorted := append(config.LedgerRanges(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].First < sorted[j].First })
+
+	for _, r := range sorted {
+		if n := len(merged); n > 0 && r.First <= merged[n-1].Last+1 {
+			if r.Last > merged[n-1].Last {
+				merged[n-1].Last = r.Last
+			}
+
+			continue
+		}
+
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+// subtractRanges returns parts of ranges not covered by done
+func subtractRanges(ranges, done config.LedgerRanges) (result config.LedgerRanges) {
+	done = mergeRanges(done)
+
+	for _, r := range mergeRanges(ranges) {
+		for _, d := range done {
+			if d.Last < r.First || d.First > r.Last {
+				continue
+			}
+
+			if d.First > r.First {
+				result = append(result, config.LedgerRange{First: r.First, Last: d.First - 1})
+			}
+
+			r.First = d.Last + 1
+
+			if r.First > r.Last {
+				break
+			}
+		}
+
+		if r.First <= r.Last {
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
@@ -1,8 +1,8 @@
 package commands
 
 import (
+	"context"
 	"fmt"
-	"log"
 
 	"github.com/astroband/astrologer/es"
 )
@@ -14,29 +14,17 @@ type CreateIndexCommandConfig struct {
 
 // CreateIndexCommand represents the `create-index` CLI command
 type CreateIndexCommand struct {
-	ES     es.Adapter
+	ES     es.Sink
 	Config CreateIndexCommandConfig
 }
 
-// Execute creates Astrologer indices in ElasticSearch
-func (cmd *CreateIndexCommand) Execute() {
-	for name, def := range es.GetIndexDefinitions() {
-		cmd.refreshIndex(name, def)
+// Run creates Astrologer schema in the output, missing indices and fields are created on rerun
+func (cmd *CreateIndexCommand) Run(ctx context.Context) error {
+	if err := cmd.ES.CreateSchema(cmd.Config.Force); err != nil {
+		return err
 	}
+
 	fmt.Println("Indicies created successfully!")
-}
 
-func (cmd *CreateIndexCommand) refreshIndex(name es.IndexName, schema es.IndexDefinition) {
-	if !cmd.ES.IndexExists(name) {
-		cmd.ES.CreateIndex(name, schema)
-		log.Printf("%s index created!", name)
-	} else {
-		if cmd.Config.Force {
-			cmd.ES.DeleteIndex(name)
-			cmd.ES.CreateIndex(name, schema)
-			log.Printf("%s index recreated!", name)
-		} else {
-			log.Printf("%s index found, skipping...", name)
-		}
-	}
+	return nil
 }
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/astroband/astrologer/es"
+)
+
+// DumpMappingsCommandConfig represents configuration options for the `dump-mappings` CLI command
+type DumpMappingsCommandConfig struct {
+	Indices []string
+	Out     string
+}
+
+// DumpMappingsCommand represents the `dump-mappings` CLI command
+type DumpMappingsCommand struct {
+	Config DumpMappingsCommandConfig
+}
+
+// Run prints settings and mappings of indices as create-index would create them,
+// or writes them into <index>.json files of the output directory
+func (cmd *DumpMappingsCommand) Run(ctx context.Context) error {
+	bodies, err := es.SchemaBodies()
+	if err != nil {
+		return err
+	}
+
+	if len(cmd.Config.Indices) > 0 {
+		selected := make(map[es.IndexName]json.RawMessage, len(cmd.Config.Indices))
+
+		for _, index := range cmd.Config.Indices {
+			body, ok := bodies[es.IndexName(index)]
+			if !ok {
+				return fmt.Errorf("unknown index %s", index)
+			}
+
+			selected[es.IndexName(index)] = body
+		}
+
+		bodies = selected
+	}
+
+	if cmd.Config.Out == "" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(bodies)
+	}
+
+	if err := os.MkdirAll(cmd.Config.Out, 0755); err != nil {
+		return err
+	}
+
+	for name, body := range bodies {
+		var b bytes.Buffer
+
+		if err := json.Indent(&b, body, "", "  "); err != nil {
+			return err
+		}
+
+		b.WriteByte('\n')
+
+		if err := ioutil.WriteFile(filepath.Join(cmd.Config.Out, string(name)+".json"), b.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,8 +1,14 @@
 package commands
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/astroband/astrologer/es"
 	"github.com/olekukonko/tablewriter"
@@ -10,18 +16,61 @@ import (
 
 const step = 10000
 
+// coverageBarWidth is the width of bucket coverage bars in characters
+const coverageBarWidth = 20
+
+// EsStatsCommandConfig represents configuration options for the `es-stats` CLI command
+type EsStatsCommandConfig struct {
+	Watch       time.Duration
+	MetricsFile string
+
+	// SerializeOptions select ledger indices to show
+	SerializeOptions es.SerializeOptions
+}
+
 // EsStatsCommand represents the `es-stats` CLI command
 type EsStatsCommand struct {
-	ES es.Adapter
+	ES     es.Adapter
+	Config EsStatsCommandConfig
+
+	prevCount int
+	prevTime  time.Time
 }
 
-// Execute collects ledger staticstics for the current ES cluster
-func (cmd *EsStatsCommand) Execute() {
+// Run collects ledger staticstics for the current ES cluster, with --watch until ctx is cancelled
+func (cmd *EsStatsCommand) Run(ctx context.Context) error {
+	if cmd.Config.Watch == 0 {
+		return cmd.render()
+	}
+
+	for {
+		fmt.Print("\033[H\033[2J")
+
+		if err := cmd.render(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(cmd.Config.Watch):
+		}
+	}
+}
+
+func (cmd *EsStatsCommand) render() error {
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"From", "To", "Doc_count"})
+	table.SetHeader([]string{"From", "To", "Doc_count", "Coverage"})
 
-	min, max := cmd.ES.MinMaxSeq()
-	buckets := cmd.esRanges(min, max)
+	min, max, err := cmd.ES.MinMaxRange()
+	if err != nil {
+		return err
+	}
+
+	buckets, err := cmd.esRanges(min, max)
+	if err != nil {
+		return err
+	}
 
 	for i := 0; i < len(buckets); i++ {
 		bucket := buckets[i].(map[string]interface{})
@@ -33,13 +82,164 @@ func (cmd *EsStatsCommand) Execute() {
 			strconv.Itoa(from),
 			strconv.Itoa(to),
 			strconv.Itoa(count),
+			coverageBar(count, to-from),
 		})
 	}
 
+	count, err := cmd.ES.LedgerCountInRange(min, max)
+	if err != nil {
+		return err
+	}
+
+	closeTime, err := cmd.ES.LastLedgerCloseTime()
+	if err != nil {
+		return err
+	}
+
+	coverage := float64(count) / float64(max-min+1)
+	lag := time.Since(closeTime)
+
+	table.SetFooter([]string{"Coverage", fmt.Sprintf("%.2f%%", coverage*100), strconv.Itoa(count), ""})
 	table.Render()
+
+	stats, err := cmd.ES.IndicesStats(es.LedgerIndices(cmd.Config.SerializeOptions))
+	if err != nil {
+		return err
+	}
+
+	renderIndicesStats(stats)
+
+	gaps, err := (&FillGapsCommand{ES: cmd.ES}).scan(min, max)
+	if err != nil {
+		return err
+	}
+
+	if len(gaps) > 0 {
+		fmt.Println("Missing ranges:", gaps.String())
+	}
+
+	fmt.Printf("Last ledger: %d, lag: %s", max, lag.Round(time.Second))
+
+	if !cmd.prevTime.IsZero() {
+		rate := float64(count-cmd.prevCount) / time.Since(cmd.prevTime).Seconds()
+		fmt.Printf(", rate: %.2f ledgers/s", rate)
+	}
+
+	fmt.Println()
+
+	cmd.prevCount = count
+	cmd.prevTime = time.Now()
+
+	if cmd.Config.MetricsFile != "" {
+		cmd.writeMetrics(min, max, count, coverage, lag, stats)
+	}
+
+	return nil
 }
 
-func (cmd *EsStatsCommand) esRanges(min int, max int) []interface{} {
+// renderIndicesStats prints document counts, ledger ranges and disk usage of indices
+func renderIndicesStats(stats []es.IndexStats) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Index", "Docs", "Min ledger", "Max ledger", "Size"})
+
+	var docs, size int64
+
+	for _, s := range stats {
+		table.Append([]string{
+			string(s.Index),
+			strconv.FormatInt(s.Docs, 10),
+			strconv.Itoa(s.MinLedger),
+			strconv.Itoa(s.MaxLedger),
+			humanBytes(s.SizeBytes),
+		})
+
+		docs += s.Docs
+		size += s.SizeBytes
+	}
+
+	table.SetFooter([]string{"Total", strconv.FormatInt(docs, 10), "", "", humanBytes(size)})
+	table.Render()
+}
+
+// coverageBar draws the share of ledgers present in the bucket
+func coverageBar(count, total int) string {
+	if total <= 0 {
+		return ""
+	}
+
+	filled := count * coverageBarWidth / total
+	if filled > coverageBarWidth {
+		filled = coverageBarWidth
+	}
+
+	return strings.Repeat("#", filled) + strings.Repeat(".", coverageBarWidth-filled)
+}
+
+// humanBytes formats the size with binary units
+func humanBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// writeMetrics writes metrics in Prometheus text format, suitable for node_exporter textfile collector
+func (cmd *EsStatsCommand) writeMetrics(min, max, count int, coverage float64, lag time.Duration, stats []es.IndexStats) {
+	metrics := fmt.Sprintf(
+		"# HELP astrologer_es_ledger_min Minimum ledger seq indexed\n"+
+			"# TYPE astrologer_es_ledger_min gauge\n"+
+			"astrologer_es_ledger_min %d\n"+
+			"# HELP astrologer_es_ledger_max Maximum ledger seq indexed\n"+
+			"# TYPE astrologer_es_ledger_max gauge\n"+
+			"astrologer_es_ledger_max %d\n"+
+			"# HELP astrologer_es_ledger_count Number of ledgers indexed\n"+
+			"# TYPE astrologer_es_ledger_count gauge\n"+
+			"astrologer_es_ledger_count %d\n"+
+			"# HELP astrologer_es_coverage_ratio Share of ledgers indexed between min and max\n"+
+			"# TYPE astrologer_es_coverage_ratio gauge\n"+
+			"astrologer_es_coverage_ratio %f\n"+
+			"# HELP astrologer_es_lag_seconds Seconds since the close time of the last indexed ledger\n"+
+			"# TYPE astrologer_es_lag_seconds gauge\n"+
+			"astrologer_es_lag_seconds %f\n",
+		min, max, count, coverage, lag.Seconds(),
+	)
+
+	metrics += "# HELP astrologer_es_index_docs Number of documents in the index\n" +
+		"# TYPE astrologer_es_index_docs gauge\n"
+
+	for _, s := range stats {
+		metrics += fmt.Sprintf("astrologer_es_index_docs{index=%q} %d\n", s.Index, s.Docs)
+	}
+
+	metrics += "# HELP astrologer_es_index_size_bytes Disk usage of the index including replicas\n" +
+		"# TYPE astrologer_es_index_size_bytes gauge\n"
+
+	for _, s := range stats {
+		metrics += fmt.Sprintf("astrologer_es_index_size_bytes{index=%q} %d\n", s.Index, s.SizeBytes)
+	}
+
+	tmp := cmd.Config.MetricsFile + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, []byte(metrics), 0644); err != nil {
+		log.Println("Failed to write metrics:", err)
+		return
+	}
+
+	if err := os.Rename(tmp, cmd.Config.MetricsFile); err != nil {
+		log.Println("Failed to write metrics:", err)
+	}
+}
+
+func (cmd *EsStatsCommand) esRanges(min int, max int) ([]interface{}, error) {
 	var ranges []map[string]interface{}
 
 	for i := min; i < max; i += step {
@@ -50,8 +250,10 @@ func (cmd *EsStatsCommand) esRanges(min int, max int) []interface{} {
 		ranges = append(ranges, map[string]interface{}{"from": i, "to": to})
 	}
 
-	aggs := cmd.ES.LedgerSeqRangeQuery(ranges)
-	buckets := aggs["buckets"].([]interface{})
+	aggs, err := cmd.ES.LedgerSeqRangeQuery(ranges)
+	if err != nil {
+		return nil, err
+	}
 
-	return buckets
+	return aggs["buckets"].([]interface{}), nil
 }
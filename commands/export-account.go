@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/astroband/astrologer/db"
+	"github.com/astroband/astrologer/es"
+)
+
+// ExportAccountCommandConfig represents configuration options for the `export-account` CLI command
+type ExportAccountCommandConfig struct {
+	AccountID string
+	Out       string
+}
+
+// ExportAccountCommand represents the `export-account` CLI command
+type ExportAccountCommand struct {
+	ES     es.Adapter
+	DB     db.Adapter
+	Config ExportAccountCommandConfig
+}
+
+type accountBundleManifest struct {
+	AccountID   string         `json:"account_id"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Exists      bool           `json:"exists"`
+	Counts      map[string]int `json:"counts"`
+}
+
+// Run writes everything known about the account into the bundle directory
+func (cmd *ExportAccountCommand) Run(ctx context.Context) error {
+	id := cmd.Config.AccountID
+
+	if err := os.MkdirAll(cmd.Config.Out, 0755); err != nil {
+		return err
+	}
+
+	account, err := cmd.DB.AccountRowForID(id)
+	if err != nil {
+		return err
+	}
+
+	trustLines, err := cmd.DB.TrustLineRowsForAccount(id)
+	if err != nil {
+		return err
+	}
+
+	manifest := accountBundleManifest{
+		AccountID:   id,
+		GeneratedAt: time.Now().UTC(),
+		Exists:      account != nil,
+		Counts:      map[string]int{"trustlines": len(trustLines)},
+	}
+
+	if err := cmd.write("account.json", account); err != nil {
+		return err
+	}
+
+	if err := cmd.write("trustlines.json", trustLines); err != nil {
+		return err
+	}
+
+	history, err := cmd.ES.AccountHistory(id)
+	if err != nil {
+		return err
+	}
+
+	for index, docs := range history {
+		if docs == nil {
+			docs = []json.RawMessage{}
+		}
+
+		manifest.Counts[string(index)] = len(docs)
+
+		if err := cmd.write(string(index)+".json", docs); err != nil {
+			return err
+		}
+
+		log.Printf("%d documents found in %s index", len(docs), index)
+	}
+
+	if err := cmd.write("manifest.json", manifest); err != nil {
+		return err
+	}
+
+	log.Println("Account bundle written to", cmd.Config.Out)
+
+	return nil
+}
+
+func (cmd *ExportAccountCommand) write(name string, data interface{}) error {
+	file, err := os.Create(filepath.Join(cmd.Config.Out, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(data)
+}
@@ -2,10 +2,18 @@ package commands
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"log"
-	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/gammazero/workerpool"
+	"github.com/olekukonko/tablewriter"
 	progressbar "github.com/schollz/progressbar/v2"
 
 	"github.com/astroband/astrologer/config"
@@ -13,124 +21,951 @@ import (
 	"github.com/astroband/astrologer/es"
 )
 
-var (
-	bar *progressbar.ProgressBar
-)
-
 // ExportCommandConfig represents configuration options for `export` CLI command
 type ExportCommandConfig struct {
 	Start      config.NumberWithSign
 	Count      int
+	Ranges     config.LedgerRanges
 	RetryCount int
 	DryRun     bool
+	Clamp      bool
+	Verify     bool
 	BatchSize  int
+
+	// Concurrency batches are exported at once
+	Concurrency int
+
+	// Verbose prints indexed data instead of the progress bar
+	Verbose bool
+
+	// End is the last ledger to export instead of Count, zero means not set
+	End int
+
+	// From and To select ledgers by close time instead of Start and Count, To is exclusive
+	From time.Time
+	To   time.Time
+
+	// BalanceBatches balances batches by estimated document count instead of ledger count
+	BalanceBatches bool
+
+	// MaxDuration stops scheduling new batches after the given duration, zero means no limit
+	MaxDuration time.Duration
+
+	// Audit stores hash chain over exported batches
+	Audit bool
+
+	// CheckChain verifies previous ledger hashes before indexing
+	CheckChain bool
+
+	// TradeAggregations builds trade candles for exported ledgers
+	TradeAggregations bool
+
+	// IndexSummary stores end-of-range summary in the export_summaries index
+	IndexSummary bool
+
+	// Optimize disables refresh and replicas during export, restores them and force merges indices afterwards
+	Optimize bool
+
+	// MaxNumSegments force merge target
+	MaxNumSegments int
+
+	// AccountFilters stores Bloom filter of accounts involved in every batch
+	AccountFilters bool
+
+	// MaxDocs and MaxBytes stop scheduling new batches once exceeded, zero means no limit
+	MaxDocs  int
+	MaxBytes int64
+
+	// MaxReplicationLag pauses batches while the database replica lags more, zero disables the check
+	MaxReplicationLag time.Duration
+
+	// Follow switches to ingestion once the export is complete
+	Follow bool
+
+	// Reverse exports the most recent batches first
+	Reverse bool
+
+	// MaxBatchInflight limits batches reading from the database at once, zero means --concurrency
+	MaxBatchInflight int
+
+	// Checkpoint file records fully indexed batches, Resume exports only ranges not recorded there
+	Checkpoint string
+	Resume     bool
+
+	// Accounts limits exported documents to ones involving the accounts, empty exports everything
+	Accounts map[string]bool
+
+	// Assets limits operations, payments, balances and trades to ones involving the asset keys, empty exports everything
+	Assets map[string]bool
+
+	// DryRunOutput file receives bulk payloads which would be indexed, requires DryRun
+	DryRunOutput string
+
+	SerializeOptions es.SerializeOptions
 }
 
 // ExportCommand represents the `export` CLI command
 type ExportCommand struct {
-	ES     es.Adapter
+	ES     es.Sink
 	DB     db.Adapter
 	Config ExportCommandConfig
 
-	firstLedger int
-	lastLedger  int
+	adapter    es.Adapter
+	deadline   time.Time
+	mutex      sync.Mutex
+	remaining  config.LedgerRanges
+	audited    []*es.AuditRecord
+	hours      map[time.Time]bool
+	from, to   time.Time
+	summary    es.ExportSummary
+	throttle   *lagThrottle
+	checkpoint *exportCheckpoint
+	dbSlots    chan struct{}
+	dryRunFile *os.File
+	stopped    error
+	bar        *progressbar.ProgressBar
 }
 
-// Execute starts the export process
-func (cmd *ExportCommand) Execute() {
-	cmd.firstLedger, cmd.lastLedger = cmd.getRange()
+// Run exports ledgers, returns *ExitError if stopped early by --max-duration or the budget.
+// Batches not started because of cancellation or failure are printed as remaining ranges.
+func (cmd *ExportCommand) Run(ctx context.Context) error {
+	ranges := cmd.Config.Ranges
+
+	if cmd.Config.Follow && cmd.Config.DryRun {
+		return errors.New("--follow can not be used with --dry-run")
+	}
+
+	if len(ranges) > 0 && (cmd.Config.Start.Value != 0 || cmd.Config.Count != 0 || cmd.Config.End != 0 ||
+		!cmd.Config.From.IsZero() || !cmd.Config.To.IsZero()) {
+		return errors.New("--ranges and --ranges-file can not be used with start, count, --end, --from or --to")
+	}
+
+	// adapter is nil for write-only outputs
+	cmd.adapter, _ = es.AsAdapter(cmd.ES)
+
+	if cmd.adapter == nil && !cmd.Config.DryRun {
+		if cmd.Config.Verify {
+			return errors.New("--verify requires ElasticSearch output, write-only outputs can not be read back")
+		}
+
+		if cmd.Config.Optimize {
+			return errors.New("--optimize requires ElasticSearch output")
+		}
+
+		if cmd.Config.SerializeOptions.Stats || cmd.Config.TradeAggregations {
+			log.Println("Stats and trade aggregation rollups require ElasticSearch output, skipped")
+		}
+	}
+
+	if cmd.Config.Resume {
+		pending, err := cmd.resume()
+		if err != nil {
+			return err
+		}
+
+		if cmd.checkpoint != nil && len(pending) == 0 {
+			log.Println("Export recorded in", cmd.Config.Checkpoint, "is already complete")
+			return cmd.checkpoint.remove()
+		}
+
+		if cmd.checkpoint != nil {
+			ranges = pending
+		}
+	}
+
+	if len(ranges) == 0 {
+		var err error
+
+		if ranges, err = cmd.positionalRanges(); err != nil {
+			return err
+		}
+	}
+
+	ranges, err := cmd.validateRanges(ranges)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Config.Checkpoint != "" && cmd.checkpoint == nil && !cmd.Config.DryRun {
+		cmd.checkpoint = newCheckpoint(cmd.Config.Checkpoint, ranges)
+	}
+
+	counts := make([]int, len(ranges))
+	total := 0
 
-	total := cmd.DB.LedgerHeaderRowCount(cmd.firstLedger, cmd.lastLedger)
+	for n, r := range ranges {
+		if counts[n], err = cmd.DB.LedgerHeaderRowCount(r.First, r.Last); err != nil {
+			return err
+		}
+
+		total += counts[n]
+
+		log.Println("Exporting ledgers from", r.First, "to", r.Last, "total", counts[n])
+	}
 
 	if total == 0 {
-		log.Fatal("Nothing to export within given range!", cmd.firstLedger, cmd.lastLedger)
+		return fmt.Errorf("nothing to export within given range %s", ranges.String())
+	}
+
+	if cmd.Config.DryRunOutput != "" {
+		if cmd.dryRunFile, err = os.Create(cmd.Config.DryRunOutput); err != nil {
+			return err
+		}
+
+		defer cmd.dryRunFile.Close()
+	}
+
+	if cmd.Config.MaxDuration > 0 {
+		cmd.deadline = time.Now().Add(cmd.Config.MaxDuration)
+	}
+
+	cmd.hours = make(map[time.Time]bool)
+
+	if cmd.Config.MaxBatchInflight > 0 {
+		cmd.dbSlots = make(chan struct{}, cmd.Config.MaxBatchInflight)
+	}
+
+	if cmd.Config.MaxReplicationLag > 0 {
+		cmd.throttle = &lagThrottle{db: cmd.DB, max: cmd.Config.MaxReplicationLag}
+
+		_, ok, err := cmd.DB.ReplicationLag()
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			log.Println("Database is not a streaming replica, --max-replication-lag has no effect")
+		}
+	}
+
+	cmd.summary = es.ExportSummary{Ranges: ranges.String(), StartedAt: time.Now()}
+
+	var relaxed map[es.IndexName]es.IndexSettings
+	indices := es.LedgerIndices(cmd.Config.SerializeOptions)
+
+	if cmd.Config.Optimize && !cmd.Config.DryRun {
+		if relaxed, err = cmd.adapter.RelaxIndices(indices); err != nil {
+			return err
+		}
+	}
+
+	cmd.createBar(total)
+
+	var batches config.LedgerRanges
+
+	for n, r := range ranges {
+		if cmd.Config.BalanceBatches {
+			weighted, err := cmd.planWeightedBatches(r, counts[n])
+			if err != nil {
+				return err
+			}
+
+			batches = append(batches, weighted...)
+		} else {
+			batches = append(batches, cmd.planBatches(r, counts[n])...)
+		}
+	}
+
+	if cmd.Config.Reverse {
+		sort.SliceStable(batches, func(i, j int) bool { return batches[i].First > batches[j].First })
 	}
 
-	log.Println("Exporting ledgers from", cmd.firstLedger, "to", cmd.lastLedger, "total", total)
+	workers := cmd.Config.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
 
-	createBar(total)
+	pool := workerpool.New(workers)
 
-	for i := 0; i < cmd.blockCount(total); i++ {
-		i := i
-		pool.Submit(func() { cmd.exportBlock(i) })
+	for _, batch := range batches {
+		batch := batch
+		pool.Submit(func() { cmd.runBlock(ctx, batch) })
 	}
 
 	pool.StopWait()
-	finishBar()
+	cmd.finishBar()
+
+	// Index settings are restored even if post processing fails
+	postErr := cmd.postProcess()
+
+	if relaxed != nil {
+		if err := cmd.adapter.RestoreIndices(relaxed); err != nil {
+			return err
+		}
+
+		if len(cmd.remaining) == 0 && postErr == nil {
+			if err := cmd.adapter.ForceMerge(indices, cmd.Config.MaxNumSegments); err != nil {
+				return err
+			}
+		}
+	}
+
+	if postErr != nil {
+		return postErr
+	}
+
+	cmd.summary.Finish()
+	cmd.printSummary()
+
+	if cmd.Config.IndexSummary && !cmd.Config.DryRun {
+		var b bytes.Buffer
+
+		if err := es.SerializeForBulk(&cmd.summary, &b); err != nil {
+			return err
+		}
+
+		if _, err := cmd.ES.IndexWithRetries(&b, cmd.Config.RetryCount); err != nil {
+			return err
+		}
+	}
+
+	if len(cmd.remaining) > 0 {
+		sort.Slice(cmd.remaining, func(i, j int) bool { return cmd.remaining[i].First < cmd.remaining[j].First })
+
+		log.Println("Export stopped early:", cmd.stopped)
+		log.Println("Remaining ranges:", cmd.remaining.String())
+		log.Println("Resume with: export --ranges", cmd.remaining.String())
+
+		if cmd.checkpoint != nil {
+			log.Println("Or with: export --checkpoint", cmd.Config.Checkpoint, "--resume")
+		}
+
+		return cmd.stopped
+	}
+
+	if cmd.checkpoint != nil {
+		if err := cmd.checkpoint.remove(); err != nil {
+			log.Println("Failed to remove checkpoint file:", err)
+		}
+	}
+
+	log.Println("Exported", total, "ledgers in", len(ranges), "range(s)")
+
+	if cmd.Config.Follow {
+		return cmd.follow(ctx, ranges)
+	}
+
+	return nil
+}
+
+// postProcess extends the audit chain and rolls up stats and trade aggregations of exported ledgers
+func (cmd *ExportCommand) postProcess() error {
+	if cmd.Config.DryRun {
+		return nil
+	}
+
+	if cmd.Config.Audit {
+		if err := cmd.writeAudit(); err != nil {
+			return err
+		}
+	}
+
+	if cmd.Config.SerializeOptions.Stats && cmd.adapter != nil {
+		if err := cmd.adapter.RollupHourlyStats(cmd.touchedHours()); err != nil {
+			return err
+		}
+	}
+
+	if cmd.Config.TradeAggregations && !cmd.from.IsZero() && cmd.adapter != nil {
+		if err := cmd.adapter.RollupTradeAggregations(cmd.from, cmd.to); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (cmd *ExportCommand) exportBlock(i int) {
+// follow switches to real time ingestion starting right after the last exported ledger
+func (cmd *ExportCommand) follow(ctx context.Context, ranges config.LedgerRanges) error {
+	last := 0
+
+	for _, r := range ranges {
+		if r.Last > last {
+			last = r.Last
+		}
+	}
+
+	ingestConfig := IngestCommandConfig{
+		After:             last,
+		Audit:             cmd.Config.Audit,
+		CheckChain:        cmd.Config.CheckChain,
+		TradeAggregations: cmd.Config.TradeAggregations,
+		SerializeOptions:  cmd.Config.SerializeOptions,
+	}
+
+	if !cmd.deadline.IsZero() {
+		ingestConfig.MaxDuration = time.Until(cmd.deadline)
+	}
+
+	log.Println("Following from ledger", last+1)
+
+	ingest := &IngestCommand{ES: cmd.ES, DB: cmd.DB, Config: ingestConfig}
+
+	return ingest.Run(ctx)
+}
+
+// runBlock exports the batch unless export is stopped, failed batch stops the export
+func (cmd *ExportCommand) runBlock(ctx context.Context, batch config.LedgerRange) {
+	if cmd.stop(ctx, batch) {
+		return
+	}
+
+	if err := cmd.exportBlock(batch); err != nil {
+		cmd.mutex.Lock()
+		defer cmd.mutex.Unlock()
+
+		if cmd.stopped == nil {
+			cmd.stopped = err
+		}
+
+		cmd.remaining = append(cmd.remaining, batch)
+
+		return
+	}
+
+	// The batch is exported again on resume if the checkpoint is not saved, --verify replaces duplicates
+	if cmd.checkpoint != nil {
+		if err := cmd.checkpoint.markDone(batch); err != nil {
+			log.Printf("Failed to save checkpoint after batch %d-%d: %v", batch.First, batch.Last, err)
+		}
+	}
+}
+
+// resume loads the checkpoint file and returns ranges left to export, sets cmd.checkpoint if the file exists
+func (cmd *ExportCommand) resume() (config.LedgerRanges, error) {
+	if cmd.Config.Checkpoint == "" {
+		return nil, errors.New("--resume requires --checkpoint")
+	}
+
+	checkpoint, err := readCheckpoint(cmd.Config.Checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %v", cmd.Config.Checkpoint, err)
+	}
+
+	if checkpoint == nil {
+		log.Println("Checkpoint", cmd.Config.Checkpoint, "not found, starting from scratch")
+		return nil, nil
+	}
+
+	if len(cmd.Config.Ranges) > 0 && cmd.Config.Ranges.String() != checkpoint.Ranges {
+		return nil, fmt.Errorf(
+			"checkpoint %s was recorded for ranges %s, not %s",
+			cmd.Config.Checkpoint, checkpoint.Ranges, cmd.Config.Ranges.String(),
+		)
+	}
+
+	pending, err := checkpoint.pending()
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %v", cmd.Config.Checkpoint, err)
+	}
+
+	log.Println("Resuming export of", checkpoint.Ranges, "done", checkpoint.Done)
+
+	cmd.checkpoint = checkpoint
+
+	return pending, nil
+}
+
+func (cmd *ExportCommand) exportBlock(batch config.LedgerRange) error {
 	var b bytes.Buffer
 
-	rows := cmd.DB.LedgerHeaderRowFetchBatch(i, cmd.firstLedger, cmd.Config.BatchSize)
+	if cmd.throttle != nil {
+		cmd.throttle.wait()
+	}
+
+	release := cmd.acquireDB()
+	defer release()
+
+	// The whole batch is read from one database, replicas may lag differently.
+	// Replica which has not received the batch yet is skipped.
+	source := cmd.DB.Session()
+
+	last, err := source.LedgerHeaderLastRow()
+	if err != nil {
+		return err
+	}
+
+	if last == nil || last.LedgerSeq < batch.Last {
+		source = cmd.DB
+	}
+
+	rows, err := source.LedgerHeaderRowFetchRange(batch.First, batch.Last)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Config.CheckChain {
+		if err := cmd.checkChain(rows); err != nil {
+			return err
+		}
+	}
 
 	for n := 0; n < len(rows); n++ {
-		txs := cmd.DB.TxHistoryRowForSeq(rows[n].LedgerSeq)
-		fees := cmd.DB.TxFeeHistoryRowsForRows(txs)
+		txs, fees, err := ledgerTransactions(source, rows[n].LedgerSeq)
+		if err != nil {
+			return err
+		}
+
+		err = es.SerializeLedger(rows[n], txs, fees, &b, cmd.Config.SerializeOptions)
+
+		if err != nil {
+			return fmt.Errorf("failed to serialize ledger %d: %v", rows[n].LedgerSeq, err)
+		}
+
+		if cmd.Config.SerializeOptions.Stats || cmd.Config.TradeAggregations {
+			cmd.touch(time.Unix(rows[n].CloseTime, 0))
+		}
 
-		err := es.SerializeLedger(rows[n], txs, fees, &b)
+		if !cmd.Config.Verbose {
+			cmd.bar.Add(1)
+		}
+	}
 
+	// Indexing does not touch the database
+	release()
+
+	if len(cmd.Config.Accounts) > 0 {
+		filtered, err := es.FilterAccounts(b.Bytes(), cmd.Config.Accounts)
 		if err != nil {
-			log.Fatalf("Failed to ingest ledger %d: %v\n", rows[n].LedgerSeq, err)
+			return err
 		}
 
-		if !*config.Verbose {
-			bar.Add(1)
+		b.Reset()
+		b.Write(filtered)
+	}
+
+	if len(cmd.Config.Assets) > 0 {
+		filtered, err := es.FilterAssets(b.Bytes(), cmd.Config.Assets)
+		if err != nil {
+			return err
 		}
+
+		b.Reset()
+		b.Write(filtered)
 	}
 
-	if *config.Verbose {
+	if cmd.Config.Verbose {
 		log.Println(b.String())
 	}
 
+	if cmd.Config.Audit && len(rows) > 0 {
+		record := es.NewAuditRecord(rows[0].LedgerSeq, rows[len(rows)-1].LedgerSeq, b.Bytes(), "")
+
+		cmd.mutex.Lock()
+		cmd.audited = append(cmd.audited, record)
+		cmd.mutex.Unlock()
+	}
+
+	if cmd.Config.AccountFilters && len(rows) > 0 {
+		if err := es.SerializeForBulk(es.NewAccountFilter(rows[0].LedgerSeq, rows[len(rows)-1].LedgerSeq, b.Bytes()), &b); err != nil {
+			return err
+		}
+	}
+
+	retries := 0
+
+	if cmd.dryRunFile != nil {
+		if err := cmd.writeDryRun(b.Bytes()); err != nil {
+			return err
+		}
+	}
+
 	if !cmd.Config.DryRun {
-		cmd.ES.IndexWithRetries(&b, cmd.Config.RetryCount)
+		var err error
+
+		if retries, err = cmd.ES.IndexWithRetries(&b, cmd.Config.RetryCount); err != nil {
+			return err
+		}
+
+		if cmd.Config.Verify && len(rows) > 0 {
+			verifyRetries, err := cmd.verifyBatch(&b, rows[0].LedgerSeq, rows[len(rows)-1].LedgerSeq)
+			if err != nil {
+				return err
+			}
+
+			retries += verifyRetries
+		}
+	}
+
+	cmd.mutex.Lock()
+	defer cmd.mutex.Unlock()
+
+	cmd.summary.Retries += retries
+
+	return cmd.summary.Add(b.Bytes())
+}
+
+// writeDryRun appends the bulk payload of the batch to --dry-run-output, payloads of concurrent batches are not interleaved
+func (cmd *ExportCommand) writeDryRun(payload []byte) error {
+	cmd.mutex.Lock()
+	defer cmd.mutex.Unlock()
+
+	if _, err := cmd.dryRunFile.Write(payload); err != nil {
+		return fmt.Errorf("failed to write %s: %v", cmd.Config.DryRunOutput, err)
 	}
+
+	return nil
 }
 
-func (cmd *ExportCommand) index(b *bytes.Buffer, retry int) {
-	indexed := cmd.ES.BulkInsert(b)
+// acquireDB blocks until the batch may read from the database, returned func releases the slot and may be called twice
+func (cmd *ExportCommand) acquireDB() (release func()) {
+	var once sync.Once
+
+	if cmd.dbSlots == nil {
+		return func() {}
+	}
+
+	cmd.dbSlots <- struct{}{}
 
-	if !indexed {
-		if retry > cmd.Config.RetryCount {
-			log.Fatal("Retries for bulk failed, aborting")
+	return func() { once.Do(func() { <-cmd.dbSlots }) }
+}
+
+// stop checks cancellation, the deadline and the budget before the batch is started,
+// records the batch as remaining if the export is stopped
+func (cmd *ExportCommand) stop(ctx context.Context, batch config.LedgerRange) bool {
+	cmd.mutex.Lock()
+	defer cmd.mutex.Unlock()
+
+	if cmd.stopped == nil {
+		switch {
+		case ctx.Err() != nil:
+			cmd.stopped = ctx.Err()
+		case !cmd.deadline.IsZero() && time.Now().After(cmd.deadline):
+			cmd.stopped = &ExitError{Code: ExitCodeDeadline, Message: "max duration exceeded"}
+		case cmd.Config.MaxDocs > 0 && cmd.summary.Documents >= cmd.Config.MaxDocs:
+			cmd.stopped = &ExitError{
+				Code:    ExitCodeBudget,
+				Message: fmt.Sprintf("document budget exceeded (%d of %d)", cmd.summary.Documents, cmd.Config.MaxDocs),
+			}
+		case cmd.Config.MaxBytes > 0 && cmd.summary.Bytes >= cmd.Config.MaxBytes:
+			cmd.stopped = &ExitError{
+				Code:    ExitCodeBudget,
+				Message: fmt.Sprintf("byte budget exceeded (%d of %d)", cmd.summary.Bytes, cmd.Config.MaxBytes),
+			}
 		}
+	}
+
+	if cmd.stopped == nil {
+		return false
+	}
+
+	cmd.remaining = append(cmd.remaining, batch)
 
-		delay := time.Duration((rand.Intn(10) + 5))
-		time.Sleep(delay * time.Second)
+	return true
+}
+
+// verifyBatch checks that all documents of the batch were indexed, otherwise deletes the batch and indexes it again.
+// Returns the number of bulk retries made.
+func (cmd *ExportCommand) verifyBatch(b *bytes.Buffer, first, last int) (retries int, err error) {
+	expected, err := es.BulkDocCounts(b.Bytes())
+	if err != nil {
+		return 0, err
+	}
 
-		cmd.index(b, retry+1)
+	indices := make([]es.IndexName, 0, len(expected))
+	for index := range expected {
+		indices = append(indices, index)
+	}
+
+	for attempt := 1; ; attempt++ {
+		mismatches, err := cmd.adapter.VerifyBatch(expected, first, last)
+		if err != nil {
+			return retries, err
+		}
+
+		if len(mismatches) == 0 {
+			return retries, nil
+		}
+
+		for _, mismatch := range mismatches {
+			log.Printf("Batch %d-%d: %s", first, last, mismatch)
+		}
+
+		if attempt > cmd.Config.RetryCount {
+			return retries, fmt.Errorf("batch %d-%d verification failed after %d attempts", first, last, attempt)
+		}
+
+		log.Printf("Batch %d-%d: deleting and indexing again (attempt %d)", first, last, attempt)
+
+		if err := cmd.adapter.DeleteLedgerRange(indices, first, last); err != nil {
+			return retries, err
+		}
+
+		n, err := cmd.ES.IndexWithRetries(b, cmd.Config.RetryCount)
+		retries += n + 1
+
+		if err != nil {
+			return retries, err
+		}
 	}
 }
 
-// Parses range of export command
-func (cmd *ExportCommand) getRange() (first int, last int) {
-	firstLedger := cmd.DB.LedgerHeaderFirstRow()
-	lastLedger := cmd.DB.LedgerHeaderLastRow()
+// checkChain verifies batch ledgers reference hashes of their predecessors, including the ledger preceding the batch
+func (cmd *ExportCommand) checkChain(rows []db.LedgerHeaderRow) error {
+	var prev *db.LedgerHeaderRow
+
+	if len(rows) == 0 {
+		return nil
+	}
 
-	if cmd.Config.Start.Explicit {
-		if cmd.Config.Start.Value < 0 {
-			first = lastLedger.LedgerSeq + cmd.Config.Start.Value + 1
-		} else if config.Start.Value > 0 {
-			first = firstLedger.LedgerSeq + cmd.Config.Start.Value
+	before, err := cmd.DB.LedgerHeaderRowFetchRange(rows[0].LedgerSeq-1, rows[0].LedgerSeq-1)
+	if err != nil {
+		return err
+	}
+
+	if len(before) > 0 {
+		prev = &before[0]
+	}
+
+	for n := range rows {
+		if err := rows[n].CheckChain(prev); err != nil {
+			return fmt.Errorf("ledger chain is broken, database may be restored from mismatched backups: %v", err)
 		}
-	} else if cmd.Config.Start.Value != 0 {
+
+		prev = &rows[n]
+	}
+
+	return nil
+}
+
+// writeAudit chains hashes of exported batches in ledger order and stores them in the audit index
+func (cmd *ExportCommand) writeAudit() error {
+	var b bytes.Buffer
+
+	if len(cmd.audited) == 0 {
+		return nil
+	}
+
+	sort.Slice(cmd.audited, func(i, j int) bool { return cmd.audited[i].First < cmd.audited[j].First })
+
+	var prev *es.AuditRecord
+
+	// Write-only outputs have no earlier records to read, the chain starts over
+	if cmd.adapter != nil {
+		var err error
+
+		if prev, err = cmd.adapter.LastAuditRecord(cmd.audited[0].First); err != nil {
+			return err
+		}
+	}
+
+	prevHash := auditHash(prev)
+
+	for _, record := range cmd.audited {
+		record.PrevHash = prevHash
+		record.Hash = es.ChainHash(prevHash, record.BatchHash)
+		prevHash = record.Hash
+
+		if err := es.SerializeForBulk(record, &b); err != nil {
+			return err
+		}
+	}
+
+	if _, err := cmd.ES.IndexWithRetries(&b, cmd.Config.RetryCount); err != nil {
+		return err
+	}
+
+	log.Println("Audit chain extended with", len(cmd.audited), "batch(es), head", prevHash)
+
+	return nil
+}
+
+// printSummary prints scope and cost of the export
+func (cmd *ExportCommand) printSummary() {
+	s := cmd.summary
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Summary", ""})
+	table.AppendBulk([][]string{
+		{"Ranges", s.Ranges},
+		{"Ledgers", strconv.Itoa(s.Ledgers)},
+		{"Transactions", strconv.Itoa(s.Transactions)},
+		{"Operations", strconv.Itoa(s.Operations)},
+		{"Balances", strconv.Itoa(s.Balances)},
+		{"Documents", strconv.Itoa(s.Documents)},
+		{"Bytes", strconv.FormatInt(s.Bytes, 10)},
+		{"Retries", strconv.Itoa(s.Retries)},
+		{"Elapsed", time.Duration(s.Elapsed * float64(time.Second)).Round(time.Second).String()},
+		{"Rate", fmt.Sprintf("%.2f ledgers/s", s.Rate)},
+	})
+
+	if cmd.Config.DryRun {
+		table.SetFooter([]string{"Dry run", "nothing sent"})
+	}
+
+	table.Render()
+}
+
+// touch records close time of the exported ledger
+func (cmd *ExportCommand) touch(closeTime time.Time) {
+	cmd.mutex.Lock()
+	defer cmd.mutex.Unlock()
+
+	cmd.hours[closeTime.Truncate(time.Hour)] = true
+
+	if cmd.from.IsZero() || closeTime.Before(cmd.from) {
+		cmd.from = closeTime
+	}
+
+	if closeTime.After(cmd.to) {
+		cmd.to = closeTime
+	}
+}
+
+// touchedHours returns hours covered by exported ledgers in chronological order
+func (cmd *ExportCommand) touchedHours() (hours []time.Time) {
+	for hour := range cmd.hours {
+		hours = append(hours, hour)
+	}
+
+	sort.Slice(hours, func(i, j int) bool { return hours[i].Before(hours[j]) })
+
+	return hours
+}
+
+// auditHash returns the hash of the audit record or empty string for the chain start
+func auditHash(record *es.AuditRecord) string {
+	if record == nil {
+		return ""
+	}
+
+	return record.Hash
+}
+
+// validateRanges checks ranges against ledgers available in the database, clamps them if requested
+func (cmd *ExportCommand) validateRanges(ranges config.LedgerRanges) (valid config.LedgerRanges, err error) {
+	min, max, err := cmd.availableRange()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range ranges {
+		if r.First > r.Last {
+			return nil, fmt.Errorf("invalid range %d-%d: first ledger is greater than last", r.First, r.Last)
+		}
+
+		clamped, ok := r.Clamp(min, max)
+
+		if !ok {
+			return nil, fmt.Errorf("range %d-%d is outside of ledgers available in the database (%d-%d)", r.First, r.Last, min, max)
+		}
+
+		if clamped != r {
+			if !cmd.Config.Clamp {
+				return nil, fmt.Errorf(
+					"range %d-%d exceeds ledgers available in the database (%d-%d), use --clamp to export %d-%d",
+					r.First, r.Last, min, max, clamped.First, clamped.Last,
+				)
+			}
+
+			log.Printf("Range %d-%d clamped to %d-%d", r.First, r.Last, clamped.First, clamped.Last)
+		}
+
+		valid = append(valid, clamped)
+	}
+
+	return valid, nil
+}
+
+// errNoLedgers is returned when the database is empty
+var errNoLedgers = errors.New("no ledgers in the database")
+
+// availableRange returns first and last ledger sequences stored in the database
+func (cmd *ExportCommand) availableRange() (min, max int, err error) {
+	firstLedger, lastLedger, err := cmd.edgeLedgers()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if firstLedger == nil || lastLedger == nil {
+		return 0, 0, errNoLedgers
+	}
+
+	return firstLedger.LedgerSeq, lastLedger.LedgerSeq, nil
+}
+
+// edgeLedgers returns first and last ledgers stored in the database, nil if the database is empty
+func (cmd *ExportCommand) edgeLedgers() (first, last *db.LedgerHeaderRow, err error) {
+	if first, err = cmd.DB.LedgerHeaderFirstRow(); err != nil {
+		return nil, nil, err
+	}
+
+	if last, err = cmd.DB.LedgerHeaderLastRow(); err != nil {
+		return nil, nil, err
+	}
+
+	return first, last, nil
+}
+
+// positionalRanges resolves start and count arguments, --end, --from and --to into the range validated
+// the same way as --ranges, relative start is the offset from the first or the last available ledger
+func (cmd *ExportCommand) positionalRanges() (config.LedgerRanges, error) {
+	var first, last int
+
+	min, max, err := cmd.availableRange()
+	if err != nil {
+		return nil, err
+	}
+
+	if !cmd.Config.From.IsZero() && cmd.Config.Start.Value != 0 {
+		return nil, errors.New("--from can not be used with start")
+	}
+
+	if (cmd.Config.End != 0 || !cmd.Config.To.IsZero()) && cmd.Config.Count != 0 {
+		return nil, errors.New("--end and --to can not be used with count")
+	}
+
+	if cmd.Config.End != 0 && !cmd.Config.To.IsZero() {
+		return nil, errors.New("--end can not be used with --to")
+	}
+
+	switch {
+	case !cmd.Config.From.IsZero():
+		row, err := cmd.DB.LedgerHeaderClosedAfter(cmd.Config.From)
+		if err != nil {
+			return nil, err
+		}
+
+		if row == nil {
+			return nil, fmt.Errorf("no ledgers closed after %s", cmd.Config.From.Format(time.RFC3339))
+		}
+
+		first = row.LedgerSeq
+	case cmd.Config.Start.Explicit && cmd.Config.Start.Value < 0:
+		first = max + cmd.Config.Start.Value + 1
+	case cmd.Config.Start.Explicit:
+		first = min + cmd.Config.Start.Value
+	case cmd.Config.Start.Value != 0:
 		first = cmd.Config.Start.Value
-	} else {
-		first = firstLedger.LedgerSeq
+	default:
+		first = min
 	}
 
-	if cmd.Config.Count == 0 {
-		last = lastLedger.LedgerSeq
-	} else {
+	switch {
+	case !cmd.Config.To.IsZero():
+		last = max
+
+		row, err := cmd.DB.LedgerHeaderClosedAfter(cmd.Config.To)
+		if err != nil {
+			return nil, err
+		}
+
+		if row != nil {
+			last = row.LedgerSeq - 1
+		}
+	case cmd.Config.End != 0:
+		last = cmd.Config.End
+	case cmd.Config.Count == 0:
+		last = max
+	default:
 		last = first + cmd.Config.Count - 1
 	}
 
-	return first, last
+	return config.LedgerRanges{{First: first, Last: last}}, nil
 }
 
-func createBar(count int) {
-	bar = progressbar.NewOptions(
+func (cmd *ExportCommand) createBar(count int) {
+	cmd.bar = progressbar.NewOptions(
 		count,
 		progressbar.OptionEnableColorCodes(false),
 		progressbar.OptionShowCount(),
@@ -139,12 +974,12 @@ func createBar(count int) {
 		progressbar.OptionSetWidth(100),
 	)
 
-	bar.RenderBlank()
+	cmd.bar.RenderBlank()
 }
 
-func finishBar() {
-	if !*config.Verbose {
-		bar.Finish()
+func (cmd *ExportCommand) finishBar() {
+	if !cmd.Config.Verbose {
+		cmd.bar.Finish()
 	}
 }
 
@@ -0,0 +1,272 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/astroband/astrologer/config"
+	"github.com/astroband/astrologer/db"
+	"github.com/astroband/astrologer/es"
+	"github.com/gammazero/workerpool"
+	progressbar "github.com/schollz/progressbar/v2"
+)
+
+// gapScanIntervals are histogram bucket sizes of gap scan levels, the last one must be 1
+var gapScanIntervals = []int{10000, 100, 1}
+
+// FillGapsCommandConfig represents configuration options for the `fill-gaps` CLI command
+type FillGapsCommandConfig struct {
+	RetryCount int
+	BatchSize  int
+	DryRun     bool
+
+	// Concurrency batches are filled at once, zero fills them one by one
+	Concurrency int
+
+	// Progress shows the progress bar with ETA
+	Progress bool
+
+	SerializeOptions es.SerializeOptions
+}
+
+// FillGapsCommand represents the `fill-gaps` CLI command
+type FillGapsCommand struct {
+	ES     es.Adapter
+	DB     db.Adapter
+	Config FillGapsCommandConfig
+}
+
+// errNothingIndexed is returned when the output has no ledgers to look for gaps between
+var errNothingIndexed = errors.New("no ledgers indexed")
+
+// Run finds ledgers missing between the first and the last indexed ones and indexes them
+func (cmd *FillGapsCommand) Run(ctx context.Context) error {
+	_, ok, err := cmd.ES.LastIndexedLedger()
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return errNothingIndexed
+	}
+
+	min, max, err := cmd.ES.MinMaxRange()
+	if err != nil {
+		return err
+	}
+
+	gaps, err := cmd.scan(min, max)
+	if err != nil {
+		return err
+	}
+
+	if len(gaps) == 0 {
+		log.Println("No gaps found between", min, "and", max)
+		return nil
+	}
+
+	log.Println("Gaps found:", gaps.String())
+
+	if cmd.Config.DryRun {
+		return nil
+	}
+
+	return cmd.fill(ctx, gaps)
+}
+
+// scan returns ranges of ledgers missing in the output between first and last inclusive.
+// Ledgers are counted server-side per histogram bucket, only partially filled buckets are counted again with finer intervals.
+func (cmd *FillGapsCommand) scan(first, last int) (gaps config.LedgerRanges, err error) {
+	if first > last {
+		return nil, nil
+	}
+
+	return cmd.scanLevel(gaps, first, last, 0)
+}
+
+func (cmd *FillGapsCommand) scanLevel(gaps config.LedgerRanges, first, last, level int) (config.LedgerRanges, error) {
+	interval := gapScanIntervals[level]
+
+	counts, err := cmd.ES.LedgerHistogram(first, last, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	for key := first - first%interval; key <= last; key += interval {
+		from, to := key, key+interval-1
+		if from < first {
+			from = first
+		}
+
+		if to > last {
+			to = last
+		}
+
+		switch count := counts[key]; {
+		case count == 0:
+			gaps = appendGap(gaps, from, to)
+		case count < to-from+1:
+			if gaps, err = cmd.scanLevel(gaps, from, to, level+1); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return gaps, nil
+}
+
+// appendGap adds the range joining it with the previous one if adjacent
+func appendGap(gaps config.LedgerRanges, first, last int) config.LedgerRanges {
+	if n := len(gaps); n > 0 && gaps[n-1].Last+1 == first {
+		gaps[n-1].Last = last
+		return gaps
+	}
+
+	return append(gaps, config.LedgerRange{First: first, Last: last})
+}
+
+// fill indexes ledgers of the gaps in batches on Concurrency workers, ledgers missing in the database are skipped
+func (cmd *FillGapsCommand) fill(ctx context.Context, gaps config.LedgerRanges) error {
+	var (
+		mutex    sync.Mutex
+		firstErr error
+		bar      *progressbar.ProgressBar
+	)
+
+	batches := planGapBatches(gaps, cmd.Config.BatchSize)
+
+	if cmd.Config.Progress {
+		bar = progressbar.NewOptions(
+			gaps.Count(),
+			progressbar.OptionEnableColorCodes(false),
+			progressbar.OptionShowCount(),
+			progressbar.OptionThrottle(500*time.Millisecond),
+			progressbar.OptionSetRenderBlankState(true),
+		)
+	}
+
+	workers := cmd.Config.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	wp := workerpool.New(workers)
+
+	for _, batch := range batches {
+		batch := batch
+
+		wp.Submit(func() {
+			mutex.Lock()
+			failed := firstErr != nil
+			mutex.Unlock()
+
+			if failed || ctx.Err() != nil {
+				return
+			}
+
+			if err := cmd.fillBatch(batch); err != nil {
+				mutex.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mutex.Unlock()
+
+				return
+			}
+
+			if bar != nil {
+				bar.Add(batch.Count())
+			}
+		})
+	}
+
+	wp.StopWait()
+
+	if bar != nil {
+		bar.Finish()
+		fmt.Println()
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	log.Println(gaps.Count(), "ledgers in", len(gaps), "gap(s) filled")
+
+	return nil
+}
+
+// planGapBatches groups gaps into batches of up to size missing ledgers, small gaps nearby share a batch
+func planGapBatches(gaps config.LedgerRanges, size int) (batches []config.LedgerRanges) {
+	var batch config.LedgerRanges
+
+	count := 0
+
+	for _, gap := range gaps {
+		for first := gap.First; first <= gap.Last; {
+			last := first + size - count - 1
+			if last > gap.Last {
+				last = gap.Last
+			}
+
+			batch = append(batch, config.LedgerRange{First: first, Last: last})
+			count += last - first + 1
+			first = last + 1
+
+			if count == size {
+				batches = append(batches, batch)
+				batch, count = nil, 0
+			}
+		}
+	}
+
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+// fillBatch indexes ledgers of the batch ranges in one bulk request
+func (cmd *FillGapsCommand) fillBatch(batch config.LedgerRanges) error {
+	var b bytes.Buffer
+
+	for _, r := range batch {
+		rows, err := cmd.DB.LedgerHeaderRowFetchRange(r.First, r.Last)
+		if err != nil {
+			return err
+		}
+
+		if len(rows) < r.Last-r.First+1 {
+			log.Printf("Range %d-%d: %d ledgers missing in the database", r.First, r.Last, r.Last-r.First+1-len(rows))
+		}
+
+		for _, row := range rows {
+			txs, fees, err := ledgerTransactions(cmd.DB, row.LedgerSeq)
+			if err != nil {
+				return err
+			}
+
+			if err := es.SerializeLedger(row, txs, fees, &b, cmd.Config.SerializeOptions); err != nil {
+				return fmt.Errorf("failed to serialize ledger %d: %v", row.LedgerSeq, err)
+			}
+		}
+	}
+
+	if b.Len() > 0 {
+		if _, err := cmd.ES.IndexWithRetries(&b, cmd.Config.RetryCount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
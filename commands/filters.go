@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/astroband/astrologer/es"
+	"github.com/stellar/go/strkey"
+)
+
+// AccountSet validates account IDs given by flags or the live config, nil means no filter
+func AccountSet(ids []string) (map[string]bool, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	accounts := make(map[string]bool, len(ids))
+
+	for _, id := range ids {
+		if _, err := strkey.Decode(strkey.VersionByteAccountID, id); err != nil {
+			return nil, fmt.Errorf("invalid account ID %s: %v", id, err)
+		}
+
+		accounts[id] = true
+	}
+
+	return accounts, nil
+}
+
+// AssetSet converts assets given by flags or the live config to asset keys, nil means no filter
+func AssetSet(values []string) (map[string]bool, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	assets := make(map[string]bool, len(values))
+
+	for _, value := range values {
+		key, err := es.ParseAssetKey(value)
+		if err != nil {
+			return nil, err
+		}
+
+		assets[key] = true
+	}
+
+	return assets, nil
+}
+
+// OpTypeSet converts operation types given by flags or the live config to types of operation documents,
+// nil means no filter
+func OpTypeSet(names []string) (map[string]bool, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	types := make(map[string]bool, len(names))
+
+	for _, name := range names {
+		t, err := es.ParseOperationType(name)
+		if err != nil {
+			return nil, err
+		}
+
+		types[t] = true
+	}
+
+	return types, nil
+}
@@ -2,6 +2,9 @@ package commands
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"time"
 
@@ -10,69 +13,510 @@ import (
 	"github.com/astroband/astrologer/es"
 )
 
-const ingestRetries = 25
+// Ingest waits for ES to come back instead of exiting
+const ingestRetries = 0
+
+// IngestCommandConfig represents configuration options for the `ingest` CLI command
+type IngestCommandConfig struct {
+	// MaxDuration stops ingestion after the given duration, zero means no limit
+	MaxDuration time.Duration
+
+	// Audit appends every ledger to the audit hash chain
+	Audit bool
+
+	// CheckChain verifies previous ledger hashes before indexing
+	CheckChain bool
+
+	// TradeAggregations builds trade candles once a minute
+	TradeAggregations bool
+
+	SerializeOptions es.SerializeOptions
+
+	// LiveConfig overrides settings without restart, optional
+	LiveConfig *config.LiveConfig
+
+	// Listener wakes ingest up on new ledgers instead of polling every second, optional
+	Listener *db.Listener
+
+	// Resume starts after the last ledger in the output when no start ledger is given
+	Resume bool
+
+	// FillGaps scans for missing ledgers on startup and with the given interval, backfills them in background
+	FillGaps time.Duration
+
+	// Start is the first ledger to ingest, negative is the offset from the last ledger in the database,
+	// zero resumes or starts from the last ledger
+	Start int
+
+	// After starts right after the given ledger, overrides Start, used by export --follow
+	After int
+
+	// DBMaxQPS is the command line database query rate limit, used when the live config has none
+	DBMaxQPS int
+
+	// FlowsAssets are assets payment flows are rolled up for once a day, FlowsInterval is the flows interval
+	FlowsAssets   []string
+	FlowsInterval string
+
+	// FlowsLabelsFile maps accounts to flow categories, labels of the live config override it
+	FlowsLabelsFile string
+}
 
 // IngestCommand represents the CLI command which starts the Astrologer ingestion daemon
 type IngestCommand struct {
-	ES es.Adapter
-	DB db.Adapter
+	ES     es.Sink
+	DB     db.Adapter
+	Config IngestCommandConfig
+
+	adapter     es.Adapter
+	flowsLabels map[string]string
 }
 
-// Execute starts ingestion
-func (cmd *IngestCommand) Execute() {
-	current := cmd.getStartLedger()
+// Run ingests ledgers until ctx is cancelled, returns *ExitError when stopped by --max-duration
+func (cmd *IngestCommand) Run(ctx context.Context) error {
+	var deadline time.Time
+	var audit *es.AuditRecord
+	var hour, minute, day time.Time
+	var prev *db.LedgerHeaderRow
+
+	// adapter is nil for write-only outputs
+	cmd.adapter, _ = es.AsAdapter(cmd.ES)
+
+	if cmd.adapter == nil && (cmd.Config.SerializeOptions.Stats || cmd.Config.TradeAggregations || len(cmd.Config.FlowsAssets) > 0) {
+		log.Println("Stats, trade aggregation and flows rollups require ElasticSearch output, skipped")
+	}
+
+	if cmd.Config.FlowsLabelsFile != "" {
+		labels, err := readLabels(cmd.Config.FlowsLabelsFile)
+		if err != nil {
+			return err
+		}
+
+		cmd.flowsLabels = labels
+	}
+
+	current, err := cmd.getStartLedger(ctx)
+	if err != nil {
+		return err
+	}
+
 	log.Println("Starting ingest from", current.LedgerSeq)
 
+	if cmd.Config.FillGaps > 0 && cmd.adapter != nil {
+		go cmd.fillGaps(ctx)
+	}
+
+	before, err := cmd.DB.LedgerHeaderRowFetchRange(current.LedgerSeq-1, current.LedgerSeq-1)
+	if err != nil {
+		return err
+	}
+
+	if len(before) > 0 {
+		prev = &before[0]
+	}
+
+	// Write-only outputs have no earlier records to read, the chain starts over
+	if cmd.Config.Audit && cmd.adapter != nil {
+		if audit, err = cmd.adapter.LastAuditRecord(current.LedgerSeq); err != nil {
+			return err
+		}
+	}
+
+	if cmd.Config.MaxDuration > 0 {
+		deadline = time.Now().Add(cmd.Config.MaxDuration)
+	}
+
 	for {
 		var b bytes.Buffer
 		var seq = current.LedgerSeq
 
-		txs := cmd.DB.TxHistoryRowForSeq(seq)
-		fees := cmd.DB.TxFeeHistoryRowsForRows(txs)
+		if cmd.Config.CheckChain {
+			if err := current.CheckChain(prev); err != nil {
+				return fmt.Errorf("ledger chain is broken, database may be restored from mismatched backups: %v", err)
+			}
+		}
+
+		txs, fees, err := ledgerTransactions(cmd.DB, seq)
+		if err != nil {
+			return fmt.Errorf("failed to ingest ledger %d: %v", seq, err)
+		}
+
+		settings := cmd.liveSettings()
+
+		options, err := settings.serializeOptions(cmd.Config.SerializeOptions)
+		if err != nil {
+			return fmt.Errorf("failed to ingest ledger %d: %v", seq, err)
+		}
 
-		err := es.SerializeLedger(*current, txs, fees, &b)
+		err = es.SerializeLedger(*current, txs, fees, &b, options)
 
 		if err != nil {
-			log.Fatalf("Failed to ingest ledger %d: %v\n", seq, err)
+			return fmt.Errorf("failed to ingest ledger %d: %v", seq, err)
+		}
+
+		if err := settings.filter(&b); err != nil {
+			return fmt.Errorf("failed to ingest ledger %d: %v", seq, err)
 		}
 		//es.NewBulkMaker(*current, txs, fees, &b).Make()
 
-		cmd.ES.IndexWithRetries(&b, ingestRetries)
+		if cmd.Config.Audit {
+			audit = es.NewAuditRecord(seq, seq, b.Bytes(), auditHash(audit))
+			if err := es.SerializeForBulk(audit, &b); err != nil {
+				return fmt.Errorf("failed to ingest ledger %d: %v", seq, err)
+			}
+		}
+
+		if _, err := cmd.ES.IndexWithRetries(&b, ingestRetries); err != nil {
+			log.Println("Resume with: ingest", seq)
+			return fmt.Errorf("failed to ingest ledger %d: %v", seq, err)
+		}
 
 		log.Println("Ledger", seq, "ingested.")
 
-		current = cmd.DB.LedgerHeaderNext(seq)
+		if cmd.Config.SerializeOptions.Stats && cmd.adapter != nil {
+			closeHour := time.Unix(current.CloseTime, 0).Truncate(time.Hour)
+
+			// The hour is complete once the first ledger of the next hour is ingested
+			if !hour.IsZero() && closeHour.After(hour) {
+				if err := cmd.adapter.RollupHourlyStats([]time.Time{hour}); err != nil {
+					return err
+				}
+			}
+
+			hour = closeHour
+		}
+
+		if cmd.Config.TradeAggregations && cmd.adapter != nil {
+			closeMinute := time.Unix(current.CloseTime, 0).Truncate(time.Minute)
+
+			if !minute.IsZero() && closeMinute.After(minute) {
+				if err := cmd.adapter.RollupTradeAggregations(minute, minute); err != nil {
+					return err
+				}
+			}
+
+			minute = closeMinute
+		}
+
+		if len(cmd.Config.FlowsAssets) > 0 && cmd.adapter != nil {
+			closeDay := time.Unix(current.CloseTime, 0).UTC().Truncate(24 * time.Hour)
+
+			// Flows are rebuilt from the whole payments index, so they are rolled up once a day only
+			if !day.IsZero() && closeDay.After(day) {
+				if err := cmd.rollupFlows(settings); err != nil {
+					return err
+				}
+			}
 
-		for {
-			if current != nil {
-				break
+			day = closeDay
+		}
+
+		stopped := ctx.Err()
+
+		if stopped == nil && !deadline.IsZero() && time.Now().After(deadline) {
+			stopped = &ExitError{Code: ExitCodeDeadline, Message: "max duration exceeded"}
+		}
+
+		if stopped != nil {
+			if !hour.IsZero() {
+				if err := cmd.adapter.RollupHourlyStats([]time.Time{hour}); err != nil {
+					return err
+				}
 			}
-			time.Sleep(1 * time.Second)
-			current = cmd.DB.LedgerHeaderNext(seq)
+
+			if !minute.IsZero() {
+				if err := cmd.adapter.RollupTradeAggregations(minute, minute); err != nil {
+					return err
+				}
+			}
+
+			if !day.IsZero() {
+				if err := cmd.rollupFlows(settings); err != nil {
+					return err
+				}
+			}
+
+			log.Println("Ingest stopped after ledger", seq, "-", stopped)
+			log.Println("Resume with: ingest", seq+1)
+
+			return stopped
+		}
+
+		prev = current
+		current, err = cmd.waitNext(ctx, seq)
+
+		if err != nil {
+			log.Println("Ingest stopped after ledger", seq, "-", err)
+			log.Println("Resume with: ingest", seq+1)
+
+			return err
+		}
+	}
+}
+
+// listenPollInterval is the safety net poll interval when notifications are used
+const listenPollInterval = 10 * time.Second
+
+// waitNext waits until the ledger following seq appears in the database
+func (cmd *IngestCommand) waitNext(ctx context.Context, seq int) (*db.LedgerHeaderRow, error) {
+	for {
+		h, err := cmd.DB.LedgerHeaderNext(seq)
+		if err != nil {
+			return nil, err
+		}
+
+		if h != nil {
+			return h, nil
+		}
+
+		if cmd.Config.Listener != nil {
+			if err := cmd.Config.Listener.Wait(ctx, listenPollInterval); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(1 * time.Second):
+		}
+	}
+}
+
+// ingestFillGapsBatchSize ledgers per bulk request of the background backfill
+const ingestFillGapsBatchSize = 50
+
+// gapScanMargin recent ledgers excluded from the gap scan, they may be not refreshed yet
+const gapScanMargin = 100
+
+// fillGaps periodically backfills ledgers missing behind the ingested head until ctx is cancelled
+func (cmd *IngestCommand) fillGaps(ctx context.Context) {
+	filler := &FillGapsCommand{
+		ES: cmd.adapter,
+		DB: cmd.DB,
+		Config: FillGapsCommandConfig{
+			RetryCount:       ingestRetries,
+			BatchSize:        ingestFillGapsBatchSize,
+			SerializeOptions: cmd.Config.SerializeOptions,
+		},
+	}
+
+	for {
+		if err := cmd.backfill(ctx, filler); err != nil {
+			log.Println("Backfill stopped:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(cmd.Config.FillGaps):
+		}
+	}
+}
+
+// backfill scans the indexed range for gaps and fills them
+func (cmd *IngestCommand) backfill(ctx context.Context, filler *FillGapsCommand) error {
+	_, ok, err := cmd.adapter.LastIndexedLedger()
+	if err != nil || !ok {
+		return err
+	}
+
+	min, max, err := cmd.adapter.MinMaxRange()
+	if err != nil {
+		return err
+	}
+
+	gaps, err := filler.scan(min, max-gapScanMargin)
+	if err != nil || len(gaps) == 0 {
+		return err
+	}
+
+	log.Println("Backfilling gaps:", gaps.String())
+
+	return filler.fill(ctx, gaps)
+}
+
+// rollupFlows rebuilds flows of FlowsAssets, labels of the live config override FlowsLabelsFile
+func (cmd *IngestCommand) rollupFlows(settings ingestLiveSettings) error {
+	labels := cmd.flowsLabels
+	if settings.Labels != nil {
+		labels = settings.Labels
+	}
+
+	for _, asset := range cmd.Config.FlowsAssets {
+		if err := cmd.adapter.RollupFlows(asset, cmd.Config.FlowsInterval, labels); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// liveSettings returns current settings of the live config and applies the database query rate limit,
+// settings are empty without the live config, so command line values are used
+func (cmd *IngestCommand) liveSettings() ingestLiveSettings {
+	if cmd.Config.LiveConfig == nil {
+		return ingestLiveSettings{}
+	}
+
+	settings := cmd.Config.LiveConfig.Settings()
+
+	if c, ok := cmd.DB.(*db.Client); ok {
+		if settings.DBMaxQPS != nil {
+			c.SetMaxQPS(*settings.DBMaxQPS)
+		} else {
+			c.SetMaxQPS(cmd.Config.DBMaxQPS)
+		}
+	}
+
+	return ingestLiveSettings{settings}
+}
+
+// ingestLiveSettings applies the live config to ledgers being ingested
+type ingestLiveSettings struct {
+	config.LiveSettings
+}
+
+// serializeOptions returns command line options with operation types of the live config
+func (settings ingestLiveSettings) serializeOptions(options es.SerializeOptions) (es.SerializeOptions, error) {
+	if settings.OpTypes != nil {
+		types, err := OpTypeSet(settings.OpTypes)
+		if err != nil {
+			return options, err
+		}
+
+		options.OpTypes = types
+	}
+
+	return options, nil
+}
+
+// filter drops documents not involving accounts and assets of the live config from the bulk payload
+func (settings ingestLiveSettings) filter(b *bytes.Buffer) error {
+	accounts, err := AccountSet(settings.Accounts)
+	if err != nil {
+		return err
+	}
+
+	assets, err := AssetSet(settings.Assets)
+	if err != nil {
+		return err
+	}
+
+	if accounts == nil && assets == nil {
+		return nil
+	}
+
+	payload := b.Bytes()
+
+	if accounts != nil {
+		if payload, err = es.FilterAccounts(payload, accounts); err != nil {
+			return err
+		}
+	}
+
+	if assets != nil {
+		if payload, err = es.FilterAssets(payload, assets); err != nil {
+			return err
 		}
 	}
+
+	b.Reset()
+	b.Write(payload)
+
+	return nil
 }
 
-func (cmd *IngestCommand) getStartLedger() (h *db.LedgerHeaderRow) {
-	if *config.StartIngest == 0 {
-		h = cmd.DB.LedgerHeaderLastRow()
+// ValidateLiveSettings rejects live config with unknown accounts, assets or operation types
+func ValidateLiveSettings(settings config.LiveSettings) error {
+	if _, err := AccountSet(settings.Accounts); err != nil {
+		return err
+	}
+
+	if _, err := AssetSet(settings.Assets); err != nil {
+		return err
+	}
+
+	if _, err := OpTypeSet(settings.OpTypes); err != nil {
+		return err
+	}
+
+	if settings.DBMaxQPS != nil && *settings.DBMaxQPS < 0 {
+		return fmt.Errorf("db_max_qps must not be negative: %d", *settings.DBMaxQPS)
+	}
+
+	return nil
+}
+
+// errNothingToIngest is returned when the start ledger is not in the database
+var errNothingToIngest = errors.New("nothing to ingest")
+
+func (cmd *IngestCommand) getStartLedger(ctx context.Context) (h *db.LedgerHeaderRow, err error) {
+	if cmd.Config.After > 0 {
+		return cmd.resumeAfter(ctx, cmd.Config.After)
+	}
+
+	if cmd.Config.Start == 0 && cmd.Config.Resume && cmd.adapter != nil {
+		last, ok, err := cmd.adapter.LastIndexedLedger()
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			return cmd.resumeAfter(ctx, last)
+		}
+
+		log.Println("Nothing indexed yet, starting from the latest ledger")
+	}
+
+	if cmd.Config.Start == 0 {
+		h, err = cmd.DB.LedgerHeaderLastRow()
 	} else {
-		if *config.StartIngest > 0 {
-			h = cmd.DB.LedgerHeaderNext(*config.StartIngest)
+		if cmd.Config.Start > 0 {
+			h, err = cmd.DB.LedgerHeaderNext(cmd.Config.Start)
 		} else {
-			last := cmd.DB.LedgerHeaderLastRow()
+			var last *db.LedgerHeaderRow
+
+			if last, err = cmd.DB.LedgerHeaderLastRow(); err != nil {
+				return nil, err
+			}
 
 			if last == nil {
-				log.Fatal("Nothing to ingest")
+				return nil, errNothingToIngest
 			}
 
-			h = cmd.DB.LedgerHeaderNext(last.LedgerSeq + *config.StartIngest)
+			h, err = cmd.DB.LedgerHeaderNext(last.LedgerSeq + cmd.Config.Start)
 		}
 	}
 
+	if err != nil {
+		return nil, err
+	}
+
 	if h == nil {
-		log.Fatal("Nothing to ingest")
+		return nil, errNothingToIngest
+	}
+
+	return h, nil
+}
+
+// resumeAfter returns the ledger following the last indexed one, waits for it if the output is up to date
+func (cmd *IngestCommand) resumeAfter(ctx context.Context, last int) (*db.LedgerHeaderRow, error) {
+	log.Println("Resuming after ledger", last)
+
+	h, err := cmd.waitNext(ctx, last)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.LedgerSeq != last+1 {
+		return nil, fmt.Errorf(
+			"ledgers %d-%d are missing in the database, export them first or pass the start ledger explicitly",
+			last+1, h.LedgerSeq-1,
+		)
 	}
 
-	return h
+	return h, nil
 }
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/astroband/astrologer/db"
+)
+
+// lagCheckInterval limits how often workers query replication lag
+const lagCheckInterval = 5 * time.Second
+
+// lagThrottle pauses workers while the database replica lags behind the primary more than max
+type lagThrottle struct {
+	db  db.Adapter
+	max time.Duration
+
+	mutex   sync.Mutex
+	checked time.Time
+	lag     time.Duration
+}
+
+// wait blocks until replication lag is below the threshold
+func (t *lagThrottle) wait() {
+	for paused := false; ; paused = true {
+		lag := t.current()
+
+		if lag <= t.max {
+			if paused {
+				log.Printf("Replication lag is %s, resuming", lag.Round(time.Second))
+			}
+
+			return
+		}
+
+		if !paused {
+			log.Printf("Replication lag is %s (max %s), pausing", lag.Round(time.Second), t.max)
+		}
+
+		time.Sleep(lagCheckInterval)
+	}
+}
+
+// current returns the replication lag, checked at most once per lagCheckInterval for all workers
+func (t *lagThrottle) current() time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if time.Since(t.checked) < lagCheckInterval {
+		return t.lag
+	}
+
+	lag, _, err := t.db.ReplicationLag()
+	if err != nil {
+		log.Println("Failed to check replication lag:", err)
+		return t.lag
+	}
+
+	t.lag = lag
+	t.checked = time.Now()
+
+	return t.lag
+}
@@ -0,0 +1,20 @@
+package commands
+
+import (
+	"github.com/astroband/astrologer/db"
+)
+
+// ledgerTransactions reads transactions of the ledger and their fee changes
+func ledgerTransactions(source db.Adapter, seq int) ([]db.TxHistoryRow, []db.TxFeeHistoryRow, error) {
+	txs, err := source.TxHistoryRowForSeq(seq)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fees, err := source.TxFeeHistoryRowsForRows(txs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return txs, fees, nil
+}
@@ -1,15 +1,27 @@
 package commands
 
 import (
-	"github.com/astroband/astrologer/config"
-	"github.com/gammazero/workerpool"
+	"context"
 )
 
-var (
-	pool = workerpool.New(*config.Concurrency)
-)
+// ExitCodeDeadline is returned when the command stopped early because --max-duration was exceeded
+const ExitCodeDeadline = 3
+
+// ExitCodeBudget is returned when export stopped early because --max-docs or --max-bytes was exceeded
+const ExitCodeBudget = 4
+
+// ExitError is returned when the command stopped early and the process should exit with Code
+type ExitError struct {
+	Code    int
+	Message string
+}
+
+func (e *ExitError) Error() string {
+	return e.Message
+}
 
-// Command is an interface representing an Astrologer CLI command
+// Command is an interface representing an Astrologer CLI command.
+// Run returns when the command is finished or ctx is cancelled, errors are returned instead of exiting.
 type Command interface {
-	Execute()
+	Run(ctx context.Context) error
 }
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+
+	"github.com/astroband/astrologer/es"
+)
+
+// ReindexCommandConfig represents configuration options for the `reindex` CLI command
+type ReindexCommandConfig struct {
+	// Indices to reindex, all indices except data streams by default
+	Indices []string
+
+	// Scripts maps index names to painless script files transforming their documents
+	Scripts map[string]string
+
+	// DeleteOld deletes indices replaced by reindexed ones
+	DeleteOld bool
+}
+
+// ReindexCommand represents the `reindex` CLI command
+type ReindexCommand struct {
+	ES     es.Adapter
+	Config ReindexCommandConfig
+}
+
+// Run reindexes indices one by one into new indices created with current definitions
+func (cmd *ReindexCommand) Run(ctx context.Context) error {
+	indices := cmd.Config.Indices
+
+	if len(indices) == 0 {
+		definitions, err := es.GetIndexDefinitions()
+		if err != nil {
+			return err
+		}
+
+		for name := range definitions {
+			if !es.DataStreams[name] {
+				indices = append(indices, string(name))
+			}
+		}
+
+		sort.Strings(indices)
+	}
+
+	for index := range cmd.Config.Scripts {
+		if !contains(indices, index) {
+			return fmt.Errorf("script is given for %s which is not reindexed", index)
+		}
+	}
+
+	for _, index := range indices {
+		var script string
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if path, ok := cmd.Config.Scripts[index]; ok {
+			source, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			script = string(source)
+		}
+
+		if err := cmd.ES.Reindex(es.IndexName(index), script, cmd.Config.DeleteOld); err != nil {
+			return err
+		}
+	}
+
+	log.Println(len(indices), "indices reindexed")
+
+	return nil
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
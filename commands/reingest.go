@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/astroband/astrologer/db"
+	"github.com/astroband/astrologer/es"
+)
+
+// ReingestCommandConfig represents configuration options for the `reingest` CLI command
+type ReingestCommandConfig struct {
+	Start      int
+	Count      int
+	BatchSize  int
+	RetryCount int
+
+	SerializeOptions es.SerializeOptions
+}
+
+// ReingestCommand represents the `reingest` CLI command
+type ReingestCommand struct {
+	ES     es.Adapter
+	DB     db.Adapter
+	Config ReingestCommandConfig
+}
+
+// Run replaces documents of the range batch by batch: documents of batch ledgers are deleted and indexed again.
+// Documents get _id, so rerunning the command overwrites them.
+func (cmd *ReingestCommand) Run(ctx context.Context) error {
+	if cmd.Config.Start <= 0 || cmd.Config.Count <= 0 {
+		return fmt.Errorf("invalid range: start %d, count %d", cmd.Config.Start, cmd.Config.Count)
+	}
+
+	last := cmd.Config.Start + cmd.Config.Count - 1
+	options := cmd.Config.SerializeOptions
+	options.DocIDs = true
+
+	log.Println("Reingesting ledgers from", cmd.Config.Start, "to", last)
+
+	for first := cmd.Config.Start; first <= last; first += cmd.Config.BatchSize {
+		if err := ctx.Err(); err != nil {
+			log.Printf("Reingest stopped, resume with: reingest %d %d", first, last-first+1)
+			return err
+		}
+
+		batchLast := first + cmd.Config.BatchSize - 1
+		if batchLast > last {
+			batchLast = last
+		}
+
+		if err := cmd.reingestBatch(first, batchLast, options); err != nil {
+			return err
+		}
+
+		log.Printf("Ledgers %d-%d reingested", first, batchLast)
+	}
+
+	return nil
+}
+
+func (cmd *ReingestCommand) reingestBatch(first, last int, options es.SerializeOptions) error {
+	var b bytes.Buffer
+
+	rows, err := cmd.DB.LedgerHeaderRowFetchRange(first, last)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) < last-first+1 {
+		log.Printf("Batch %d-%d: %d ledgers missing in the database", first, last, last-first+1-len(rows))
+	}
+
+	for _, row := range rows {
+		txs, fees, err := ledgerTransactions(cmd.DB, row.LedgerSeq)
+		if err != nil {
+			return err
+		}
+
+		if err := es.SerializeLedger(row, txs, fees, &b, options); err != nil {
+			return fmt.Errorf("failed to serialize ledger %d: %v", row.LedgerSeq, err)
+		}
+	}
+
+	if b.Len() == 0 {
+		return nil
+	}
+
+	// Documents indexed earlier have generated ids, versioned documents overwrite themselves.
+	// All indices are cleared, documents the fixed serializer no longer produces are removed too.
+	if err := cmd.ES.DeleteLedgerRange(es.AppendOnlyLedgerIndices(options), first, last); err != nil {
+		return err
+	}
+
+	_, err = cmd.ES.IndexWithRetries(&b, cmd.Config.RetryCount)
+
+	return err
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/astroband/astrologer/es"
+)
+
+// replayBatchSize bulk payload size of replayed documents
+const replayBatchSize = 5 * 1024 * 1024
+
+// ReplayDLQCommandConfig represents configuration options for the `replay-dlq` CLI command
+type ReplayDLQCommandConfig struct {
+	File       string
+	RetryCount int
+}
+
+// ReplayDLQCommand represents the `replay-dlq` CLI command
+type ReplayDLQCommand struct {
+	ES     es.Sink
+	Config ReplayDLQCommandConfig
+}
+
+// Run submits documents of the dead letter queue again, documents failing again are added to the new queue file
+func (cmd *ReplayDLQCommand) Run(ctx context.Context) error {
+	var b bytes.Buffer
+
+	if cmd.Config.File == "" {
+		return errors.New("dead letter queue file is not given, use --dlq")
+	}
+
+	replaying := fmt.Sprintf("%s.replay", cmd.Config.File)
+
+	if err := os.Rename(cmd.Config.File, replaying); err != nil {
+		return err
+	}
+
+	letters, err := es.ReadDeadLetters(replaying)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Replaying", len(letters), "documents from", cmd.Config.File)
+
+	for _, letter := range letters {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		b.Write(letter.Meta)
+		b.WriteByte('\n')
+
+		// Delete actions have no source line
+		if len(letter.Source) > 0 {
+			b.Write(letter.Source)
+			b.WriteByte('\n')
+		}
+
+		if b.Len() > replayBatchSize {
+			if _, err := cmd.ES.IndexWithRetries(&b, cmd.Config.RetryCount); err != nil {
+				return err
+			}
+
+			b.Reset()
+		}
+	}
+
+	if b.Len() > 0 {
+		if _, err := cmd.ES.IndexWithRetries(&b, cmd.Config.RetryCount); err != nil {
+			return err
+		}
+	}
+
+	failed := es.DeadLetters.Count()
+	log.Println(len(letters)-failed, "documents replayed,", failed, "failed again")
+
+	return os.Remove(replaying)
+}
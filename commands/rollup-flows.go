@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/astroband/astrologer/es"
+)
+
+// RollupFlowsCommandConfig represents configuration options for the `rollup flows` CLI command
+type RollupFlowsCommandConfig struct {
+	AssetID    string
+	Interval   string
+	LabelsFile string
+}
+
+// RollupFlowsCommand represents the `rollup flows` CLI command
+type RollupFlowsCommand struct {
+	ES     es.Adapter
+	Config RollupFlowsCommandConfig
+}
+
+// Run builds flows of the asset between account categories
+func (cmd *RollupFlowsCommand) Run(ctx context.Context) error {
+	labels := make(map[string]string)
+
+	if cmd.Config.LabelsFile != "" {
+		var err error
+
+		labels, err = readLabels(cmd.Config.LabelsFile)
+		if err != nil {
+			return err
+		}
+
+		log.Println("Loaded", len(labels), "account labels")
+	} else {
+		log.Println("No --labels given, all accounts are", es.UnlabeledCategory)
+	}
+
+	return cmd.ES.RollupFlows(cmd.Config.AssetID, cmd.Config.Interval, labels)
+}
+
+// readLabels reads account,category lines, # starts a comment
+func readLabels(path string) (map[string]string, error) {
+	labels := make(map[string]string)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid labels file %s: %v", path, err)
+		}
+
+		labels[strings.TrimSpace(record[0])] = strings.TrimSpace(record[1])
+	}
+
+	return labels, nil
+}
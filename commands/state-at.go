@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/astroband/astrologer/es"
+	"github.com/olekukonko/tablewriter"
+)
+
+// StateAtCommandConfig represents configuration options for the `state-at` CLI command
+type StateAtCommandConfig struct {
+	AccountID string
+	Seq       int
+	JSON      bool
+}
+
+// StateAtCommand represents the `state-at` CLI command
+type StateAtCommand struct {
+	ES     es.Adapter
+	Config StateAtCommandConfig
+}
+
+type stateBalance struct {
+	Value       string   `json:"value"`
+	PagingToken string   `json:"paging_token"`
+	Asset       es.Asset `json:"asset"`
+}
+
+type stateTrustLine struct {
+	Asset       es.Asset `json:"asset"`
+	Balance     string   `json:"balance"`
+	Limit       string   `json:"limit"`
+	Authorized  bool     `json:"authorized"`
+	PagingToken string   `json:"paging_token"`
+	Deleted     bool     `json:"deleted"`
+}
+
+type stateOffer struct {
+	OfferID     int64     `json:"offer_id"`
+	Selling     *es.Asset `json:"selling"`
+	Buying      *es.Asset `json:"buying"`
+	Amount      string    `json:"amount"`
+	Price       float64   `json:"price"`
+	PagingToken string    `json:"paging_token"`
+	Deleted     bool      `json:"deleted"`
+}
+
+type stateSigner struct {
+	Signer      string `json:"signer"`
+	Weight      int    `json:"weight"`
+	PagingToken string `json:"paging_token"`
+}
+
+// Run prints account state as of the given ledger
+func (cmd *StateAtCommand) Run(ctx context.Context) error {
+	state, err := cmd.ES.AccountStateAt(cmd.Config.AccountID, cmd.Config.Seq)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Config.JSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(state)
+	}
+
+	fmt.Println("Account", state.AccountID, "as of ledger", state.Seq)
+
+	balances := tablewriter.NewWriter(os.Stdout)
+	balances.SetHeader([]string{"Asset", "Balance", "Changed at"})
+
+	for _, doc := range state.Balances {
+		var b stateBalance
+
+		if err := json.Unmarshal(doc, &b); err != nil {
+			return err
+		}
+
+		balances.Append([]string{stateAssetKey(&b.Asset), b.Value, b.PagingToken})
+	}
+
+	balances.Render()
+
+	signers := tablewriter.NewWriter(os.Stdout)
+	signers.SetHeader([]string{"Signer", "Weight", "Changed at"})
+
+	for _, doc := range state.Signers {
+		var s stateSigner
+
+		if err := json.Unmarshal(doc, &s); err != nil {
+			return err
+		}
+
+		if s.Weight == 0 {
+			continue
+		}
+
+		signers.Append([]string{s.Signer, strconv.Itoa(s.Weight), s.PagingToken})
+	}
+
+	signers.Render()
+
+	trustLines := tablewriter.NewWriter(os.Stdout)
+	trustLines.SetHeader([]string{"Asset", "Balance", "Limit", "Authorized", "Changed at"})
+
+	for _, doc := range state.TrustLines {
+		var t stateTrustLine
+
+		if err := json.Unmarshal(doc, &t); err != nil {
+			return err
+		}
+
+		if t.Deleted {
+			continue
+		}
+
+		trustLines.Append([]string{stateAssetKey(&t.Asset), t.Balance, t.Limit, strconv.FormatBool(t.Authorized), t.PagingToken})
+	}
+
+	trustLines.Render()
+
+	offers := tablewriter.NewWriter(os.Stdout)
+	offers.SetHeader([]string{"Offer", "Selling", "Buying", "Amount", "Price", "Changed at"})
+
+	for _, doc := range state.Offers {
+		var o stateOffer
+
+		if err := json.Unmarshal(doc, &o); err != nil {
+			return err
+		}
+
+		if o.Deleted {
+			continue
+		}
+
+		offers.Append([]string{
+			strconv.FormatInt(o.OfferID, 10),
+			stateAssetKey(o.Selling),
+			stateAssetKey(o.Buying),
+			o.Amount,
+			strconv.FormatFloat(o.Price, 'f', -1, 64),
+			o.PagingToken,
+		})
+	}
+
+	offers.Render()
+
+	return nil
+}
+
+// stateAssetKey returns CODE:ISSUER key of the asset, documents written before the key switch have legacy ids
+func stateAssetKey(a *es.Asset) string {
+	if a == nil {
+		return ""
+	}
+
+	if a.Issuer == "" {
+		return a.Code
+	}
+
+	return es.AssetKey(a.Code, a.Issuer)
+}
@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -10,22 +12,41 @@ import (
 	"github.com/olekukonko/tablewriter"
 )
 
+// StatsCommandConfig represents configuration options for the `stats` CLI command
+type StatsCommandConfig struct {
+	// Start and Count select the range to count transactions and operations in, zero Start skips counting
+	Start int
+	Count int
+}
+
 // StatsCommand represents a `stats` CLI command
 type StatsCommand struct {
-	ES es.Adapter
-	DB db.Adapter
+	ES     es.Adapter
+	DB     db.Adapter
+	Config StatsCommandConfig
 }
 
-// Execute prints ledger statistics for the current database
-func (cmd *StatsCommand) Execute() {
+// Run prints ledger statistics for the current database
+func (cmd *StatsCommand) Run(ctx context.Context) error {
 	var g []int
 
-	first := cmd.DB.LedgerHeaderFirstRow()
-	last := cmd.DB.LedgerHeaderLastRow()
-	gaps := cmd.DB.LedgerHeaderGaps()
+	first, err := cmd.DB.LedgerHeaderFirstRow()
+	if err != nil {
+		return err
+	}
+
+	last, err := cmd.DB.LedgerHeaderLastRow()
+	if err != nil {
+		return err
+	}
 
 	if (first == nil) || (last == nil) {
-		fmt.Println("Current database is empty!")
+		return errors.New("current database is empty")
+	}
+
+	gaps, err := cmd.DB.LedgerHeaderGaps()
+	if err != nil {
+		return err
 	}
 
 	table := tablewriter.NewWriter(os.Stdout)
@@ -48,7 +69,11 @@ func (cmd *StatsCommand) Execute() {
 		min := g[i*2]
 		max := g[i*2+1]
 		count := max - min + 1
-		countES := cmd.ES.LedgerCountInRange(min, max)
+
+		countES, err := cmd.ES.LedgerCountInRange(min, max)
+		if err != nil {
+			return err
+		}
 
 		total += count
 		totalES += countES
@@ -64,4 +89,47 @@ func (cmd *StatsCommand) Execute() {
 	table.SetFooter([]string{"", "Total", strconv.Itoa(total), strconv.Itoa(totalES)})
 
 	table.Render()
+
+	if cmd.Config.Start > 0 {
+		return cmd.renderTxStats()
+	}
+
+	return nil
+}
+
+// renderTxStats prints transaction and operation counts of the range, helps to estimate export size
+func (cmd *StatsCommand) renderTxStats() error {
+	last := cmd.Config.Start + cmd.Config.Count - 1
+
+	ledgers, err := cmd.DB.LedgerHeaderRowCount(cmd.Config.Start, last)
+	if err != nil {
+		return err
+	}
+
+	s, err := cmd.DB.TxStatsInRange(cmd.Config.Start, last)
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Ledgers", "Transactions", "Failed", "Operations", "Ops/ledger", "Size"})
+
+	opsPerLedger := 0.0
+	if ledgers > 0 {
+		opsPerLedger = float64(s.Operations) / float64(ledgers)
+	}
+
+	table.Append([]string{
+		strconv.Itoa(ledgers),
+		strconv.Itoa(s.Transactions),
+		fmt.Sprintf("%d (%.2f%%)", s.Failed, s.FailedRatio()*100),
+		strconv.Itoa(s.Operations),
+		fmt.Sprintf("%.1f", opsPerLedger),
+		humanBytes(s.Bytes),
+	})
+
+	fmt.Printf("Ledgers %d-%d:\n", cmd.Config.Start, last)
+	table.Render()
+
+	return nil
 }
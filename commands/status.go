@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/astroband/astrologer/db"
+	"github.com/astroband/astrologer/es"
+	"github.com/olekukonko/tablewriter"
+)
+
+// StatusCommandConfig represents configuration options for the `status` CLI command
+type StatusCommandConfig struct {
+	// MaxLag fails the command when ES is behind the database by more ledgers, zero disables the check
+	MaxLag int
+
+	SerializeOptions es.SerializeOptions
+}
+
+// StatusCommand represents the `status` CLI command
+type StatusCommand struct {
+	ES     es.Adapter
+	DB     db.Adapter
+	Config StatusCommandConfig
+}
+
+// Run prints the health summary, returns error if the cluster is red or ES lags more than allowed
+func (cmd *StatusCommand) Run(ctx context.Context) error {
+	head, err := cmd.DB.LedgerHeaderLastRow()
+	if err != nil {
+		return err
+	}
+
+	if head == nil {
+		return errors.New("current database is empty")
+	}
+
+	gaps, err := cmd.DB.LedgerHeaderGaps()
+	if err != nil {
+		return err
+	}
+
+	health, err := cmd.ES.ClusterHealth()
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Index", "Last ledger", "Lag"})
+
+	indices := es.LedgerIndices(cmd.Config.SerializeOptions)
+	ledgers, err := cmd.ES.IndexedLedgers(indices)
+	if err != nil {
+		return err
+	}
+
+	for _, index := range indices {
+		seq, ok := ledgers[index]
+		if !ok {
+			table.Append([]string{string(index), "-", "-"})
+			continue
+		}
+
+		table.Append([]string{string(index), strconv.Itoa(seq), strconv.Itoa(head.LedgerSeq - seq)})
+	}
+
+	table.Render()
+
+	min, max, err := cmd.ES.MinMaxRange()
+	if err != nil {
+		return err
+	}
+
+	closeTime, err := cmd.ES.LastLedgerCloseTime()
+	if err != nil {
+		return err
+	}
+
+	lag := head.LedgerSeq - max
+	lagTime := time.Unix(head.CloseTime, 0).Sub(closeTime)
+
+	missing := 0
+	if max > 0 {
+		count, err := cmd.ES.LedgerCountInRange(min, max)
+		if err != nil {
+			return err
+		}
+
+		missing = max - min + 1 - count
+	}
+
+	fmt.Printf("Database head: %d\n", head.LedgerSeq)
+	fmt.Printf("ES last ledger: %d, lag: %d ledgers, %.1f minutes\n", max, lag, lagTime.Minutes())
+	fmt.Printf("ES gaps: %d ledgers missing between %d and %d\n", missing, min, max)
+	fmt.Printf("Database gaps: %d\n", len(gaps))
+	fmt.Printf("Cluster health: %s\n", health)
+
+	if health == "red" {
+		return errors.New("cluster health is red")
+	}
+
+	if cmd.Config.MaxLag > 0 && lag > cmd.Config.MaxLag {
+		return fmt.Errorf("ES is %d ledgers behind the database, more than %d allowed", lag, cmd.Config.MaxLag)
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/astroband/astrologer/db"
+	"github.com/astroband/astrologer/es"
+)
+
+// VerifyCommandConfig represents configuration options for the `verify` CLI command
+type VerifyCommandConfig struct {
+	Start int
+	Count int
+
+	// Sample is the number of random ledgers of the range to compare, zero compares every ledger
+	Sample int
+
+	SerializeOptions es.SerializeOptions
+}
+
+// VerifyCommand represents the `verify` CLI command
+type VerifyCommand struct {
+	ES     es.Adapter
+	DB     db.Adapter
+	Config VerifyCommandConfig
+}
+
+// Run serializes ledgers from the database again and compares documents with ones stored in ES,
+// returns error if any ledger differs
+func (cmd *VerifyCommand) Run(ctx context.Context) error {
+	if cmd.Config.Start <= 0 || cmd.Config.Count <= 0 {
+		return fmt.Errorf("invalid range: start %d, count %d", cmd.Config.Start, cmd.Config.Count)
+	}
+
+	seqs := cmd.sample()
+	failed := 0
+
+	log.Println("Verifying", len(seqs), "ledgers from", cmd.Config.Start, "to", cmd.Config.Start+cmd.Config.Count-1)
+
+	for _, seq := range seqs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		mismatches, err := cmd.verifyLedger(seq)
+		if err != nil {
+			return err
+		}
+
+		for _, mismatch := range mismatches {
+			log.Printf("Ledger %d: %s", seq, mismatch)
+		}
+
+		if len(mismatches) > 0 {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d ledgers differ, reingest them to fix", failed, len(seqs))
+	}
+
+	log.Println("All", len(seqs), "ledgers match")
+
+	return nil
+}
+
+// sample returns sorted ledger sequences to verify
+func (cmd *VerifyCommand) sample() []int {
+	if cmd.Config.Sample <= 0 || cmd.Config.Sample >= cmd.Config.Count {
+		seqs := make([]int, cmd.Config.Count)
+
+		for n := range seqs {
+			seqs[n] = cmd.Config.Start + n
+		}
+
+		return seqs
+	}
+
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	seqs := random.Perm(cmd.Config.Count)[:cmd.Config.Sample]
+
+	for n := range seqs {
+		seqs[n] += cmd.Config.Start
+	}
+
+	sort.Ints(seqs)
+
+	return seqs
+}
+
+// verifyLedger compares documents of the ledger by content hash, reports them by document id
+func (cmd *VerifyCommand) verifyLedger(seq int) (mismatches []string, err error) {
+	var b bytes.Buffer
+
+	rows, err := cmd.DB.LedgerHeaderRowFetchRange(seq, seq)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return []string{"missing in the database"}, nil
+	}
+
+	txs, fees, err := ledgerTransactions(cmd.DB, seq)
+	if err != nil {
+		return nil, err
+	}
+
+	options := cmd.Config.SerializeOptions
+	options.DocIDs = true
+
+	if err := es.SerializeLedger(rows[0], txs, fees, &b, options); err != nil {
+		return nil, fmt.Errorf("failed to serialize ledger %d: %v", seq, err)
+	}
+
+	docs, err := es.BulkDocuments(b.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	expected := make(map[es.IndexName]map[string][]string)
+
+	for _, doc := range docs {
+		if expected[doc.Index] == nil {
+			expected[doc.Index] = make(map[string][]string)
+		}
+
+		hash, err := es.ContentHash(doc.Source)
+		if err != nil {
+			return nil, err
+		}
+
+		expected[doc.Index][hash] = append(expected[doc.Index][hash], doc.ID)
+	}
+
+	// Indices without expected documents are checked too, stray documents of the ledger are reported
+	for _, index := range es.AppendOnlyLedgerIndices(options) {
+		hashes := expected[index]
+
+		stored, err := cmd.ES.LedgerDocuments(index, seq)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, doc := range stored {
+			hash, err := es.ContentHash(doc.Source)
+			if err != nil {
+				return nil, fmt.Errorf("%s/%s: %v", index, doc.ID, err)
+			}
+
+			if ids := hashes[hash]; len(ids) > 0 {
+				hashes[hash] = ids[1:]
+				continue
+			}
+
+			mismatches = append(mismatches, fmt.Sprintf("%s/%s is not expected or differs", index, doc.ID))
+		}
+
+		for _, ids := range hashes {
+			for _, id := range ids {
+				mismatches = append(mismatches, fmt.Sprintf("%s/%s is missing or differs", index, id))
+			}
+		}
+	}
+
+	sort.Strings(mismatches)
+
+	return mismatches, nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const liveConfigPollInterval = 5 * time.Second
+
+// LiveSettings represents settings which can be changed without restarting ingest, nil means command line value is used
+type LiveSettings struct {
+	// Accounts limits ingested documents to ones involving the accounts, empty list ingests everything
+	Accounts []string `json:"accounts"`
+
+	// Assets limits ingested operations, payments, balances and trades to ones involving the assets,
+	// native or CODE:ISSUER, empty list ingests everything
+	Assets []string `json:"assets"`
+
+	// OpTypes limits operations and payments indices to operations of the types, empty list indexes all
+	OpTypes []string `json:"op_types"`
+
+	// Labels maps account IDs to categories of flows rolled up by ingest
+	Labels map[string]string `json:"labels"`
+
+	// DBMaxQPS limits the rate of database queries, zero disables the limit
+	DBMaxQPS *int `json:"db_max_qps"`
+}
+
+// LiveConfig holds LiveSettings reloaded from the file on SIGHUP or when the file changes
+type LiveConfig struct {
+	path     string
+	validate func(LiveSettings) error
+	mutex    sync.RWMutex
+	settings LiveSettings
+	modTime  time.Time
+}
+
+// WatchLiveConfig loads settings from the file and starts watching it,
+// settings rejected by validate are not applied, validate is optional
+func WatchLiveConfig(path string, validate func(LiveSettings) error) (*LiveConfig, error) {
+	c := &LiveConfig{path: path, validate: validate}
+
+	if err := c.reload(); err != nil {
+		return nil, fmt.Errorf("failed to load live config: %v", err)
+	}
+
+	go c.watch()
+
+	return c, nil
+}
+
+// Settings returns current settings
+func (c *LiveConfig) Settings() LiveSettings {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.settings
+}
+
+func (c *LiveConfig) watch() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	ticker := time.NewTicker(liveConfigPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hup:
+		case <-ticker.C:
+			if !c.changed() {
+				continue
+			}
+		}
+
+		// Broken file keeps previous settings in effect
+		if err := c.reload(); err != nil {
+			log.Println("Failed to reload live config, keeping previous settings:", err)
+			continue
+		}
+
+		log.Println("Live config reloaded from", c.path)
+	}
+}
+
+func (c *LiveConfig) changed() bool {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return false
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return !info.ModTime().Equal(c.modTime)
+}
+
+func (c *LiveConfig) reload() error {
+	var settings LiveSettings
+
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return err
+	}
+
+	if c.validate != nil {
+		if err := c.validate(settings); err != nil {
+			return err
+		}
+	}
+
+	c.mutex.Lock()
+	c.settings = settings
+	c.modTime = info.ModTime()
+	c.mutex.Unlock()
+
+	return nil
+}
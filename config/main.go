@@ -1,8 +1,12 @@
 package config
 
 import (
+	"bufio"
 	"fmt"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/alecthomas/kingpin.v2"
 )
@@ -49,19 +53,234 @@ func NumberWithSignParse(s kingpin.Settings) (target *NumberWithSign) {
 	return
 }
 
+// Date represents the point in time given as a date (UTC) or RFC 3339 timestamp
+type Date struct {
+	time.Time
+}
+
+// Set parses the date
+func (d *Date) Set(value string) error {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, value); err != nil {
+			return fmt.Errorf("invalid date %q, use 2006-01-02 or RFC 3339", value)
+		}
+	}
+
+	d.Time = t
+
+	return nil
+}
+
+func (d *Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+
+	return d.Format(time.RFC3339)
+}
+
+// DateParse is a helper for the kingpin date parser
+func DateParse(s kingpin.Settings) (target *Date) {
+	target = &Date{}
+	s.SetValue(target)
+	return
+}
+
+// LedgerRange represents inclusive range of ledgers
+type LedgerRange struct {
+	First int
+	Last  int
+}
+
+// LedgerRanges represents the list of ranges passed as "100-200,5000-6000"
+type LedgerRanges []LedgerRange
+
+func (r *LedgerRanges) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+
+		if item == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(item, "-", 2)
+
+		if len(bounds) != 2 {
+			return fmt.Errorf("invalid ledger range %q, expected FIRST-LAST", item)
+		}
+
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return err
+		}
+
+		last, err := strconv.Atoi(bounds[1])
+		if err != nil {
+			return err
+		}
+
+		if first <= 0 || last < first {
+			return fmt.Errorf("invalid ledger range %q", item)
+		}
+
+		*r = append(*r, LedgerRange{first, last})
+	}
+
+	return nil
+}
+
+// Clamp returns the range limited to [min, max], false if there is no intersection
+func (r LedgerRange) Clamp(min, max int) (LedgerRange, bool) {
+	if r.First < min {
+		r.First = min
+	}
+
+	if r.Last > max {
+		r.Last = max
+	}
+
+	return r, r.First <= r.Last
+}
+
+func (r *LedgerRanges) String() string {
+	items := make([]string, len(*r))
+
+	for i, item := range *r {
+		items[i] = fmt.Sprintf("%d-%d", item.First, item.Last)
+	}
+
+	return strings.Join(items, ",")
+}
+
+// Count returns the number of ledgers in all ranges
+func (r LedgerRanges) Count() (n int) {
+	for _, item := range r {
+		n += item.Last - item.First + 1
+	}
+
+	return n
+}
+
+// IsCumulative allows the flag to be repeated
+func (r *LedgerRanges) IsCumulative() bool {
+	return true
+}
+
+// LedgerRangesParse is a helper for the kingpin ranges parser
+func LedgerRangesParse(s kingpin.Settings) (target *LedgerRanges) {
+	target = &LedgerRanges{}
+	s.SetValue(target)
+	return
+}
+
+// ReadLedgerRangesFile reads ranges from the file, one or more comma separated ranges per line, # starts a comment
+func ReadLedgerRangesFile(path string) (LedgerRanges, error) {
+	var ranges LedgerRanges
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		if err := ranges.Set(line); err != nil {
+			return nil, err
+		}
+	}
+
+	return ranges, scanner.Err()
+}
+
+// ReadAccountsFile reads account IDs, one per line, # starts a comment
+func ReadAccountsFile(path string) ([]string, error) {
+	var ids []string
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		if line = strings.TrimSpace(line); line != "" {
+			ids = append(ids, line)
+		}
+	}
+
+	return ids, scanner.Err()
+}
+
 var (
 	createIndexCommand = kingpin.Command("create-index", "Create ES indexes")
+	dumpMappings       = kingpin.Command("dump-mappings", "Print index settings and mappings create-index would create as JSON")
 	exportCommand      = kingpin.Command("export", "Run export")
 	ingestCommand      = kingpin.Command("ingest", "Start real time ingestion")
-	_                  = kingpin.Command("stats", "Print database ledger statistics")
-	_                  = kingpin.Command("es-stats", "Print ES ranges stats")
+	exportAccount      = kingpin.Command("export-account", "Export everything about the account into JSON bundle")
+	stateAtCommand     = kingpin.Command("state-at", "Reconstruct account state as of the given ledger")
+	statsCommand       = kingpin.Command("stats", "Print database ledger statistics")
+	esStatsCommand     = kingpin.Command("es-stats", "Print ES ranges stats")
+	statusCommand      = kingpin.Command("status", "Print database head, ES lag, gaps and cluster health")
+	verifyCommand      = kingpin.Command("verify", "Compare indexed documents with ledgers serialized from the database again")
+	reingestCommand    = kingpin.Command("reingest", "Delete and index the range again, e.g. after serialization fixes")
+	reindexCommand     = kingpin.Command("reindex", "Copy indices into new ones created with current mappings and point index names to them")
+	fillGapsCommand    = kingpin.Command("fill-gaps", "Index ledgers missing between the first and the last indexed ones")
+	replayDLQCommand   = kingpin.Command("replay-dlq", "Submit documents of the dead letter queue again")
+	rollupCommand      = kingpin.Command("rollup", "Build rollups from indexed documents")
+	rollupFlowsCommand = rollupCommand.Command("flows", "Build payment flows between account categories")
 
-	// DatabaseURL Stellar Core database URL
+	// DatabaseURL Stellar Core database URLs, the primary and its read replicas
 	DatabaseURL = kingpin.
-			Flag("database-url", "Stellar Core database URL").
+			Flag("database-url", "Stellar Core database URL, repeat for read replicas to spread export batches and fail over").
 			Default("postgres://localhost/core?sslmode=disable").
 			OverrideDefaultFromEnvar("DATABASE_URL").
-			URL()
+			URLList()
+
+	// DBRetries query retries on database connection errors
+	DBRetries = kingpin.
+			Flag("db-retries", "Retries of queries failed because of lost database connection, with exponential backoff up to a minute, -1 retries forever").
+			Default("10").
+			Int()
+
+	// DBMaxQPS limits the rate of database queries
+	DBMaxQPS = kingpin.
+			Flag("db-max-qps", "Maximum database queries per second, 0 means no limit").
+			Int()
+
+	// NetworkPassphrase passphrase of the exported network
+	NetworkPassphrase = kingpin.
+				Flag("network-passphrase", "Network passphrase, names the network of documents and hashes transactions read from history archives").
+				Default("Public Global Stellar Network ; September 2015").
+				OverrideDefaultFromEnvar("NETWORK_PASSPHRASE").
+				String()
+
+	// Network name stored in documents, derived from the passphrase of well-known networks
+	Network = kingpin.
+		Flag("network", "Network name stored in the network field of documents, e.g. pubnet, required for custom passphrases").
+		OverrideDefaultFromEnvar("NETWORK").
+		String()
+
+	// NetworkIndexPrefix prefixes index names with the network name
+	NetworkIndexPrefix = kingpin.
+				Flag("network-index-prefix", "Prefix index names with the network name, e.g. pubnet_op, to host several networks in one cluster").
+				Bool()
 
 	// EsURL ElasticSearch URL
 	EsURL = kingpin.
@@ -70,6 +289,92 @@ var (
 		OverrideDefaultFromEnvar("ES_URL").
 		URL()
 
+	// EsAPIKey ElasticSearch API key
+	EsAPIKey = kingpin.
+			Flag("es-api-key", "ElasticSearch API key, base64 encoded id:api_key").
+			OverrideDefaultFromEnvar("ES_API_KEY").
+			String()
+
+	// EsCloudID Elastic Cloud deployment ID, replaces --es-url
+	EsCloudID = kingpin.
+			Flag("es-cloud-id", "Elastic Cloud deployment ID, replaces --es-url").
+			OverrideDefaultFromEnvar("ES_CLOUD_ID").
+			String()
+
+	// EsUsername ElasticSearch user name
+	EsUsername = kingpin.
+			Flag("es-username", "ElasticSearch user name").
+			OverrideDefaultFromEnvar("ES_USERNAME").
+			String()
+
+	// EsPassword ElasticSearch password
+	EsPassword = kingpin.
+			Flag("es-password", "ElasticSearch password").
+			OverrideDefaultFromEnvar("ES_PASSWORD").
+			String()
+
+	// EsCACert custom CA bundle to verify ElasticSearch certificate
+	EsCACert = kingpin.
+			Flag("es-ca-cert", "PEM file with CA certificates to verify ElasticSearch certificate").
+			OverrideDefaultFromEnvar("ES_CA_CERT").
+			ExistingFile()
+
+	// EsClientCert client certificate for ElasticSearch
+	EsClientCert = kingpin.
+			Flag("es-client-cert", "PEM file with client certificate").
+			OverrideDefaultFromEnvar("ES_CLIENT_CERT").
+			ExistingFile()
+
+	// EsClientKey client certificate key for ElasticSearch
+	EsClientKey = kingpin.
+			Flag("es-client-key", "PEM file with client certificate key").
+			OverrideDefaultFromEnvar("ES_CLIENT_KEY").
+			ExistingFile()
+
+	// EsInsecureSkipVerify disables ElasticSearch certificate verification
+	EsInsecureSkipVerify = kingpin.
+				Flag("insecure-skip-verify", "Do not verify ElasticSearch certificate").
+				OverrideDefaultFromEnvar("ES_INSECURE_SKIP_VERIFY").
+				Bool()
+
+	// Output writes documents to the given destinations instead of ElasticSearch, the first one serves reads
+	Output = kingpin.
+		Flag("output", "Write documents to the destination instead of ElasticSearch, e.g. file:///var/lib/astrologer, kafka://broker:9092/stellar. or parquet+s3://bucket/prefix, repeat for several outputs").
+		OverrideDefaultFromEnvar("OUTPUT").
+		URLList()
+
+	// DLQ dead letter queue file
+	DLQ = kingpin.
+		Flag("dlq", "Append documents failed permanently or after all retries to the JSON lines file instead of aborting").
+		OverrideDefaultFromEnvar("DLQ").
+		String()
+
+	// EsGzip compresses bulk requests
+	EsGzip = kingpin.
+		Flag("es-gzip", "Compress bulk requests with gzip, use --no-es-gzip to disable").
+		Default("true").
+		OverrideDefaultFromEnvar("ES_GZIP").
+		Bool()
+
+	// InjectFailures fails the given percentage of bulk writes, used by integration tests
+	InjectFailures = kingpin.
+			Flag("inject-failures", "Fail the given percentage of bulk writes to test retries").
+			Hidden().
+			Default("0").
+			Float64()
+
+	// OutputMaxSize rotates output files after the given size
+	OutputMaxSize = kingpin.
+			Flag("output-max-size", "Rotate output files after the given size").
+			Default("100MB").
+			Bytes()
+
+	// KafkaFormat encoding of messages published to Kafka output
+	KafkaFormat = kingpin.
+			Flag("kafka-format", "Encoding of Kafka messages").
+			Default("json").
+			Enum("json", "msgpack")
+
 	// Concurrency How many tasks and goroutines to produce (all at once for now)
 	Concurrency = kingpin.
 			Flag("concurrency", "Concurrency for indexing").
@@ -78,6 +383,133 @@ var (
 			OverrideDefaultFromEnvar("CONCURRENCY").
 			Int()
 
+	// IndexChanges enables raw ledger entry changes index
+	IndexChanges = kingpin.
+			Flag("index-changes", "Index raw ledger entry changes into the changes index").
+			OverrideDefaultFromEnvar("INDEX_CHANGES").
+			Bool()
+
+	// IndexPayments enables payments index
+	IndexPayments = kingpin.
+			Flag("index-payments", "Index payment-like operations into the payments index").
+			OverrideDefaultFromEnvar("INDEX_PAYMENTS").
+			Bool()
+
+	// IndexEffects enables effects index
+	IndexEffects = kingpin.
+			Flag("index-effects", "Index Horizon-style effects of successful operations into the effects index").
+			OverrideDefaultFromEnvar("INDEX_EFFECTS").
+			Bool()
+
+	// IndexOffers enables offers index
+	IndexOffers = kingpin.
+			Flag("index-offers", "Index the latest state of order book offers into the offers index").
+			OverrideDefaultFromEnvar("INDEX_OFFERS").
+			Bool()
+
+	// IndexTrustLines enables trustlines index
+	IndexTrustLines = kingpin.
+			Flag("index-trustlines", "Index the latest state of trustlines into the trustlines index").
+			OverrideDefaultFromEnvar("INDEX_TRUSTLINES").
+			Bool()
+
+	// IndexAccounts enables accounts index
+	IndexAccounts = kingpin.
+			Flag("index-accounts", "Index the latest state of accounts into the accounts index").
+			OverrideDefaultFromEnvar("INDEX_ACCOUNTS").
+			Bool()
+
+	// IndexDataEntries enables data_entries index
+	IndexDataEntries = kingpin.
+				Flag("index-data-entries", "Index the latest state of account data entries into the data_entries index").
+				OverrideDefaultFromEnvar("INDEX_DATA_ENTRIES").
+				Bool()
+
+	// OpTypes operation types to index
+	OpTypes = kingpin.
+		Flag("op-type", "Index only operations of the type into operations and payments indices, e.g. payment, repeat for several types").
+		Strings()
+
+	// MemoRequired flags payments to accounts requiring memo
+	MemoRequired = kingpin.
+			Flag("memo-required", "Flag payments to accounts with config.memo_required data entry (SEP-29)").
+			OverrideDefaultFromEnvar("MEMO_REQUIRED").
+			Bool()
+
+	// MemoRequiredAccountsFile known accounts requiring memo
+	MemoRequiredAccountsFile = kingpin.
+					Flag("memo-required-accounts", "File with known accounts requiring memo, one per line, implies --memo-required").
+					ExistingFile()
+
+	// TradeAggregations enables OHLCV candles rollup
+	TradeAggregations = kingpin.
+				Flag("trade-aggregations", "Build 1m/1h/1d OHLCV candles from trades into the trade_aggregations index").
+				OverrideDefaultFromEnvar("TRADE_AGGREGATIONS").
+				Bool()
+
+	// Timestamps @timestamp source per index
+	Timestamps = kingpin.
+			Flag("timestamp", "Add @timestamp to documents of the index from ledger close time or ingestion time, e.g. tx=close, *=ingest").
+			PlaceHolder("INDEX=close|ingest").
+			StringMap()
+
+	// DataStreams indices written as data streams
+	DataStreams = kingpin.
+			Flag("data-stream", "Write the index as a data stream, e.g. op or balance, repeat for several indices").
+			PlaceHolder("INDEX").
+			Strings()
+
+	// DataStreamPolicy ILM policy of data streams
+	DataStreamPolicy = kingpin.
+				Flag("data-stream-policy", "ILM policy attached to data stream templates by create-index").
+				String()
+
+	// AllowSchemaDrift only warns when indices schema version differs from the binary one
+	AllowSchemaDrift = kingpin.
+				Flag("allow-schema-drift", "Warn instead of failing when indices were created by a different schema version").
+				Bool()
+
+	// LegacyAssetKeys writes CODE-ISSUER asset keys along with CODE:ISSUER
+	LegacyAssetKeys = kingpin.
+			Flag("legacy-asset-keys", "Also write old CODE-ISSUER asset keys as asset legacy_id during migration").
+			OverrideDefaultFromEnvar("LEGACY_ASSET_KEYS").
+			Bool()
+
+	// IndexStats enables stats rollup documents
+	IndexStats = kingpin.
+			Flag("index-stats", "Write per-ledger and per-hour stats documents into the stats index").
+			OverrideDefaultFromEnvar("INDEX_STATS").
+			Bool()
+
+	// CheckChain verifies previous ledger hashes before indexing
+	CheckChain = kingpin.
+			Flag("check-chain", "Verify that every ledger references the hash of the previous one, use --no-check-chain to disable").
+			Default("true").
+			Bool()
+
+	// Audit enables hash chain over exported batches
+	Audit = kingpin.
+		Flag("audit", "Store hash chain over exported batches into the audit index").
+		OverrideDefaultFromEnvar("AUDIT").
+		Bool()
+
+	// MaxDuration stops export or ingest after the given duration
+	MaxDuration = kingpin.
+			Flag("max-duration", "Stop cleanly after the given duration (e.g. 2h), exits with code 3").
+			OverrideDefaultFromEnvar("MAX_DURATION").
+			Duration()
+
+	// Profile profiler kind
+	Profile = kingpin.
+		Flag("profile", "Write pprof profile of the given kind for the duration of the command").
+		Enum("cpu", "mem", "block")
+
+	// ProfileDir directory to write profiles to
+	ProfileDir = kingpin.
+			Flag("profile-dir", "Directory to write profiles to").
+			Default(".").
+			String()
+
 	// BatchSize Batch size for bulk export
 	BatchSize = exportCommand.
 			Flag("batch", "Ledger batch size").
@@ -85,6 +517,11 @@ var (
 			Default("50").
 			Int()
 
+	// BalanceBatches balances batches by transaction count
+	BalanceBatches = exportCommand.
+			Flag("balance-batches", "Balance batches by transaction count instead of ledger count").
+			Bool()
+
 	// Retries Number of retries
 	Retries = exportCommand.
 		Flag("retries", "Retries count").
@@ -97,15 +534,225 @@ var (
 	// Count ledgers
 	Count = exportCommand.Arg("count", "Count of ledgers to ingest, should be aliquout batch size").Default("0").Int()
 
+	// End last ledger to export instead of count
+	End = exportCommand.Flag("end", "Last ledger to export, inclusive, instead of count").Int()
+
+	// From export ledgers closed at or after the date
+	From = DateParse(exportCommand.Flag("from", "Export ledgers closed at or after the date, e.g. 2023-01-01 or 2023-01-01T12:00:00Z, instead of start"))
+
+	// To export ledgers closed before the date
+	To = DateParse(exportCommand.Flag("to", "Export ledgers closed before the date, instead of count"))
+
+	// Ranges ledger ranges to export instead of start/count
+	Ranges = LedgerRangesParse(exportCommand.Flag("ranges", "Comma separated ledger ranges to export, e.g. 100-200,5000-6000"))
+
+	// RangesFile file containing ledger ranges to export
+	RangesFile = exportCommand.Flag("ranges-file", "File with ledger ranges to export, one or more per line").ExistingFile()
+
 	// StartIngest ledger to start with ingesting
 	StartIngest = ingestCommand.Arg("start", "Ledger to start ingesting").Int()
 
+	// IngestResume starts ingest after the last indexed ledger
+	IngestResume = ingestCommand.Flag("resume", "Start after the last ledger indexed when no start ledger is given").Default("true").Bool()
+
+	// IngestFillGaps interval of the background gap scan
+	IngestFillGaps = ingestCommand.Flag("fill-gaps", "Scan for missing ledgers on startup and with the given interval, backfill them in background, 0 disables").Default("1h").Duration()
+
+	// VerifyStart first ledger to verify
+	VerifyStart = verifyCommand.Arg("start", "First ledger to verify").Required().Int()
+
+	// VerifyCount ledgers to verify
+	VerifyCount = verifyCommand.Arg("count", "Count of ledgers to verify").Required().Int()
+
+	// VerifySample number of random ledgers to verify
+	VerifySample = verifyCommand.Flag("sample", "Verify the given number of random ledgers of the range, 0 verifies all").Int()
+
+	// ReingestStart first ledger to reingest
+	ReingestStart = reingestCommand.Arg("start", "First ledger to reingest").Required().Int()
+
+	// ReingestCount ledgers to reingest
+	ReingestCount = reingestCommand.Arg("count", "Count of ledgers to reingest").Required().Int()
+
+	// ReingestBatchSize ledgers per bulk request
+	ReingestBatchSize = reingestCommand.Flag("batch", "Ledger batch size").Short('b').Default("50").Int()
+
+	// ReingestRetries retries count
+	ReingestRetries = reingestCommand.Flag("retries", "Retries count").Default("25").Int()
+
+	// FillGapsBatchSize ledgers per bulk request
+	FillGapsBatchSize = fillGapsCommand.Flag("batch", "Ledger batch size").Short('b').Default("50").Int()
+
+	// FillGapsRetries retries count
+	FillGapsRetries = fillGapsCommand.Flag("retries", "Retries count").Default("25").Int()
+
+	// FillGapsDryRun prints gaps without filling them
+	FillGapsDryRun = fillGapsCommand.Flag("dry-run", "Print gaps without filling them").Bool()
+
+	// IngestListen waits for notifications of new ledgers instead of polling
+	IngestListen = ingestCommand.Flag("listen", "LISTEN for new ledgers on the first --database-url instead of polling, requires the notification trigger").Bool()
+
+	// LiveConfigFile file with settings reloaded by ingest without restart
+	LiveConfigFile = ingestCommand.Flag("live-config", "JSON file with settings reloaded on change or SIGHUP").ExistingFile()
+
+	// IngestFlowsAssets assets to roll up payment flows of during ingestion
+	IngestFlowsAssets = ingestCommand.Flag("flows-asset", "Roll up payment flows of the asset once a day, e.g. native or USD:GABC..., repeat for several assets").Strings()
+
+	// IngestFlowsInterval interval of flows rolled up during ingestion
+	IngestFlowsInterval = ingestCommand.Flag("flows-interval", "Interval of flows rolled up during ingestion").Default("weekly").Enum("daily", "weekly", "monthly")
+
+	// IngestFlowsLabels file mapping accounts to categories of flows rolled up during ingestion
+	IngestFlowsLabels = ingestCommand.Flag("flows-labels", "CSV file of account,category lines, labels of --live-config override it").ExistingFile()
+
 	// Verbose print data
 	Verbose = exportCommand.Flag("verbose", "Print indexed data").Bool()
 
+	// ExportVerify verifies document counts of every batch
+	ExportVerify = exportCommand.Flag("verify", "Verify document counts after every batch, delete and reindex the batch on mismatch").Bool()
+
+	// ExportClamp clamps requested ranges to ledgers available in the database
+	ExportClamp = exportCommand.Flag("clamp", "Clamp ranges to ledgers available in the database instead of failing").Bool()
+
+	// ExportIndexSummary stores end-of-range summary
+	ExportIndexSummary = exportCommand.Flag("index-summary", "Index end-of-range summary into export_summaries").Bool()
+
+	// ExportOptimize relaxes index settings during export and force merges indices afterwards
+	ExportOptimize = exportCommand.Flag("optimize", "Disable refresh and replicas during export, restore them and force merge indices when finished").Bool()
+
+	// ExportMaxNumSegments force merge target
+	ExportMaxNumSegments = exportCommand.Flag("max-num-segments", "Number of segments to force merge indices to with --optimize").Default("1").Int()
+
+	// ExportAccountFilters stores account Bloom filters per batch
+	ExportAccountFilters = exportCommand.Flag("account-filters", "Store Bloom filter of accounts involved in every batch to speed up account lookups").Bool()
+
+	// ExportMaxDocs document budget of the run
+	ExportMaxDocs = exportCommand.Flag("max-docs", "Stop cleanly after the given number of documents is exported, exits with code 4").Int()
+
+	// ExportMaxBytes byte budget of the run
+	ExportMaxBytes = exportCommand.Flag("max-bytes", "Stop cleanly after the given amount of bulk payload is exported (e.g. 10GB), exits with code 4").Bytes()
+
+	// ExportMaxReplicationLag pauses export while the database replica lags behind
+	ExportMaxReplicationLag = exportCommand.Flag("max-replication-lag", "Pause batches while the database streaming replica lags behind the primary more than given duration, e.g. 30s").Duration()
+
+	// ExportSource ledger source of export
+	ExportSource = exportCommand.Flag("source", "Read ledgers from the history archive instead of the core database, e.g. archive://history.stellar.org/prd/core-live/core_live_001 or archive+s3://bucket/path?region=us-east-1").URL()
+
+	// ExportMaxBatchInflight limits batches reading from the database at once
+	ExportMaxBatchInflight = exportCommand.Flag("db-max-batch-inflight", "Maximum batches reading from the database at once, indexing is not limited, 0 means --concurrency").Int()
+
+	// ExportReverse exports newest ledgers first
+	ExportReverse = exportCommand.Flag("reverse", "Export the most recent ledgers first and work backwards").Bool()
+
+	// ExportFollow switches export to ingestion once caught up
+	ExportFollow = exportCommand.Flag("follow", "Switch to real time ingestion after the export is complete").Bool()
+
+	// ExportCheckpoint file with progress of the export
+	ExportCheckpoint = exportCommand.Flag("checkpoint", "File recording batches fully indexed, removed when the export completes").String()
+
+	// ExportResume continues the export recorded in the checkpoint file
+	ExportResume = exportCommand.Flag("resume", "Export only ranges not recorded in the --checkpoint file").Bool()
+
+	// ReindexIndices indices to reindex, all by default
+	ReindexIndices = reindexCommand.Flag("index", "Reindex only the given index, repeat for several indices").Strings()
+
+	// ReindexScripts painless scripts transforming documents of indices
+	ReindexScripts = reindexCommand.Flag("script", "Painless script file transforming documents of the index, e.g. txs=txs.painless").StringMap()
+
+	// ReindexDeleteOld deletes replaced indices
+	ReindexDeleteOld = reindexCommand.Flag("delete-old", "Delete indices replaced by reindexed ones").Bool()
+
+	// ExportAccounts accounts to export documents of
+	ExportAccounts = exportCommand.Flag("account", "Export only documents involving the account, repeat for several accounts").Strings()
+
+	// ExportAssets assets to export operations, balances and trades of
+	ExportAssets = exportCommand.Flag("asset", "Export only operations, balances and trades involving the asset, e.g. native or USD:GABC..., repeat for several assets").Strings()
+
 	// ExportDryRun do not index data
 	ExportDryRun = exportCommand.Flag("dry-run", "Do not send actual data to Elastic").Bool()
 
+	// ExportDryRunOutput file to write bulk payloads to instead of indexing
+	ExportDryRunOutput = exportCommand.Flag("dry-run-output", "Write bulk request bodies to the file instead of indexing, implies --dry-run").String()
+
+	// DumpMappingsIndices indices to dump, all by default
+	DumpMappingsIndices = dumpMappings.Flag("index", "Dump only the given index, repeat for several indices").Strings()
+
+	// DumpMappingsOut directory to write <index>.json files to
+	DumpMappingsOut = dumpMappings.Flag("out", "Write <index>.json files into the directory instead of printing").String()
+
+	// ExportAccountID account to export
+	ExportAccountID = exportAccount.Arg("account", "Account ID").Required().String()
+
+	// ExportAccountOut bundle output directory
+	ExportAccountOut = exportAccount.Flag("out", "Output directory").Default(".").String()
+
+	// EsStatsWatch refresh interval for es-stats
+	EsStatsWatch = esStatsCommand.Flag("watch", "Refresh stats continuously with the given interval, e.g. 30s").Duration()
+
+	// EsStatsMetricsFile file to write Prometheus metrics to
+	EsStatsMetricsFile = esStatsCommand.Flag("metrics-file", "Write stats in Prometheus text format to the file").String()
+
+	// StatsStart first ledger of the range to count transactions and operations in
+	StatsStart = statsCommand.Arg("start", "First ledger of the range to count transactions and operations in").Int()
+
+	// StatsCount ledgers of the range
+	StatsCount = statsCommand.Arg("count", "Count of ledgers of the range").Default("1").Int()
+
+	// StatusMaxLag maximum ledgers ES may be behind the database
+	StatusMaxLag = statusCommand.Flag("max-lag", "Exit with error if ES is behind the database by more ledgers, 0 disables the check").Int()
+
+	// StateAtAccountID account to reconstruct
+	StateAtAccountID = stateAtCommand.Flag("account", "Account ID").Required().String()
+
+	// StateAtLedger ledger to reconstruct account state at
+	StateAtLedger = stateAtCommand.Flag("ledger", "Ledger sequence").Required().Int()
+
+	// StateAtJSON print state as JSON
+	StateAtJSON = stateAtCommand.Flag("json", "Print state as JSON").Bool()
+
+	// ReplayDLQRetries retries count of replayed documents
+	ReplayDLQRetries = replayDLQCommand.Flag("retries", "Retries count").Default("25").Int()
+
+	// RollupFlowsAsset asset ID to build flows for
+	RollupFlowsAsset = rollupFlowsCommand.Flag("asset", "Asset ID, e.g. native or USD:GABC...").Required().String()
+
+	// RollupFlowsInterval flows interval
+	RollupFlowsInterval = rollupFlowsCommand.Flag("interval", "Flows interval").Default("weekly").Enum("daily", "weekly", "monthly")
+
+	// RollupFlowsLabels file mapping accounts to categories
+	RollupFlowsLabels = rollupFlowsCommand.Flag("labels", "CSV file of account,category lines").ExistingFile()
+
 	// ForceRecreateIndexes Allows indexes to be deleted before creation
 	ForceRecreateIndexes = createIndexCommand.Flag("force", "Delete indexes before creation").Bool()
+
+	// CreateIndexShards number of primary shards of created indices
+	CreateIndexShards = createIndexCommand.Flag("shards", "Number of primary shards of every created index").String()
+
+	// CreateIndexReplicas number of replicas of created indices
+	CreateIndexReplicas = createIndexCommand.Flag("replicas", "Number of replicas of every created index").String()
+
+	// CreateIndexRefreshInterval refresh interval of created indices
+	CreateIndexRefreshInterval = createIndexCommand.Flag("refresh-interval", "Refresh interval of every created index, e.g. 30s").String()
+
+	// CreateIndexCodec compression codec of created indices
+	CreateIndexCodec = createIndexCommand.Flag("codec", "Compression codec of every created index").Enum("default", "best_compression")
+
+	// CreateIndexTemplates installs index templates with rollover aliases instead of concrete indices
+	CreateIndexTemplates = createIndexCommand.Flag("templates", "Install index templates and rollover aliases of append-only indices instead of creating indices").Bool()
+
+	// CreateIndexILMPolicy ILM policy to install and attach to index templates
+	CreateIndexILMPolicy = createIndexCommand.Flag("ilm-policy", "Install the ILM policy with the name and attach it to index templates").String()
+
+	// CreateIndexILMRolloverSize write index size triggering the rollover
+	CreateIndexILMRolloverSize = createIndexCommand.Flag("ilm-rollover-size", "Roll over the write index of the size").Default("50gb").String()
+
+	// CreateIndexILMRolloverAge write index age triggering the rollover
+	CreateIndexILMRolloverAge = createIndexCommand.Flag("ilm-rollover-age", "Roll over the write index of the age").Default("30d").String()
+
+	// CreateIndexILMWarmAfter age of the warm phase
+	CreateIndexILMWarmAfter = createIndexCommand.Flag("ilm-warm-after", "Force merge indices and lower their priority after the rollover, e.g. 7d").String()
+
+	// CreateIndexILMDeleteAfter age of the delete phase
+	CreateIndexILMDeleteAfter = createIndexCommand.Flag("ilm-delete-after", "Delete indices after the rollover, e.g. 365d, indices are kept by default").String()
+
+	// CreateIndexSettings JSON file of index settings per index
+	CreateIndexSettings = createIndexCommand.Flag("settings", `JSON file of index settings keyed by index name or "*", e.g. {"op": {"number_of_shards": 12}}`).ExistingFile()
 )
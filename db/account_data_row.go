@@ -0,0 +1,60 @@
+package db
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/stellar/go/xdr"
+)
+
+// MemoRequiredDataName is the data entry name marking accounts which require memo on incoming payments (SEP-29)
+const MemoRequiredDataName = "config.memo_required"
+
+var memoRequiredValue = base64.StdEncoding.EncodeToString([]byte("1"))
+
+type accountDataRow struct {
+	AccountID   string `db:"accountid"`
+	DataValue   string `db:"datavalue"`
+	LedgerEntry string `db:"ledgerentry"`
+}
+
+// MemoRequiredAccounts returns IDs of accounts currently having config.memo_required data entry set to 1
+func (db *Client) MemoRequiredAccounts() (ids []string, err error) {
+	var rows []accountDataRow
+
+	if !db.schema.HasTable("accountdata") {
+		return nil, nil
+	}
+
+	name := base64.StdEncoding.EncodeToString([]byte(MemoRequiredDataName))
+
+	// Newer core releases keep the whole entry as XDR
+	query := "SELECT accountid, datavalue, '' AS ledgerentry FROM accountdata WHERE dataname = $1"
+	if !db.schema.HasColumn("accountdata", "datavalue") {
+		query = "SELECT accountid, '' AS datavalue, ledgerentry FROM accountdata WHERE dataname = $1"
+	}
+
+	if err := db.selectRows(&rows, query, name); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		value := row.DataValue
+
+		if row.LedgerEntry != "" {
+			var entry xdr.LedgerEntry
+
+			if err := xdr.SafeUnmarshalBase64(row.LedgerEntry, &entry); err != nil {
+				return nil, fmt.Errorf("invalid data entry of account %s: %v", row.AccountID, err)
+			}
+
+			value = base64.StdEncoding.EncodeToString(entry.Data.MustData().DataValue)
+		}
+
+		if value == memoRequiredValue {
+			ids = append(ids, row.AccountID)
+		}
+	}
+
+	return ids, nil
+}
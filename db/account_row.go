@@ -0,0 +1,181 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/guregu/null"
+	"github.com/stellar/go/xdr"
+)
+
+// AccountRow represents row of accounts table
+type AccountRow struct {
+	AccountID          string      `db:"accountid" json:"account_id"`
+	Balance            int64       `db:"balance" json:"balance"`
+	SeqNum             int64       `db:"seqnum" json:"seq_num"`
+	NumSubEntries      int         `db:"numsubentries" json:"num_sub_entries"`
+	InflationDest      null.String `db:"inflationdest" json:"inflation_dest"`
+	HomeDomain         string      `db:"homedomain" json:"home_domain"`
+	Thresholds         string      `db:"thresholds" json:"thresholds"`
+	Flags              int         `db:"flags" json:"flags"`
+	LastModified       int         `db:"lastmodified" json:"last_modified"`
+	BuyingLiabilities  null.Int    `db:"buyingliabilities" json:"buying_liabilities"`
+	SellingLiabilities null.Int    `db:"sellingliabilities" json:"selling_liabilities"`
+}
+
+// TrustLineRow represents row of trustlines table
+type TrustLineRow struct {
+	AccountID          string   `db:"accountid" json:"account_id"`
+	AssetType          int      `db:"assettype" json:"asset_type"`
+	Issuer             string   `db:"issuer" json:"issuer"`
+	AssetCode          string   `db:"assetcode" json:"asset_code"`
+	Limit              int64    `db:"tlimit" json:"limit"`
+	Balance            int64    `db:"balance" json:"balance"`
+	Flags              int      `db:"flags" json:"flags"`
+	LastModified       int      `db:"lastmodified" json:"last_modified"`
+	BuyingLiabilities  null.Int `db:"buyingliabilities" json:"buying_liabilities"`
+	SellingLiabilities null.Int `db:"sellingliabilities" json:"selling_liabilities"`
+}
+
+// AccountRowForID returns current state of the account or nil if account does not exist
+func (db *Client) AccountRowForID(id string) (*AccountRow, error) {
+	var a AccountRow
+
+	// Newer core releases keep the whole entry as XDR
+	if !db.schema.HasColumn("accounts", "balance") {
+		return db.accountRowFromEntry(id)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT accountid, balance, seqnum, numsubentries, inflationdest, homedomain,
+		       thresholds, flags, lastmodified, %s, %s
+		FROM accounts WHERE accountid = $1
+	`,
+		db.schema.optionalColumn("accounts", "buyingliabilities"),
+		db.schema.optionalColumn("accounts", "sellingliabilities"),
+	)
+
+	err := db.get(&a, query, id)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// TrustLineRowsForAccount returns current trustlines of the account
+func (db *Client) TrustLineRowsForAccount(id string) ([]TrustLineRow, error) {
+	lines := []TrustLineRow{}
+
+	if !db.schema.HasColumn("trustlines", "balance") {
+		return db.trustLineRowsFromEntries(id)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT accountid, assettype, issuer, assetcode, tlimit, balance,
+		       flags, lastmodified, %s, %s
+		FROM trustlines WHERE accountid = $1 ORDER BY assetcode, issuer
+	`,
+		db.schema.optionalColumn("trustlines", "buyingliabilities"),
+		db.schema.optionalColumn("trustlines", "sellingliabilities"),
+	)
+
+	err := db.selectRows(&lines, query, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+func (db *Client) accountRowFromEntry(id string) (*AccountRow, error) {
+	var data string
+	var entry xdr.LedgerEntry
+
+	err := db.get(&data, "SELECT ledgerentry FROM accounts WHERE accountid = $1", id)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if err = xdr.SafeUnmarshalBase64(data, &entry); err != nil {
+		return nil, fmt.Errorf("invalid entry of account %s: %v", id, err)
+	}
+
+	a := entry.Data.MustAccount()
+
+	row := &AccountRow{
+		AccountID:     a.AccountId.Address(),
+		Balance:       int64(a.Balance),
+		SeqNum:        int64(a.SeqNum),
+		NumSubEntries: int(a.NumSubEntries),
+		HomeDomain:    string(a.HomeDomain),
+		Thresholds:    base64.StdEncoding.EncodeToString(a.Thresholds[:]),
+		Flags:         int(a.Flags),
+		LastModified:  int(entry.LastModifiedLedgerSeq),
+	}
+
+	if a.InflationDest != nil {
+		row.InflationDest = null.StringFrom(a.InflationDest.Address())
+	}
+
+	if v1, ok := a.Ext.GetV1(); ok {
+		row.BuyingLiabilities = null.IntFrom(int64(v1.Liabilities.Buying))
+		row.SellingLiabilities = null.IntFrom(int64(v1.Liabilities.Selling))
+	}
+
+	return row, nil
+}
+
+func (db *Client) trustLineRowsFromEntries(id string) ([]TrustLineRow, error) {
+	var entries []string
+
+	lines := []TrustLineRow{}
+
+	if err := db.selectRows(&entries, "SELECT ledgerentry FROM trustlines WHERE accountid = $1", id); err != nil {
+		return nil, err
+	}
+
+	for _, data := range entries {
+		var entry xdr.LedgerEntry
+		var assetType, code, issuer string
+
+		if err := xdr.SafeUnmarshalBase64(data, &entry); err != nil {
+			return nil, fmt.Errorf("invalid trust line entry of account %s: %v", id, err)
+		}
+
+		t := entry.Data.MustTrustLine()
+		t.Asset.MustExtract(&assetType, &code, &issuer)
+
+		row := TrustLineRow{
+			AccountID:    t.AccountId.Address(),
+			AssetType:    int(t.Asset.Type),
+			Issuer:       issuer,
+			AssetCode:    code,
+			Limit:        int64(t.Limit),
+			Balance:      int64(t.Balance),
+			Flags:        int(t.Flags),
+			LastModified: int(entry.LastModifiedLedgerSeq),
+		}
+
+		if v1, ok := t.Ext.GetV1(); ok {
+			row.BuyingLiabilities = null.IntFrom(int64(v1.Liabilities.Buying))
+			row.SellingLiabilities = null.IntFrom(int64(v1.Liabilities.Selling))
+		}
+
+		lines = append(lines, row)
+	}
+
+	return lines, nil
+}
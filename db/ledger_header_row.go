@@ -2,7 +2,8 @@ package db
 
 import (
 	"database/sql"
-	"log"
+	"fmt"
+	"time"
 
 	"github.com/stellar/go/xdr"
 )
@@ -17,6 +18,22 @@ type LedgerHeaderRow struct {
 	Data           xdr.LedgerHeader `db:"data"`
 }
 
+// CheckChain returns an error if the ledger directly follows prev but does not reference its hash
+func (h *LedgerHeaderRow) CheckChain(prev *LedgerHeaderRow) error {
+	if prev == nil || prev.LedgerSeq != h.LedgerSeq-1 {
+		return nil
+	}
+
+	if h.PrevHash != prev.Hash {
+		return fmt.Errorf(
+			"ledger %d previous hash %s does not match hash %s of ledger %d",
+			h.LedgerSeq, h.PrevHash, prev.Hash, prev.LedgerSeq,
+		)
+	}
+
+	return nil
+}
+
 // Gap represents gap in ledger sequence
 type Gap struct {
 	Start int `db:"gap_start"`
@@ -24,92 +41,82 @@ type Gap struct {
 }
 
 // LedgerHeaderRowCount returns total ledgers count within given range
-func (db *Client) LedgerHeaderRowCount(first, last int) int {
-	total := 0
-
+func (db *Client) LedgerHeaderRowCount(first, last int) (total int, err error) {
 	if last == 0 {
-		db.rawClient.Get(&total, "SELECT count(ledgerseq) FROM ledgerheaders WHERE ledgerseq >= $1", first)
+		err = db.get(&total, "SELECT count(ledgerseq) FROM ledgerheaders WHERE ledgerseq >= $1", first)
 	} else {
-		db.rawClient.Get(&total, "SELECT count(ledgerseq) FROM ledgerheaders WHERE ledgerseq >= $1 AND ledgerseq <= $2", first, last)
+		err = db.get(&total, "SELECT count(ledgerseq) FROM ledgerheaders WHERE ledgerseq >= $1 AND ledgerseq <= $2", first, last)
 	}
 
-	return total
+	return total, err
 }
 
 // LedgerHeaderRowFetchBatch gets bunch of ledgers
-func (db *Client) LedgerHeaderRowFetchBatch(n, start, batchSize int) []LedgerHeaderRow {
-	ledgers := []LedgerHeaderRow{}
+func (db *Client) LedgerHeaderRowFetchBatch(n, start, batchSize int) ([]LedgerHeaderRow, error) {
 	offset := n * batchSize
 	low := offset + start
 	high := low + batchSize - 1
 
-	err := db.rawClient.Select(
+	return db.LedgerHeaderRowFetchRange(low, high)
+}
+
+// LedgerHeaderRowFetchRange gets ledgers within the given inclusive range
+func (db *Client) LedgerHeaderRowFetchRange(low, high int) ([]LedgerHeaderRow, error) {
+	ledgers := []LedgerHeaderRow{}
+
+	err := db.selectRows(
 		&ledgers,
-		"SELECT * FROM ledgerheaders WHERE ledgerseq BETWEEN $1 AND $2 ORDER BY ledgerseq ASC",
+		"SELECT "+selectColumns("ledgerheaders")+" FROM ledgerheaders WHERE ledgerseq BETWEEN $1 AND $2 ORDER BY ledgerseq ASC",
 		low,
 		high)
 
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	return ledgers
+	return ledgers, nil
 }
 
 // LedgerHeaderLastRow returns lastest ledger in the database
-func (db *Client) LedgerHeaderLastRow() *LedgerHeaderRow {
-	var h LedgerHeaderRow
-
-	err := db.rawClient.Get(&h, "SELECT * FROM ledgerheaders ORDER BY ledgerseq DESC LIMIT 1")
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil
-		}
-
-		log.Fatal(err)
-	}
-
-	return &h
+func (db *Client) LedgerHeaderLastRow() (*LedgerHeaderRow, error) {
+	return db.ledgerHeader("ORDER BY ledgerseq DESC LIMIT 1")
 }
 
 // LedgerHeaderFirstRow returns lastest first ledger in the database
-func (db *Client) LedgerHeaderFirstRow() *LedgerHeaderRow {
-	var h LedgerHeaderRow
-
-	err := db.rawClient.Get(&h, "SELECT * FROM ledgerheaders ORDER BY ledgerseq ASC LIMIT 1")
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil
-		}
+func (db *Client) LedgerHeaderFirstRow() (*LedgerHeaderRow, error) {
+	return db.ledgerHeader("ORDER BY ledgerseq ASC LIMIT 1")
+}
 
-		log.Fatal(err)
-	}
+// LedgerHeaderNext returns next ledger to fetch
+func (db *Client) LedgerHeaderNext(seq int) (*LedgerHeaderRow, error) {
+	return db.ledgerHeader("WHERE ledgerseq > $1 ORDER BY ledgerseq ASC LIMIT 1", seq)
+}
 
-	return &h
+// LedgerHeaderClosedAfter returns the first ledger closed at or after t, nil if there is none
+func (db *Client) LedgerHeaderClosedAfter(t time.Time) (*LedgerHeaderRow, error) {
+	return db.ledgerHeader("WHERE closetime >= $1 ORDER BY ledgerseq ASC LIMIT 1", t.Unix())
 }
 
-// LedgerHeaderNext returns next ledger to fetch
-func (db *Client) LedgerHeaderNext(seq int) *LedgerHeaderRow {
+// ledgerHeader returns the ledger selected by the query tail, nil if there is none
+func (db *Client) ledgerHeader(tail string, args ...interface{}) (*LedgerHeaderRow, error) {
 	var h LedgerHeaderRow
 
-	err := db.rawClient.Get(&h, "SELECT * FROM ledgerheaders WHERE ledgerseq > $1 ORDER BY ledgerseq ASC LIMIT 1", seq)
+	err := db.get(&h, "SELECT "+selectColumns("ledgerheaders")+" FROM ledgerheaders "+tail, args...)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil
+			return nil, nil
 		}
 
-		log.Fatal(err)
+		return nil, err
 	}
 
-	return &h
+	return &h, nil
 }
 
 // LedgerHeaderGaps returns gap positions in ledgerheaders
-func (db *Client) LedgerHeaderGaps() (r []Gap) {
-	err := db.rawClient.Select(&r, `
+func (db *Client) LedgerHeaderGaps() (r []Gap, err error) {
+	err = db.selectRows(&r, `
 		SELECT ledgerseq + 1 AS gap_start, next_nr - 1 AS gap_end
 		FROM (
   		SELECT ledgerseq, LEAD(ledgerseq) OVER (ORDER BY ledgerseq) AS next_nr
@@ -118,9 +125,5 @@ func (db *Client) LedgerHeaderGaps() (r []Gap) {
 		WHERE ledgerseq + 1 <> next_nr
 	`)
 
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	return r
+	return r, err
 }
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// LedgerChannel is notified on ledgerheaders inserts by the trigger described in README
+const LedgerChannel = "astrologer_ledgers"
+
+// Listener receives notifications of new ledgers, LISTEN is not available on streaming replicas
+type Listener struct {
+	raw *pq.Listener
+}
+
+// Listen subscribes to LedgerChannel, lost connection is restored in background
+func Listen(databaseURL *url.URL) (*Listener, error) {
+	raw := pq.NewListener(databaseURL.String(), time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println("Database listener:", err)
+		}
+	})
+
+	if err := raw.Listen(LedgerChannel); err != nil {
+		raw.Close()
+		return nil, err
+	}
+
+	return &Listener{raw: raw}, nil
+}
+
+// Wait blocks until a new ledger is notified, timeout expires or ctx is cancelled.
+// Reconnection is reported as a notification, ledgers closed while disconnected are not lost.
+func (l *Listener) Wait(ctx context.Context, timeout time.Duration) error {
+	select {
+	case <-l.raw.Notify:
+		return nil
+	case <-time.After(timeout):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops listening
+func (l *Listener) Close() error {
+	return l.raw.Close()
+}
@@ -1,66 +1,184 @@
 package db
 
 import (
-	"bytes"
+	"database/sql/driver"
+	"errors"
+	"io"
 	"log"
+	"net"
 	"net/url"
-	"unicode/utf8"
+	"reflect"
+	"sync/atomic"
+	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // Postgres driver
+	"github.com/lib/pq" // Postgres driver
 )
 
-// Copy paste from Horizon
-func utf8Scrub(in string) string {
+// Adapter defines the interface to work with ledger database
+type Adapter interface {
+	LedgerHeaderRowCount(first int, last int) (int, error)
+	LedgerHeaderRowFetchBatch(n int, start int, batchSize int) ([]LedgerHeaderRow, error)
+	LedgerHeaderRowFetchRange(low int, high int) ([]LedgerHeaderRow, error)
+	LedgerHeaderLastRow() (*LedgerHeaderRow, error)
+	LedgerHeaderFirstRow() (*LedgerHeaderRow, error)
+	LedgerHeaderNext(seq int) (*LedgerHeaderRow, error)
+	LedgerHeaderClosedAfter(t time.Time) (*LedgerHeaderRow, error)
+	LedgerHeaderGaps() ([]Gap, error)
+	TxHistoryRowForSeq(seq int) ([]TxHistoryRow, error)
+	TxCountsInRange(first int, last int) (map[int]int, error)
+	TxStatsInRange(first int, last int) (TxStats, error)
+	TxFeeHistoryRowsForRows(rows []TxHistoryRow) ([]TxFeeHistoryRow, error)
+	AccountRowForID(id string) (*AccountRow, error)
+	TrustLineRowsForAccount(id string) ([]TrustLineRow, error)
+	MemoRequiredAccounts() ([]string, error)
+	ReplicationLag() (lag time.Duration, ok bool, err error)
+	Session() Adapter
+}
 
-	// First check validity using the stdlib, returning if the string is already
-	// valid
-	if utf8.ValidString(in) {
-		return in
-	}
+// RetryForever makes the client retry queries on connection errors until the connection is restored
+const RetryForever = -1
 
-	left := []byte(in)
-	var result bytes.Buffer
+const (
+	defaultRetries  = 10
+	maxRetryDelay   = time.Minute
+	connMaxLifetime = 30 * time.Minute
+)
 
-	for len(left) > 0 {
-		r, n := utf8.DecodeRune(left)
+// Client is an adapter implementation for stellar-core database
+type Client struct {
+	pool    []*sqlx.DB
+	hosts   []string
+	next    *uint32
+	start   int
+	retries int
+	limiter *rateLimiter
+	schema  *Schema
+}
+
+// Connect returns the Client configured for the specified databases, replicas of the same core database.
+// Queries go to the first database, sessions are spread across all of them, failed database is replaced by the next one.
+func Connect(databaseURLs ...*url.URL) (*Client, error) {
+	client := &Client{next: new(uint32), retries: defaultRetries, limiter: &rateLimiter{}}
+	available := 0
 
-		_, err := result.WriteRune(r)
+	for _, u := range databaseURLs {
+		db, err := sqlx.Open(u.Scheme, u.String())
 		if err != nil {
-			panic(err)
+			return nil, err
+		}
+
+		if err := db.Ping(); err != nil {
+			log.Printf("Database %s is not available: %v", u.Host, err)
+		} else {
+			available++
 		}
 
-		left = left[n:]
+		db.SetConnMaxLifetime(connMaxLifetime)
+
+		client.pool = append(client.pool, db)
+		client.hosts = append(client.hosts, u.Host)
 	}
 
-	return result.String()
+	if available == 0 {
+		return nil, errors.New("no database is available")
+	}
+
+	schema, err := client.detectSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := schema.Validate(); err != nil {
+		return nil, err
+	}
+
+	client.schema = schema
+
+	log.Println("stellar-core database schema version", client.schema.Version)
+
+	return client, nil
 }
 
-// Adapter defines the interface to work with ledger database
-type Adapter interface {
-	LedgerHeaderRowCount(first int, last int) int
-	LedgerHeaderRowFetchBatch(n int, start int, batchSize int) []LedgerHeaderRow
-	LedgerHeaderLastRow() *LedgerHeaderRow
-	LedgerHeaderFirstRow() *LedgerHeaderRow
-	LedgerHeaderNext(seq int) *LedgerHeaderRow
-	LedgerHeaderGaps() (r []Gap)
-	TxHistoryRowForSeq(seq int) []TxHistoryRow
-	TxFeeHistoryRowsForRows(rows []TxHistoryRow) []TxFeeHistoryRow
+// Session returns the Client sending queries to the next database in turn, so batches read from different replicas
+func (db *Client) Session() Adapter {
+	session := *db
+	session.start = int(atomic.AddUint32(db.next, 1)) % len(db.pool)
+
+	return &session
 }
 
-// Client is an adapter implementation for stellar-core database
-type Client struct {
-	rawClient *sqlx.DB
+// SetRetries sets the number of query retries on connection errors, RetryForever disables the limit
+func (db *Client) SetRetries(retries int) {
+	db.retries = retries
 }
 
-// Connect returns the Client configured for the specified database
-func Connect(databaseURL *url.URL) *Client {
-	databaseDriver := (*databaseURL).Scheme
+// withRetries runs the query, falling back to the next database and retrying with backoff if the connection was lost.
+// Broken connections are dropped by database/sql, so the next attempt uses a fresh one.
+func (db *Client) withRetries(query func(raw *sqlx.DB) error) error {
+	var err error
 
-	db, err := sqlx.Connect(databaseDriver, (*databaseURL).String())
-	if err != nil {
-		log.Fatal(err)
+	delay := time.Second
+
+	for attempt := 0; db.retries == RetryForever || attempt <= db.retries; attempt++ {
+		for i := range db.pool {
+			n := (db.start + i) % len(db.pool)
+
+			db.limiter.wait()
+
+			err = query(db.pool[n])
+
+			if err == nil || !isConnectionError(err) {
+				return err
+			}
+
+			log.Printf("Database %s connection error: %v", db.hosts[n], err)
+		}
+
+		log.Printf("Retrying in %s", delay)
+		time.Sleep(delay)
+
+		if delay < maxRetryDelay {
+			delay *= 2
+		}
+	}
+
+	return err
+}
+
+func (db *Client) get(dest interface{}, query string, args ...interface{}) error {
+	return db.withRetries(func(raw *sqlx.DB) error {
+		return raw.Get(dest, query, args...)
+	})
+}
+
+func (db *Client) selectRows(dest interface{}, query string, args ...interface{}) error {
+	return db.withRetries(func(raw *sqlx.DB) error {
+		// Rows already scanned by the failed attempt must not be duplicated
+		slice := reflect.ValueOf(dest).Elem()
+		slice.SetLen(0)
+
+		return raw.Select(dest, query, args...)
+	})
+}
+
+// isConnectionError returns true if the error is caused by lost database connection
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	var pqErr *pq.Error
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	if errors.As(err, &pqErr) {
+		// Class 08 - connection exception, 57P01-57P03 - server shutdown or restart
+		return pqErr.Code.Class() == "08" || pqErr.Code == "57P01" || pqErr.Code == "57P02" || pqErr.Code == "57P03"
 	}
 
-	return &Client{rawClient: db}
+	return false
 }
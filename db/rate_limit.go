@@ -0,0 +1,49 @@
+package db
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces queries evenly to keep their rate under the limit, shared by sessions of the Client
+type rateLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait blocks until the next query is allowed
+func (l *rateLimiter) wait() {
+	l.mutex.Lock()
+
+	if l.interval == 0 {
+		l.mutex.Unlock()
+		return
+	}
+
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+
+	l.mutex.Unlock()
+
+	time.Sleep(delay)
+}
+
+// SetMaxQPS limits the rate of queries sent to all databases of the Client, zero disables the limit.
+// The limit can be changed while queries are running, e.g. by the live config.
+func (db *Client) SetMaxQPS(qps int) {
+	var interval time.Duration
+
+	if qps > 0 {
+		interval = time.Second / time.Duration(qps)
+	}
+
+	db.limiter.mutex.Lock()
+	db.limiter.interval = interval
+	db.limiter.mutex.Unlock()
+}
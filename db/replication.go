@@ -0,0 +1,45 @@
+package db
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// ReplicationLag returns how far the most lagging streaming replica is behind the primary, ok is false if no database is a replica.
+// Replica which replayed everything it received is not lagging even if the last transaction is old.
+// Unavailable databases are skipped, queries fail over to other ones.
+func (db *Client) ReplicationLag() (lag time.Duration, ok bool, err error) {
+	for n, raw := range db.pool {
+		var seconds sql.NullFloat64
+
+		err = raw.Get(&seconds, `
+			SELECT CASE
+				WHEN NOT pg_is_in_recovery() THEN NULL
+				WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+				ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+			END
+		`)
+
+		if err != nil && isConnectionError(err) {
+			log.Printf("Database %s connection error: %v", db.hosts[n], err)
+			continue
+		}
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		if !seconds.Valid {
+			continue
+		}
+
+		ok = true
+
+		if d := time.Duration(seconds.Float64 * float64(time.Second)); d > lag {
+			lag = d
+		}
+	}
+
+	return lag, ok, nil
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MinSchemaVersion is the oldest stellar-core database schema supported (stellar-core 12)
+const MinSchemaVersion = 10
+
+// historyColumns lists columns of history tables read by the exporter, queries select them explicitly
+// so columns added by newer core releases do not break scanning into rows
+var historyColumns = map[string][]string{
+	"ledgerheaders": {"ledgerhash", "prevhash", "bucketlisthash", "ledgerseq", "closetime", "data"},
+	"txhistory":     {"txid", "ledgerseq", "txindex", "txbody", "txresult", "txmeta"},
+	"txfeehistory":  {"txid", "ledgerseq", "txindex", "txchanges"},
+}
+
+// Schema represents the layout of the connected stellar-core database
+type Schema struct {
+	Version int
+	columns map[string]map[string]bool
+}
+
+type schemaColumn struct {
+	Table  string `db:"table_name"`
+	Column string `db:"column_name"`
+}
+
+// detectSchema reads the schema version from storestate and collects table columns,
+// queries use columns to choose the layout of the particular core release
+func (db *Client) detectSchema() (*Schema, error) {
+	var state string
+	var columns []schemaColumn
+
+	s := &Schema{columns: make(map[string]map[string]bool)}
+
+	err := db.get(&state, "SELECT state FROM storestate WHERE statename = 'databaseschema'")
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to read database schema version: %v", err)
+	}
+
+	s.Version, _ = strconv.Atoi(state)
+
+	err = db.selectRows(&columns, `
+		SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = current_schema()
+	`)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database columns: %v", err)
+	}
+
+	for _, c := range columns {
+		if s.columns[c.Table] == nil {
+			s.columns[c.Table] = make(map[string]bool)
+		}
+
+		s.columns[c.Table][c.Column] = true
+	}
+
+	return s, nil
+}
+
+// Validate checks that the database has everything the exporter requires
+func (s *Schema) Validate() error {
+	if s.Version < MinSchemaVersion {
+		return fmt.Errorf("stellar-core database schema %d is not supported, minimum is %d", s.Version, MinSchemaVersion)
+	}
+
+	for _, table := range []string{"ledgerheaders", "txhistory", "txfeehistory"} {
+		if !s.HasTable(table) {
+			return fmt.Errorf("stellar-core database has no %s table, is history storage enabled?", table)
+		}
+
+		for _, column := range historyColumns[table] {
+			if !s.HasColumn(table, column) {
+				return fmt.Errorf(
+					"stellar-core database schema %d is not supported: %s table has no %s column",
+					s.Version, table, column,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// selectColumns returns comma separated columns of the history table
+func selectColumns(table string) string {
+	return strings.Join(historyColumns[table], ", ")
+}
+
+// HasTable returns true if the table exists
+func (s *Schema) HasTable(table string) bool {
+	return s.columns[table] != nil
+}
+
+// HasColumn returns true if the table has the column
+func (s *Schema) HasColumn(table string, column string) bool {
+	return s.columns[table][column]
+}
+
+// optionalColumn returns column name or NULL placeholder if the column is missing in this core release
+func (s *Schema) optionalColumn(table string, column string) string {
+	if s.HasColumn(table, column) {
+		return column
+	}
+
+	return "NULL AS " + column
+}
@@ -1,8 +1,6 @@
 package db
 
 import (
-	"log"
-
 	"github.com/jmoiron/sqlx"
 	"github.com/stellar/go/xdr"
 )
@@ -16,11 +14,11 @@ type TxFeeHistoryRow struct {
 }
 
 // TxFeeHistoryRowsForRows returns transactions for specified ledger sorted by index
-func (db *Client) TxFeeHistoryRowsForRows(rows []TxHistoryRow) []TxFeeHistoryRow {
+func (db *Client) TxFeeHistoryRowsForRows(rows []TxHistoryRow) ([]TxFeeHistoryRow, error) {
 	txs := []TxFeeHistoryRow{}
 
 	if len(rows) == 0 {
-		return txs
+		return txs, nil
 	}
 
 	ids := make([]int, len(rows))
@@ -29,16 +27,16 @@ func (db *Client) TxFeeHistoryRowsForRows(rows []TxHistoryRow) []TxFeeHistoryRow
 		ids[n] = rows[n].LedgerSeq
 	}
 
-	query, args, err := sqlx.In("SELECT * FROM txfeehistory WHERE ledgerseq IN (?) ORDER BY ledgerseq, txindex", ids)
+	query, args, err := sqlx.In("SELECT "+selectColumns("txfeehistory")+" FROM txfeehistory WHERE ledgerseq IN (?) ORDER BY ledgerseq, txindex", ids)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	query = db.rawClient.Rebind(query)
-	err = db.rawClient.Select(&txs, query, args...)
+	query = db.pool[0].Rebind(query)
+	err = db.selectRows(&txs, query, args...)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	return txs
+	return txs, nil
 }
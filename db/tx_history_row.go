@@ -1,12 +1,8 @@
 package db
 
 import (
-	"encoding/base64"
 	"fmt"
-	"log"
-	"strings"
 
-	"github.com/guregu/null"
 	"github.com/stellar/go/xdr"
 )
 
@@ -21,49 +17,42 @@ type TxHistoryRow struct {
 }
 
 // TxHistoryRowForSeq returns transactions for specified ledger sorted by index
-func (db *Client) TxHistoryRowForSeq(seq int) []TxHistoryRow {
+func (db *Client) TxHistoryRowForSeq(seq int) ([]TxHistoryRow, error) {
 	txs := []TxHistoryRow{}
 
-	err := db.rawClient.Select(&txs, "SELECT * FROM txhistory WHERE ledgerseq = $1 ORDER BY txindex", seq)
+	err := db.selectRows(&txs, "SELECT "+selectColumns("txhistory")+" FROM txhistory WHERE ledgerseq = $1 ORDER BY txindex", seq)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	return txs
+	return txs, nil
 }
 
-// MemoValue Returns clean memo value, this is copy paste from horizon internal package
-func (tx *TxHistoryRow) MemoValue() null.String {
-	var (
-		value string
-		valid bool
-		memo  = tx.Envelope.Memo()
+// TxCountsInRange returns transaction count per ledger within the given inclusive range, ledgers without transactions are omitted
+func (db *Client) TxCountsInRange(first, last int) (map[int]int, error) {
+	rows := []struct {
+		LedgerSeq int `db:"ledgerseq"`
+		Count     int `db:"count"`
+	}{}
+
+	err := db.selectRows(
+		&rows,
+		"SELECT ledgerseq, count(*) AS count FROM txhistory WHERE ledgerseq BETWEEN $1 AND $2 GROUP BY ledgerseq",
+		first,
+		last,
 	)
 
-	switch memo.Type {
-	case xdr.MemoTypeMemoNone:
-		value, valid = "", false
-	case xdr.MemoTypeMemoText:
-		scrubbed := utf8Scrub(memo.MustText())
-		notnull := strings.Join(strings.Split(scrubbed, "\x00"), "")
-		value, valid = notnull, true
-	case xdr.MemoTypeMemoId:
-		value, valid = fmt.Sprintf("%d", memo.MustId()), true
-	case xdr.MemoTypeMemoHash:
-		hash := memo.MustHash()
-		value, valid =
-			base64.StdEncoding.EncodeToString(hash[:]),
-			true
-	case xdr.MemoTypeMemoReturn:
-		hash := memo.MustRetHash()
-		value, valid =
-			base64.StdEncoding.EncodeToString(hash[:]),
-			true
-	default:
-		panic(fmt.Errorf("invalid memo type: %v", memo.Type))
+	if err != nil {
+		return nil, err
 	}
 
-	return null.NewString(value, valid)
+	counts := make(map[int]int, len(rows))
+
+	for _, row := range rows {
+		counts[row.LedgerSeq] = row.Count
+	}
+
+	return counts, nil
 }
 
 // Operations returns operations array
@@ -84,24 +73,13 @@ func (tx *TxHistoryRow) Operations() (error, []xdr.Operation) {
 func (tx *TxHistoryRow) ResultFor(index int) (result *xdr.OperationResult) {
 	results := tx.Result.Result.Result.Results
 
+	if pair, ok := tx.Result.Result.Result.GetInnerResultPair(); ok {
+		results = pair.Result.Result.Results
+	}
+
 	if results != nil {
 		result = &(*results)[index]
 	}
 
 	return result
 }
-
-// MetasFor returns meta for operation index
-func (tx *TxHistoryRow) MetasFor(index int) (result *xdr.OperationMeta) {
-	if v1, ok := tx.Meta.GetV1(); ok {
-		ops := v1.Operations
-		return &ops[index]
-	}
-
-	ops, ok := tx.Meta.GetOperations()
-	if !ok {
-		return nil
-	}
-
-	return &ops[index]
-}
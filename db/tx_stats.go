@@ -0,0 +1,79 @@
+package db
+
+import (
+	"github.com/stellar/go/xdr"
+)
+
+// txStatsBatchSize ledgers of transactions decoded per query
+const txStatsBatchSize = 1000
+
+// TxStats represents transaction and operation counts of the ledger range
+type TxStats struct {
+	Transactions int
+	Failed       int
+	Operations   int
+
+	// Bytes is the size of transaction envelopes, results and metas stored in the database
+	Bytes int64
+}
+
+// Add accounts the transaction in stats
+func (s *TxStats) Add(tx *TxHistoryRow) {
+	s.Transactions++
+	s.Operations += len(tx.Envelope.Operations())
+
+	if !tx.Successful() {
+		s.Failed++
+	}
+}
+
+// FailedRatio returns the share of failed transactions
+func (s *TxStats) FailedRatio() float64 {
+	if s.Transactions == 0 {
+		return 0
+	}
+
+	return float64(s.Failed) / float64(s.Transactions)
+}
+
+// Successful returns true if the transaction (or the inner transaction of the fee bump) succeeded
+func (tx *TxHistoryRow) Successful() bool {
+	code := tx.Result.Result.Result.Code
+
+	return code == xdr.TransactionResultCodeTxSuccess || code == xdr.TransactionResultCodeTxFeeBumpInnerSuccess
+}
+
+// TxStatsInRange counts transactions and operations within the given inclusive range, envelopes are decoded batch by batch
+func (db *Client) TxStatsInRange(first, last int) (s TxStats, err error) {
+	for low := first; low <= last; low += txStatsBatchSize {
+		high := low + txStatsBatchSize - 1
+		if high > last {
+			high = last
+		}
+
+		rows := []struct {
+			Envelope xdr.TransactionEnvelope   `db:"txbody"`
+			Result   xdr.TransactionResultPair `db:"txresult"`
+			Size     int64                     `db:"size"`
+		}{}
+
+		err = db.selectRows(
+			&rows,
+			`SELECT txbody, txresult, octet_length(txbody) + octet_length(txresult) + octet_length(txmeta) AS size
+			FROM txhistory WHERE ledgerseq BETWEEN $1 AND $2`,
+			low,
+			high,
+		)
+
+		if err != nil {
+			return s, err
+		}
+
+		for _, row := range rows {
+			s.Add(&TxHistoryRow{Envelope: row.Envelope, Result: row.Result})
+			s.Bytes += row.Size
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,112 @@
+package es
+
+import (
+	"time"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/xdr"
+)
+
+// Account represents the latest known state of the account
+type Account struct {
+	ID                 string             `json:"id"`
+	PagingToken        PagingToken        `json:"paging_token"`
+	Balance            string             `json:"balance"`
+	BuyingLiabilities  string             `json:"buying_liabilities"`
+	SellingLiabilities string             `json:"selling_liabilities"`
+	SeqNum             int64              `json:"seq_num"`
+	NumSubEntries      int                `json:"num_sub_entries"`
+	InflationDest      string             `json:"inflation_dest_id,omitempty"`
+	HomeDomain         string             `json:"home_domain,omitempty"`
+	Thresholds         *AccountThresholds `json:"thresholds,omitempty"`
+	Flags              *AccountFlags      `json:"flags,omitempty"`
+	Signers            []*Signer          `json:"signers,omitempty"`
+	LastModifiedLedger int                `json:"last_modified_ledger"`
+	LedgerCloseTime    time.Time          `json:"ledger_close_time"`
+	Deleted            bool               `json:"deleted"`
+}
+
+// ProduceAccounts returns account states changed within the given changes
+func ProduceAccounts(changes xdr.LedgerEntryChanges, pagingToken PagingToken, closeTime time.Time) (accounts []*Account) {
+	for _, change := range changes {
+		switch change.Type {
+		case xdr.LedgerEntryChangeTypeLedgerEntryCreated:
+			entry := change.MustCreated()
+			if a, ok := entry.Data.GetAccount(); ok {
+				accounts = append(accounts, NewAccount(a, int(entry.LastModifiedLedgerSeq), pagingToken, closeTime))
+			}
+		case xdr.LedgerEntryChangeTypeLedgerEntryUpdated:
+			entry := change.MustUpdated()
+			if a, ok := entry.Data.GetAccount(); ok {
+				accounts = append(accounts, NewAccount(a, int(entry.LastModifiedLedgerSeq), pagingToken, closeTime))
+			}
+		case xdr.LedgerEntryChangeTypeLedgerEntryRemoved:
+			key := change.MustRemoved()
+			if k, ok := key.GetAccount(); ok {
+				account := NewAccount(xdr.AccountEntry{AccountId: k.AccountId}, pagingToken.LedgerSeq, pagingToken, closeTime)
+				account.Thresholds = nil
+				account.Flags = nil
+				account.Deleted = true
+
+				accounts = append(accounts, account)
+			}
+		}
+	}
+
+	return accounts
+}
+
+// NewAccount creates Account from AccountEntry
+func NewAccount(a xdr.AccountEntry, lastModified int, pagingToken PagingToken, closeTime time.Time) *Account {
+	var buying, selling xdr.Int64
+
+	if v1, ok := a.Ext.GetV1(); ok {
+		buying, selling = v1.Liabilities.Buying, v1.Liabilities.Selling
+	}
+
+	master := xdr.Uint32(a.Thresholds[0])
+	low := xdr.Uint32(a.Thresholds[1])
+	medium := xdr.Uint32(a.Thresholds[2])
+	high := xdr.Uint32(a.Thresholds[3])
+	flags := xdr.Uint32(a.Flags)
+
+	account := &Account{
+		ID:                 a.AccountId.Address(),
+		PagingToken:        pagingToken,
+		Balance:            amount.String(a.Balance),
+		BuyingLiabilities:  amount.String(buying),
+		SellingLiabilities: amount.String(selling),
+		SeqNum:             int64(a.SeqNum),
+		NumSubEntries:      int(a.NumSubEntries),
+		HomeDomain:         string(a.HomeDomain),
+		Thresholds:         NewAccountThresholds(&low, &medium, &high, &master),
+		Flags:              NewAccountFlags(&flags),
+		LastModifiedLedger: lastModified,
+		LedgerCloseTime:    closeTime,
+	}
+
+	if a.InflationDest != nil {
+		account.InflationDest = a.InflationDest.Address()
+	}
+
+	for i := range a.Signers {
+		account.Signers = append(account.Signers, NewSigner(&a.Signers[i]))
+	}
+
+	return account
+}
+
+// DocID returns account id
+func (a *Account) DocID() *string {
+	return &a.ID
+}
+
+// IndexName returns accounts index name
+func (a *Account) IndexName() IndexName {
+	return accountsIndexName
+}
+
+// Version returns document version, newer changes overwrite older ones
+func (a *Account) Version() (int64, error) {
+	return a.PagingToken.Version()
+}
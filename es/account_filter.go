@@ -0,0 +1,166 @@
+package es
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// accountFilterFPRate false positive rate of account filters
+const accountFilterFPRate = 0.01
+
+var accountIDPattern = regexp.MustCompile(`"(G[A-Z2-7]{55})"`)
+
+// AccountFilter represents Bloom filter of accounts involved in the ledger range
+type AccountFilter struct {
+	ID       string       `json:"id"`
+	First    int          `json:"first"`
+	Last     int          `json:"last"`
+	Accounts int          `json:"accounts"`
+	Filter   *BloomFilter `json:"filter"`
+
+	version int64
+}
+
+// seqRange represents ledger range which may contain documents of the account
+type seqRange struct {
+	first, last int
+}
+
+// NewAccountFilter builds the filter of account IDs found in documents of the bulk payload
+func NewAccountFilter(first, last int, payload []byte) *AccountFilter {
+	accounts := make(map[string]bool)
+
+	for _, match := range accountIDPattern.FindAllSubmatch(payload, -1) {
+		accounts[string(match[1])] = true
+	}
+
+	filter := NewBloomFilter(len(accounts), accountFilterFPRate)
+	for id := range accounts {
+		filter.Add(id)
+	}
+
+	return &AccountFilter{
+		ID:       fmt.Sprintf("%d-%d", first, last),
+		First:    first,
+		Last:     last,
+		Accounts: len(accounts),
+		Filter:   filter,
+		version:  time.Now().UnixNano(),
+	}
+}
+
+// accountRanges returns ledger ranges which may contain documents of the account: ranges where the account filter
+// matches and ranges without filters. Returns false if there are no filters at all.
+func (es *Client) accountRanges(accountID string) (ranges []seqRange, ok bool, err error) {
+	filters, err := es.accountFilters()
+	if err != nil || len(filters) == 0 {
+		return nil, false, err
+	}
+
+	min, max, err := es.MinMaxRange()
+	if err != nil {
+		return nil, false, err
+	}
+
+	next := min
+
+	for _, f := range filters {
+		if f.First > next {
+			ranges = append(ranges, seqRange{next, f.First - 1})
+		}
+
+		if f.Filter.Test(accountID) {
+			ranges = append(ranges, seqRange{f.First, f.Last})
+		}
+
+		if f.Last+1 > next {
+			next = f.Last + 1
+		}
+	}
+
+	if next <= max {
+		ranges = append(ranges, seqRange{next, max})
+	}
+
+	return ranges, true, nil
+}
+
+// accountFilters returns all account filters ordered by the first ledger
+func (es *Client) accountFilters() (filters []AccountFilter, err error) {
+	var after []interface{}
+
+	for {
+		var r struct {
+			Hits struct {
+				Hits []struct {
+					Source AccountFilter `json:"_source"`
+					Sort   []interface{} `json:"sort"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+
+		body := map[string]interface{}{
+			"size": searchPageSize,
+			"sort": []map[string]interface{}{{"first": "asc"}},
+		}
+
+		if after != nil {
+			body["search_after"] = after
+		}
+
+		if err := es.search(accountFiltersIndexName, body, &r); err != nil {
+			return nil, err
+		}
+
+		for _, hit := range r.Hits.Hits {
+			filters = append(filters, hit.Source)
+		}
+
+		if len(r.Hits.Hits) < searchPageSize {
+			break
+		}
+
+		after = r.Hits.Hits[len(r.Hits.Hits)-1].Sort
+	}
+
+	sort.SliceStable(filters, func(i, j int) bool { return filters[i].First < filters[j].First })
+
+	return filters, nil
+}
+
+// pagingTokenRanges returns query matching documents of the ledger ranges
+func pagingTokenRanges(ranges []seqRange) map[string]interface{} {
+	should := make([]map[string]interface{}, len(ranges))
+
+	for i, r := range ranges {
+		should[i] = map[string]interface{}{
+			"range": map[string]interface{}{
+				"paging_token": map[string]interface{}{
+					"gte": PagingToken{LedgerSeq: r.first}.String(),
+					"lt":  PagingToken{LedgerSeq: r.last + 1}.String(),
+				},
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"bool": map[string]interface{}{"should": should, "minimum_should_match": 1},
+	}
+}
+
+// DocID returns es id
+func (f *AccountFilter) DocID() *string {
+	return &f.ID
+}
+
+// IndexName returns index name
+func (f *AccountFilter) IndexName() IndexName {
+	return accountFiltersIndexName
+}
+
+// Version returns document version, filters of the reexported range replace earlier ones
+func (f *AccountFilter) Version() (int64, error) {
+	return f.version, nil
+}
@@ -0,0 +1,108 @@
+package es
+
+import (
+	"encoding/json"
+)
+
+const searchPageSize = 1000
+
+type searchResponse struct {
+	Hits struct {
+		Hits []struct {
+			Source json.RawMessage `json:"_source"`
+			Sort   []interface{}   `json:"sort"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// Account related fields for every index
+var accountFields = map[IndexName][]string{
+	txIndexName:            {"source_account_id", "fee_account_id"},
+	opIndexName:            {"source_account_id", "destination_account_id"},
+	balanceIndexName:       {"account_id"},
+	tradesIndexName:        {"seller_id", "buyer_id"},
+	signerHistoryIndexName: {"account_id", "signer"},
+}
+
+// AccountHistory returns all the documents related to the account grouped by index.
+// Ledger ranges which account filters prove to be unrelated to the account are skipped.
+func (es *Client) AccountHistory(accountID string) (map[IndexName][]json.RawMessage, error) {
+	result := make(map[IndexName][]json.RawMessage)
+
+	ranges, filtered, err := es.accountRanges(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, fields := range accountFields {
+		if filtered && len(ranges) == 0 {
+			result[index] = nil
+			continue
+		}
+
+		should := make([]map[string]interface{}, len(fields))
+
+		for i, field := range fields {
+			should[i] = map[string]interface{}{
+				"term": map[string]interface{}{field: accountID},
+			}
+		}
+
+		query := map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should":               should,
+				"minimum_should_match": 1,
+			},
+		}
+
+		if filtered {
+			query["bool"].(map[string]interface{})["filter"] = pagingTokenRanges(ranges)
+		}
+
+		docs, err := es.searchAll(index, query)
+		if err != nil {
+			return nil, err
+		}
+
+		result[index] = docs
+	}
+
+	return result, nil
+}
+
+// searchAll fetches all documents matching the query ordered by paging token
+func (es *Client) searchAll(index IndexName, query map[string]interface{}) (docs []json.RawMessage, err error) {
+	var after []interface{}
+
+	for {
+		var r searchResponse
+
+		body := map[string]interface{}{
+			"query": query,
+			"size":  searchPageSize,
+			"sort": []map[string]interface{}{{
+				"paging_token": "asc",
+			}},
+		}
+
+		if after != nil {
+			body["search_after"] = after
+		}
+
+		if err := es.search(index, body, &r); err != nil {
+			return nil, err
+		}
+
+		hits := r.Hits.Hits
+
+		for _, hit := range hits {
+			docs = append(docs, hit.Source)
+		}
+
+		if len(hits) < searchPageSize {
+			return docs, nil
+		}
+
+		after = hits[len(hits)-1].Sort
+	}
+}
@@ -0,0 +1,117 @@
+package es
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/astroband/astrologer/paging"
+)
+
+// payloadDoc represents the document of the bulk payload with its action line
+type payloadDoc struct {
+	index  IndexName
+	meta   []byte
+	source []byte
+	token  *paging.Token
+}
+
+// FilterAccounts returns documents of the bulk payload involving any of the accounts: documents mentioning them,
+// transactions with such documents and headers of ledgers with such transactions. Other documents are dropped.
+func FilterAccounts(payload []byte, accounts map[string]bool) ([]byte, error) {
+	var out bytes.Buffer
+
+	docs, err := payloadDocs(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := make([]bool, len(docs))
+	txs := make(map[paging.Token]bool)
+	ledgers := make(map[int]bool)
+
+	for n, doc := range docs {
+		if doc.index == ledgerHeaderIndexName || !mentionsAccount(doc.source, accounts) {
+			continue
+		}
+
+		keep[n] = true
+
+		if doc.token != nil {
+			txs[paging.ForTransaction(doc.token.LedgerSeq, doc.token.TransactionOrder)] = true
+			ledgers[doc.token.LedgerSeq] = true
+		}
+	}
+
+	for n, doc := range docs {
+		if doc.index == txIndexName && doc.token != nil && txs[*doc.token] {
+			keep[n] = true
+		}
+
+		if doc.index == ledgerHeaderIndexName && doc.token != nil && ledgers[doc.token.LedgerSeq] {
+			keep[n] = true
+		}
+
+		if keep[n] {
+			out.Write(doc.meta)
+			out.WriteByte('\n')
+			out.Write(doc.source)
+			out.WriteByte('\n')
+		}
+	}
+
+	return out.Bytes(), nil
+}
+
+// payloadDocs splits the bulk payload into documents, paging tokens are read from sources
+func payloadDocs(payload []byte) (docs []payloadDoc, err error) {
+	var doc payloadDoc
+
+	scanner := bufio.NewScanner(bytes.NewReader(payload))
+	scanner.Buffer(make([]byte, 64*1024), len(payload)+1)
+
+	for meta := true; scanner.Scan(); meta = !meta {
+		line := make([]byte, len(scanner.Bytes()))
+		copy(line, scanner.Bytes())
+
+		if meta {
+			var m bulkMeta
+
+			if err := json.Unmarshal(line, &m); err != nil {
+				return nil, fmt.Errorf("invalid bulk payload: %v", err)
+			}
+
+			doc = payloadDoc{index: m.Index.Index, meta: line}
+
+			continue
+		}
+
+		var source struct {
+			PagingToken string `json:"paging_token"`
+		}
+
+		doc.source = line
+
+		if err := json.Unmarshal(line, &source); err == nil && source.PagingToken != "" {
+			if token, err := paging.Parse(source.PagingToken); err == nil {
+				doc.token = &token
+			}
+		}
+
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+// mentionsAccount checks if the document source contains any of the account IDs
+func mentionsAccount(source []byte, accounts map[string]bool) bool {
+	for _, match := range accountIDPattern.FindAllSubmatch(source, -1) {
+		if accounts[string(match[1])] {
+			return true
+		}
+	}
+
+	return false
+}
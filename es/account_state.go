@@ -0,0 +1,136 @@
+package es
+
+import (
+	"encoding/json"
+)
+
+const maxStateBuckets = 10000
+
+// AccountState represents account state reconstructed from indexed history
+type AccountState struct {
+	AccountID  string            `json:"account_id"`
+	Seq        int               `json:"seq"`
+	Balances   []json.RawMessage `json:"balances"`
+	Signers    []json.RawMessage `json:"signers"`
+	TrustLines []json.RawMessage `json:"trustlines"`
+	Offers     []json.RawMessage `json:"offers"`
+}
+
+// latestDocsBucket is a level of nested terms aggregations, the innermost level holds the latest document
+type latestDocsBucket struct {
+	Keys *struct {
+		Buckets []latestDocsBucket `json:"buckets"`
+	} `json:"keys"`
+	Latest struct {
+		Hits struct {
+			Hits []struct {
+				Source json.RawMessage `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	} `json:"latest"`
+}
+
+type latestDocsResponse struct {
+	Aggregations latestDocsBucket `json:"aggregations"`
+}
+
+// AccountStateAt reconstructs account balances, signers, trustlines and offers as of the end of the given ledger.
+// Balances are keyed by asset code and issuer, so documents written before and after the asset key switch
+// fall into the same asset. The trustlines and offers indices keep the latest version of every entry only,
+// entries changed after the ledger are missing from the state.
+func (es *Client) AccountStateAt(accountID string, seq int) (*AccountState, error) {
+	balances, err := es.latestDocs(balanceIndexName, "account_id", accountID, seq, "asset.code", "asset.issuer")
+	if err != nil {
+		return nil, err
+	}
+
+	signers, err := es.latestDocs(signerHistoryIndexName, "account_id", accountID, seq, "signer")
+	if err != nil {
+		return nil, err
+	}
+
+	trustLines, err := es.latestDocs(trustLinesIndexName, "account_id", accountID, seq, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	offers, err := es.latestDocs(offersIndexName, "seller_id", accountID, seq, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccountState{
+		AccountID:  accountID,
+		Seq:        seq,
+		Balances:   balances,
+		Signers:    signers,
+		TrustLines: trustLines,
+		Offers:     offers,
+	}, nil
+}
+
+// latestDocs returns the latest document for every distinct combination of keyFields created before the end of
+// given ledger, documents missing a key field are grouped under the empty value
+func (es *Client) latestDocs(index IndexName, field string, value string, seq int, keyFields ...string) (docs []json.RawMessage, err error) {
+	var r latestDocsResponse
+
+	before := PagingToken{LedgerSeq: seq + 1}
+
+	aggs := map[string]interface{}{
+		"latest": map[string]interface{}{
+			"top_hits": map[string]interface{}{
+				"size": 1,
+				"sort": []map[string]interface{}{{
+					"paging_token": "desc",
+				}},
+			},
+		},
+	}
+
+	for i := len(keyFields) - 1; i >= 0; i-- {
+		aggs = map[string]interface{}{
+			"keys": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field":   keyFields[i],
+					"size":    maxStateBuckets,
+					"missing": "",
+				},
+				"aggs": aggs,
+			},
+		}
+	}
+
+	query := map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []map[string]interface{}{
+					{"term": map[string]interface{}{field: value}},
+					{"range": map[string]interface{}{"paging_token": map[string]interface{}{"lt": before.String()}}},
+				},
+			},
+		},
+		"aggs": aggs,
+	}
+
+	if err := es.search(index, query, &r); err != nil {
+		return nil, err
+	}
+
+	return r.Aggregations.docs(), nil
+}
+
+// docs collects the latest documents of the bucket and its nested buckets
+func (b *latestDocsBucket) docs() (docs []json.RawMessage) {
+	for _, hit := range b.Latest.Hits.Hits {
+		docs = append(docs, hit.Source)
+	}
+
+	if b.Keys != nil {
+		for i := range b.Keys.Buckets {
+			docs = append(docs, b.Keys.Buckets[i].docs()...)
+		}
+	}
+
+	return docs
+}
@@ -3,6 +3,8 @@ package es
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"log"
 	"math/rand"
 	"net/http"
@@ -12,60 +14,81 @@ import (
 	"github.com/elastic/go-elasticsearch/v7/esapi"
 )
 
-// IndexExists checks if an index with a given name exists in the ES cluster
-func (es *Client) IndexExists(name IndexName) bool {
-	res, err := es.rawClient.Indices.Get([]string{string(name)})
+// histogramPageSize is the number of composite aggregation buckets fetched per request
+const histogramPageSize = 1000
 
+// IndexExists checks if an index with a given name exists in the ES cluster
+func (es *Client) IndexExists(name IndexName) (bool, error) {
+	res, err := es.rawClient.Indices.Get([]string{name.String()})
 	if err != nil {
-		log.Fatal(err)
+		return false, err
 	}
 
-	return res.StatusCode != http.StatusNotFound
+	res.Body.Close()
+
+	return res.StatusCode != http.StatusNotFound, nil
 }
 
 // DeleteIndex deletes the index from the ES cluster
-func (es *Client) DeleteIndex(name IndexName) {
-	res, err := es.rawClient.Indices.Delete([]string{string(name)})
-	fatalIfError(res, err)
+func (es *Client) DeleteIndex(name IndexName) error {
+	res, err := es.rawClient.Indices.Delete([]string{name.String()})
+	if err := responseError(res, err); err != nil {
+		return fmt.Errorf("failed to delete %s: %v", name, err)
+	}
+
+	res.Body.Close()
+
+	return nil
 }
 
 // CreateIndex creates an index with the given name and definition in the ES cluster
-func (es *Client) CreateIndex(name IndexName, body IndexDefinition) {
+func (es *Client) CreateIndex(name IndexName, body IndexDefinition) error {
 	create := es.rawClient.Indices.Create
 
+	versioned, err := withSchemaVersion(name, body)
+	if err != nil {
+		return err
+	}
+
 	res, err := es.rawClient.Indices.Create(
-		string(name),
-		create.WithBody(strings.NewReader(string(body))),
+		name.String(),
+		create.WithBody(strings.NewReader(versioned)),
 		create.WithIncludeTypeName(false),
 	)
-	fatalIfError(res, err)
+	if err := responseError(res, err); err != nil {
+		return fmt.Errorf("failed to create %s: %v", name, err)
+	}
+
+	res.Body.Close()
+
+	return nil
+}
+
+func (es *Client) searchLedgers(query map[string]interface{}) (r map[string]interface{}, err error) {
+	err = es.search(ledgerHeaderIndexName, query, &r)
+	return r, err
 }
 
-func (es *Client) searchLedgers(query map[string]interface{}) (r map[string]interface{}) {
+func (es *Client) search(index IndexName, query map[string]interface{}, r interface{}) error {
 	var buf bytes.Buffer
 
 	if err := json.NewEncoder(&buf).Encode(query); err != nil {
-		log.Fatalf("Error encoding query: %s", err)
+		return fmt.Errorf("error encoding query: %v", err)
 	}
 
 	res, err := es.rawClient.Search(
-		es.rawClient.Search.WithIndex("ledger"),
+		es.rawClient.Search.WithIndex(index.String()),
 		es.rawClient.Search.WithBody(&buf),
 	)
-
-	fatalIfError(res, err)
-
-	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
-		log.Fatalf("Error parsing the response body: %s", err)
+	if err := responseError(res, err); err != nil {
+		return fmt.Errorf("failed to search %s: %v", index, err)
 	}
 
-	res.Body.Close()
-
-	return r
+	return decodeBody(res.Body, r)
 }
 
-// MinMaxSeq return the minimum and maximum seqnum of ledgers stored in the ES
-func (es *Client) MinMaxSeq() (min, max int) {
+// MinMaxRange return the minimum and maximum seqnum of ledgers stored in the ES
+func (es *Client) MinMaxRange() (min, max int, err error) {
 	query := map[string]interface{}{
 		"aggs": map[string]interface{}{
 			"seq_stats": map[string]interface{}{
@@ -76,18 +99,61 @@ func (es *Client) MinMaxSeq() (min, max int) {
 		},
 	}
 
-	r := es.searchLedgers(query)
+	r, err := es.searchLedgers(query)
+	if err != nil {
+		return 0, 0, err
+	}
 
 	aggs := r["aggregations"].(map[string]interface{})["seq_stats"].(map[string]interface{})
 
+	// Stats are null when the index is empty
+	if _, ok := aggs["min"].(float64); !ok {
+		return 0, 0, nil
+	}
+
 	min = int(aggs["min"].(float64))
 	max = int(aggs["max"].(float64))
 
-	return min, max
+	return min, max, nil
+}
+
+// LastIndexedLedger returns the seqnum of the latest ledger stored in the ES, false if there are none
+func (es *Client) LastIndexedLedger() (int, bool, error) {
+	_, max, err := es.MinMaxRange()
+
+	return max, max > 0, err
+}
+
+// LastLedgerCloseTime returns the close time of the latest ledger stored in the ES
+func (es *Client) LastLedgerCloseTime() (time.Time, error) {
+	query := map[string]interface{}{
+		"size": 0,
+		"aggs": map[string]interface{}{
+			"last_close_time": map[string]interface{}{
+				"max": map[string]interface{}{
+					"field": "close_time",
+				},
+			},
+		},
+	}
+
+	r, err := es.searchLedgers(query)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	aggs := r["aggregations"].(map[string]interface{})["last_close_time"].(map[string]interface{})
+	value, ok := aggs["value"].(float64)
+
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	return time.Unix(0, int64(value)*int64(time.Millisecond)), nil
 }
 
 // LedgerSeqRangeQuery fetches ledger ranges from ES
-func (es *Client) LedgerSeqRangeQuery(ranges []map[string]interface{}) map[string]interface{} {
+func (es *Client) LedgerSeqRangeQuery(ranges []map[string]interface{}) (map[string]interface{}, error) {
 	query := map[string]interface{}{
 		"aggs": map[string]interface{}{
 			"seq_ranges": map[string]interface{}{
@@ -99,25 +165,43 @@ func (es *Client) LedgerSeqRangeQuery(ranges []map[string]interface{}) map[strin
 		},
 	}
 
-	r := es.searchLedgers(query)
+	r, err := es.searchLedgers(query)
+	if err != nil {
+		return nil, err
+	}
+
 	aggs := r["aggregations"].(map[string]interface{})["seq_ranges"].(map[string]interface{})
 
-	return aggs
+	return aggs, nil
 }
 
-// BulkInsert sends the payload to ES using bulk operation
-func (es *Client) BulkInsert(payload *bytes.Buffer) (success bool) {
-	res, err := es.rawClient.Bulk(bytes.NewReader(payload.Bytes()))
+// BulkWrite sends the payload to ES using bulk operation, returns *BulkItemsError if some documents were not accepted
+func (es *Client) BulkWrite(payload *bytes.Buffer) error {
+	var r bulkResponse
 
-	if res != nil {
-		defer res.Body.Close()
+	res, err := es.rawClient.Bulk(
+		bytes.NewReader(payload.Bytes()),
+		es.rawClient.Bulk.WithFilterPath("errors", "items.*._index", "items.*._id", "items.*.status", "items.*.error"),
+	)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("bulk request failed: %s", res.Status())
 	}
 
-	return err == nil && (res == nil || !res.IsError())
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return fmt.Errorf("invalid bulk response: %v", err)
+	}
+
+	return bulkItemsError(payload.Bytes(), &r)
 }
 
 // LedgerCountInRange counts number of ledgers from the given range persisted into ES
-func (es *Client) LedgerCountInRange(min, max int) int {
+func (es *Client) LedgerCountInRange(min, max int) (int, error) {
 	var r map[string]interface{}
 	var buf bytes.Buffer
 
@@ -133,30 +217,29 @@ func (es *Client) LedgerCountInRange(min, max int) int {
 	}
 
 	if err := json.NewEncoder(&buf).Encode(query); err != nil {
-		log.Fatalf("Error encoding query: %s", err)
+		return 0, fmt.Errorf("error encoding query: %v", err)
 	}
 
 	res, err := es.rawClient.Count(
-		es.rawClient.Count.WithIndex("ledger"),
+		es.rawClient.Count.WithIndex(ledgerHeaderIndexName.String()),
 		es.rawClient.Count.WithBody(&buf),
 	)
-
-	fatalIfError(res, err)
-
-	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
-		log.Fatalf("Error parsing the response body: %s", err)
+	if err := responseError(res, err); err != nil {
+		return 0, fmt.Errorf("failed to count ledgers: %v", err)
 	}
 
-	res.Body.Close()
+	if err := decodeBody(res.Body, &r); err != nil {
+		return 0, err
+	}
 
-	return int(r["count"].(float64))
+	return int(r["count"].(float64)), nil
 }
 
 // GetLedgerSeqsInRange rerutns seqnums of ledgers from the given range persisted in the ES cluster
-func (es *Client) GetLedgerSeqsInRange(min, max int) (seqs []int) {
+func (es *Client) GetLedgerSeqsInRange(min, max int) (seqs []int, err error) {
 	query := map[string]interface{}{
 		"_source": []string{"seq"},
-		"size":    max - min + 1,
+		"size":    max - min,
 		"sort": []map[string]interface{}{{
 			"seq": "asc",
 		}},
@@ -170,7 +253,10 @@ func (es *Client) GetLedgerSeqsInRange(min, max int) (seqs []int) {
 		},
 	}
 
-	r := es.searchLedgers(query)
+	r, err := es.searchLedgers(query)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, hit := range r["hits"].(map[string]interface{})["hits"].([]interface{}) {
 		doc := hit.(map[string]interface{})
@@ -178,33 +264,147 @@ func (es *Client) GetLedgerSeqsInRange(min, max int) (seqs []int) {
 		seqs = append(seqs, int(source["seq"].(float64)))
 	}
 
-	return
+	return seqs, nil
 }
 
-// IndexWithRetries performs a bulk insert into ES cluster with retries on failures
-func (es *Client) IndexWithRetries(payload *bytes.Buffer, retryCount int) {
-	isIndexed := es.BulkInsert(payload)
+// LedgerHistogram returns ledger document counts per bucket of interval ledgers within the inclusive range, keyed by the first seq
+// of the bucket. Empty buckets are omitted. Buckets are paged with the composite aggregation, so the whole history fits.
+func (es *Client) LedgerHistogram(first, last, interval int) (map[int]int, error) {
+	var after map[string]interface{}
+
+	counts := make(map[int]int)
+
+	for {
+		var r struct {
+			Aggregations struct {
+				Seqs struct {
+					AfterKey map[string]interface{} `json:"after_key"`
+					Buckets  []struct {
+						Key struct {
+							Seq float64 `json:"seq"`
+						} `json:"key"`
+						DocCount int `json:"doc_count"`
+					} `json:"buckets"`
+				} `json:"seqs"`
+			} `json:"aggregations"`
+		}
+
+		composite := map[string]interface{}{
+			"size": histogramPageSize,
+			"sources": []map[string]interface{}{{
+				"seq": map[string]interface{}{
+					"histogram": map[string]interface{}{"field": "seq", "interval": interval},
+				},
+			}},
+		}
+
+		if after != nil {
+			composite["after"] = after
+		}
+
+		query := map[string]interface{}{
+			"size": 0,
+			"query": map[string]interface{}{
+				"range": map[string]interface{}{
+					"seq": map[string]interface{}{"gte": first, "lte": last},
+				},
+			},
+			"aggs": map[string]interface{}{
+				"seqs": map[string]interface{}{"composite": composite},
+			},
+		}
+
+		if err := es.search(ledgerHeaderIndexName, query, &r); err != nil {
+			return nil, err
+		}
+
+		for _, bucket := range r.Aggregations.Seqs.Buckets {
+			counts[int(bucket.Key.Seq)] = bucket.DocCount
+		}
 
-	if !isIndexed {
-		if retryCount-1 == 0 {
-			log.Fatal("Retries for bulk failed, aborting")
+		if len(r.Aggregations.Seqs.Buckets) < histogramPageSize || r.Aggregations.Seqs.AfterKey == nil {
+			return counts, nil
+		}
+
+		after = r.Aggregations.Seqs.AfterKey
+	}
+}
+
+// IndexWithRetries performs a bulk insert into ES cluster with retries on failures, zero retryCount means retry forever.
+// Only documents rejected temporarily are sent again, documents failed permanently are logged and skipped.
+// Returns the number of retries made, error if retries are exhausted.
+func (es *Client) IndexWithRetries(payload *bytes.Buffer, retryCount int) (retries int, err error) {
+	for attempt := 1; ; attempt++ {
+		err := es.BulkWrite(payload)
+		if err == nil {
+			break
+		}
+
+		if items, ok := err.(*BulkItemsError); ok {
+			if items.Rejected == 0 {
+				log.Printf("Bulk insert: %d documents failed permanently, skipped", items.Failed)
+				break
+			}
+
+			payload = items.Retry
+		}
+
+		if retryCount > 0 && attempt >= retryCount {
+			if items, ok := err.(*BulkItemsError); ok && DeadLetters != nil {
+				if err := DeadLetters.AddPayload(items.Retry.Bytes(), "retries_exhausted", err.Error()); err != nil {
+					return retries, err
+				}
+
+				log.Printf("Bulk insert: %d documents still rejected after %d attempts, added to dead letter queue", items.Rejected, attempt)
+				break
+			}
+
+			return retries, fmt.Errorf("retries for bulk failed: %v", err)
 		}
 
 		delay := time.Duration((rand.Intn(10) + 5))
+		log.Printf("Bulk insert failed (attempt %d): %v, retrying in %ds", attempt, err, delay)
 		time.Sleep(delay * time.Second)
 
-		es.IndexWithRetries(payload, retryCount-1)
+		retries = attempt
+	}
+
+	return retries, nil
+}
+
+func decodeBody(body io.ReadCloser, r interface{}) error {
+	defer body.Close()
+
+	if err := json.NewDecoder(body).Decode(r); err != nil {
+		return fmt.Errorf("error parsing the response body: %v", err)
 	}
+
+	return nil
 }
 
-func fatalIfError(res *esapi.Response, err error) {
+// closeResponse returns the request error or the error response body, the body is always closed
+func closeResponse(res *esapi.Response, err error) error {
+	if err := responseError(res, err); err != nil {
+		return err
+	}
+
+	return res.Body.Close()
+}
+
+// responseError returns the request error or the error response body, the body is closed on error
+func responseError(res *esapi.Response, err error) error {
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	if res.IsError() {
+		defer res.Body.Close()
+
 		buf := new(bytes.Buffer)
 		buf.ReadFrom(res.Body)
-		log.Fatal("Error in response", buf.String())
+
+		return fmt.Errorf("%s: %s", res.Status(), buf.String())
 	}
+
+	return nil
 }
@@ -1,21 +1,34 @@
 package es
 
 import (
-	"fmt"
+	"encoding/hex"
+	"regexp"
 
 	"github.com/stellar/go/xdr"
 )
 
+// LegacyAssetKeys adds CODE-ISSUER keys used before CODE:ISSUER as legacy_id, enable it for the migration window
+var LegacyAssetKeys bool
+
+var assetCodeFormat = regexp.MustCompile(`^[A-Za-z0-9]{1,12}$`)
+
 // Asset represents es-serializable asset
 type Asset struct {
-	Code   string `json:"code"`
-	Issuer string `json:"issuer,omitempty"`
-	ID     string `json:"id"`
+	Code     string `json:"code"`
+	Issuer   string `json:"issuer,omitempty"`
+	ID       string `json:"id"`
+	LegacyID string `json:"legacy_id,omitempty"`
 }
 
 // NewNativeAsset creates new native (XLM) Asset
 func NewNativeAsset() *Asset {
-	return &Asset{"native", "", "native"}
+	asset := &Asset{Code: "native", ID: "native"}
+
+	if LegacyAssetKeys {
+		asset.LegacyID = asset.ID
+	}
+
+	return asset
 }
 
 // NewAsset creates new non-native asset
@@ -28,5 +41,30 @@ func NewAsset(a *xdr.Asset) *Asset {
 		return NewNativeAsset()
 	}
 
-	return &Asset{c, i, fmt.Sprintf("%s-%s", c, i)}
+	asset := &Asset{Code: c, Issuer: i, ID: AssetKey(c, i)}
+
+	if LegacyAssetKeys {
+		asset.LegacyID = asset.legacyKey()
+	}
+
+	return asset
+}
+
+// AssetKey returns canonical CODE:ISSUER asset key, codes outside of the protocol charset are hex encoded
+// with # prefix, so keys of different assets never collide
+func AssetKey(code, issuer string) string {
+	if !assetCodeFormat.MatchString(code) {
+		code = "#" + hex.EncodeToString([]byte(code))
+	}
+
+	return code + ":" + issuer
+}
+
+// legacyKey returns CODE-ISSUER key used before AssetKey
+func (a *Asset) legacyKey() string {
+	if a.ID == "native" {
+		return a.ID
+	}
+
+	return a.Code + "-" + a.Issuer
 }
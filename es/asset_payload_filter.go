@@ -0,0 +1,74 @@
+package es
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/stellar/go/strkey"
+)
+
+// assetFilteredIndices are indices FilterAssets drops documents of, other documents are kept
+var assetFilteredIndices = map[IndexName]bool{
+	opIndexName:       true,
+	paymentsIndexName: true,
+	balanceIndexName:  true,
+	tradesIndexName:   true,
+}
+
+// ParseAssetKey parses native or CODE:ISSUER into the asset key used in documents
+func ParseAssetKey(s string) (string, error) {
+	if s == "native" {
+		return s, nil
+	}
+
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || len(parts[0]) > 12 {
+		return "", fmt.Errorf("invalid asset %q, use native or CODE:ISSUER", s)
+	}
+
+	if _, err := strkey.Decode(strkey.VersionByteAccountID, parts[1]); err != nil {
+		return "", fmt.Errorf("invalid asset issuer %q: %v", parts[1], err)
+	}
+
+	return AssetKey(parts[0], parts[1]), nil
+}
+
+// FilterAssets drops operations, payments, balances and trades of the bulk payload not involving any of the assets
+// given by keys, documents of other indices are kept
+func FilterAssets(payload []byte, assets map[string]bool) ([]byte, error) {
+	var out bytes.Buffer
+
+	docs, err := payloadDocs(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	quoted := make([][]byte, 0, len(assets))
+	for key := range assets {
+		quoted = append(quoted, []byte(`"`+key+`"`))
+	}
+
+	for _, doc := range docs {
+		if assetFilteredIndices[doc.index] && !mentionsAny(doc.source, quoted) {
+			continue
+		}
+
+		out.Write(doc.meta)
+		out.WriteByte('\n')
+		out.Write(doc.source)
+		out.WriteByte('\n')
+	}
+
+	return out.Bytes(), nil
+}
+
+func mentionsAny(source []byte, values [][]byte) bool {
+	for _, value := range values {
+		if bytes.Contains(source, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,88 @@
+package es
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// AuditRecord represents a link of the hash chain built over exported batches
+type AuditRecord struct {
+	First     int       `json:"first"`
+	Last      int       `json:"last"`
+	BatchHash string    `json:"batch_hash"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type auditResponse struct {
+	Hits struct {
+		Hits []struct {
+			Source AuditRecord `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// NewAuditRecord creates AuditRecord for the bulk payload of ledgers first..last chained to prevHash
+func NewAuditRecord(first, last int, payload []byte, prevHash string) *AuditRecord {
+	batchHash := sha256.Sum256(payload)
+
+	record := &AuditRecord{
+		First:     first,
+		Last:      last,
+		BatchHash: hex.EncodeToString(batchHash[:]),
+		PrevHash:  prevHash,
+		CreatedAt: time.Now(),
+	}
+
+	record.Hash = ChainHash(prevHash, record.BatchHash)
+
+	return record
+}
+
+// ChainHash returns the hash of the chain link, which covers the previous link hash and the batch hash
+func ChainHash(prevHash string, batchHash string) string {
+	hash := sha256.Sum256([]byte(prevHash + batchHash))
+	return hex.EncodeToString(hash[:])
+}
+
+// LastAuditRecord returns the latest audit record covering ledgers below the given seq, nil if there is none
+func (es *Client) LastAuditRecord(seq int) (*AuditRecord, error) {
+	var r auditResponse
+
+	query := map[string]interface{}{
+		"size": 1,
+		"sort": []map[string]interface{}{
+			{"last": "desc"},
+			{"created_at": "desc"},
+		},
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{
+				"last": map[string]interface{}{"lt": seq},
+			},
+		},
+	}
+
+	if err := es.search(auditIndexName, query, &r); err != nil {
+		return nil, err
+	}
+
+	if len(r.Hits.Hits) == 0 {
+		return nil, nil
+	}
+
+	return &r.Hits.Hits[0].Source, nil
+}
+
+// DocID returns es id
+func (r *AuditRecord) DocID() *string {
+	id := fmt.Sprintf("%012d-%012d", r.First, r.Last)
+	return &id
+}
+
+// IndexName returns index name
+func (r *AuditRecord) IndexName() IndexName {
+	return auditIndexName
+}
@@ -29,11 +29,24 @@ type Balance struct {
 	CreatedAt   time.Time     `json:"created_at"`
 	Source      BalanceSource `json:"source"`
 	Asset       Asset         `json:"asset"`
+	Removed     bool          `json:"removed,omitempty"`
+
+	BuyingLiabilities  string `json:"buying_liabilities,omitempty"`
+	SellingLiabilities string `json:"selling_liabilities,omitempty"`
+
+	// Flags holds account authorization flags for native balances
+	Flags *AccountFlags `json:"flags,omitempty"`
+
+	// Authorized and AuthorizedToMaintainLiab hold trustline authorization for credit balances
+	Authorized               *bool `json:"authorized,omitempty"`
+	AuthorizedToMaintainLiab *bool `json:"authorized_to_maintain_liabilities,omitempty"`
 }
 
 // NewBalanceFromAccountEntry creates Balance from AccountEntry
 func NewBalanceFromAccountEntry(a xdr.AccountEntry, diff xdr.Int64, now time.Time, pagingToken PagingToken, source BalanceSource) *Balance {
-	return &Balance{
+	flags := xdr.Uint32(a.Flags)
+
+	balance := &Balance{
 		PagingToken: pagingToken,
 		AccountID:   a.AccountId.Address(),
 		Value:       amount.String(a.Balance),
@@ -42,12 +55,38 @@ func NewBalanceFromAccountEntry(a xdr.AccountEntry, diff xdr.Int64, now time.Tim
 		Source:      source,
 		CreatedAt:   now,
 		Asset:       *NewNativeAsset(),
+		Flags:       NewAccountFlags(&flags),
+	}
+
+	if v1, ok := a.Ext.GetV1(); ok {
+		balance.BuyingLiabilities = amount.String(v1.Liabilities.Buying)
+		balance.SellingLiabilities = amount.String(v1.Liabilities.Selling)
+	}
+
+	return balance
+}
+
+// NewBalanceFromRemovedAccount creates zero Balance for the account removed by merge
+func NewBalanceFromRemovedAccount(accountID string, oldBalance xdr.Int64, now time.Time, pagingToken PagingToken, source BalanceSource) *Balance {
+	return &Balance{
+		PagingToken: pagingToken,
+		AccountID:   accountID,
+		Value:       amount.String(0),
+		Diff:        amount.String(-oldBalance),
+		Source:      source,
+		CreatedAt:   now,
+		Asset:       *NewNativeAsset(),
+		Removed:     true,
 	}
 }
 
 // NewBalanceFromTrustLineEntry creates Balance from TrustLineEntry
 func NewBalanceFromTrustLineEntry(t xdr.TrustLineEntry, diff xdr.Int64, now time.Time, pagingToken PagingToken, source BalanceSource) *Balance {
-	return &Balance{
+	flags := xdr.TrustLineFlags(t.Flags)
+	authorized := flags.IsAuthorized()
+	authorizedToMaintainLiab := flags.IsAuthorizedToMaintainLiabilitiesFlag()
+
+	balance := &Balance{
 		PagingToken: pagingToken,
 		AccountID:   t.AccountId.Address(),
 		Value:       amount.String(t.Balance),
@@ -55,7 +94,17 @@ func NewBalanceFromTrustLineEntry(t xdr.TrustLineEntry, diff xdr.Int64, now time
 		Source:      source,
 		CreatedAt:   now,
 		Asset:       *NewAsset(&t.Asset),
+
+		Authorized:               &authorized,
+		AuthorizedToMaintainLiab: &authorizedToMaintainLiab,
 	}
+
+	if v1, ok := t.Ext.GetV1(); ok {
+		balance.BuyingLiabilities = amount.String(v1.Liabilities.Buying)
+		balance.SellingLiabilities = amount.String(v1.Liabilities.Selling)
+	}
+
+	return balance
 }
 
 // DocID balance es document id
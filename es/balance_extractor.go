@@ -51,6 +51,9 @@ func (e *BalanceExtractor) extract() []*Balance {
 
 		case xdr.LedgerEntryChangeTypeLedgerEntryUpdated:
 			e.updated(change)
+
+		case xdr.LedgerEntryChangeTypeLedgerEntryRemoved:
+			e.removed(change)
 		}
 	}
 
@@ -136,3 +139,21 @@ func (e *BalanceExtractor) updated(change xdr.LedgerEntryChange) {
 		}
 	}
 }
+
+func (e *BalanceExtractor) removed(change xdr.LedgerEntryChange) {
+	key := change.MustRemoved()
+
+	if key.Type != xdr.LedgerEntryTypeAccount {
+		return
+	}
+
+	account := key.MustAccount()
+	address := account.AccountId.Address()
+	e.index++
+	pagingToken := PagingToken{EffectIndex: e.index}.Merge(e.basePagingToken)
+
+	e.balances = append(
+		e.balances,
+		NewBalanceFromRemovedAccount(address, e.values[address], e.closeTime, pagingToken, e.source),
+	)
+}
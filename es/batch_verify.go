@@ -0,0 +1,150 @@
+package es
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type bulkMeta struct {
+	// Action is the bulk action type: index, create, update or delete
+	Action string
+	Index  bulkAction `json:"index"`
+}
+
+type bulkAction struct {
+	Index   IndexName `json:"_index"`
+	ID      string    `json:"_id"`
+	Version *int64    `json:"version"`
+}
+
+// UnmarshalJSON reads index and create actions, the latter is used for data streams, as well as update and delete
+func (m *bulkMeta) UnmarshalJSON(data []byte) error {
+	var actions struct {
+		Index  *bulkAction `json:"index"`
+		Create *bulkAction `json:"create"`
+		Update *bulkAction `json:"update"`
+		Delete *bulkAction `json:"delete"`
+	}
+
+	if err := json.Unmarshal(data, &actions); err != nil {
+		return err
+	}
+
+	switch {
+	case actions.Index != nil:
+		m.Action, m.Index = "index", *actions.Index
+	case actions.Create != nil:
+		m.Action, m.Index = "create", *actions.Create
+	case actions.Update != nil:
+		m.Action, m.Index = "update", *actions.Update
+	case actions.Delete != nil:
+		m.Action, m.Index = "delete", *actions.Delete
+	default:
+		return fmt.Errorf("unknown bulk action: %s", data)
+	}
+
+	return nil
+}
+
+// hasSource returns true if the action line is followed by the source line, delete has no source
+func (m *bulkMeta) hasSource() bool {
+	return m.Action != "delete"
+}
+
+// BulkDocCounts returns number of documents per index in the bulk payload.
+// Versioned documents may be legitimately skipped by ES, they are not counted.
+func BulkDocCounts(payload []byte) (map[IndexName]int, error) {
+	counts := make(map[IndexName]int)
+	scanner := bufio.NewScanner(bytes.NewReader(payload))
+	scanner.Buffer(make([]byte, 64*1024), len(payload)+1)
+
+	for meta := true; scanner.Scan(); meta = !meta {
+		var m bulkMeta
+
+		if !meta {
+			continue
+		}
+
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			return nil, fmt.Errorf("invalid bulk payload: %v", err)
+		}
+
+		if m.Index.Version == nil && m.Index.Index != auditIndexName {
+			counts[m.Index.Index]++
+		}
+	}
+
+	return counts, nil
+}
+
+// VerifyBatch refreshes indices and compares documents count within ledgers first..last with expected counts,
+// returns descriptions of mismatches
+func (es *Client) VerifyBatch(expected map[IndexName]int, first, last int) (mismatches []string, err error) {
+	indices := make([]string, 0, len(expected))
+	for index := range expected {
+		indices = append(indices, index.String())
+	}
+
+	res, err := es.rawClient.Indices.Refresh(es.rawClient.Indices.Refresh.WithIndex(indices...))
+	if err := responseError(res, err); err != nil {
+		return nil, fmt.Errorf("failed to refresh indices: %v", err)
+	}
+
+	res.Body.Close()
+
+	for index, count := range expected {
+		var r struct {
+			Count int `json:"count"`
+		}
+
+		res, err := es.rawClient.Count(
+			es.rawClient.Count.WithIndex(index.String()),
+			es.rawClient.Count.WithBody(strings.NewReader(ledgerRangeQuery(first, last))),
+		)
+		if err := responseError(res, err); err != nil {
+			return nil, fmt.Errorf("failed to count %s documents: %v", index, err)
+		}
+
+		if err := decodeBody(res.Body, &r); err != nil {
+			return nil, err
+		}
+
+		if r.Count != count {
+			mismatches = append(mismatches, fmt.Sprintf("%s has %d documents, expected %d", index, r.Count, count))
+		}
+	}
+
+	return mismatches, nil
+}
+
+// DeleteLedgerRange deletes documents of ledgers first..last from the given indices
+func (es *Client) DeleteLedgerRange(indices []IndexName, first, last int) error {
+	names := make([]string, len(indices))
+	for i, index := range indices {
+		names[i] = index.String()
+	}
+
+	res, err := es.rawClient.DeleteByQuery(
+		names,
+		strings.NewReader(ledgerRangeQuery(first, last)),
+		es.rawClient.DeleteByQuery.WithRefresh(true),
+		es.rawClient.DeleteByQuery.WithConflicts("proceed"),
+	)
+	if err := responseError(res, err); err != nil {
+		return fmt.Errorf("failed to delete ledgers %d-%d: %v", first, last, err)
+	}
+
+	res.Body.Close()
+
+	return nil
+}
+
+func ledgerRangeQuery(first, last int) string {
+	return fmt.Sprintf(
+		`{"query":{"range":{"paging_token":{"gte":"%s","lt":"%s"}}}}`,
+		PagingToken{LedgerSeq: first}, PagingToken{LedgerSeq: last + 1},
+	)
+}
@@ -0,0 +1,65 @@
+package es
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// BloomFilter is a compact set which may report false positives but never false negatives
+type BloomFilter struct {
+	M    uint32 `json:"m"`
+	K    uint32 `json:"k"`
+	Bits []byte `json:"bits"`
+}
+
+// NewBloomFilter creates the filter sized for n items with the given false positive rate
+func NewBloomFilter(n int, fpRate float64) *BloomFilter {
+	if n < 1 {
+		n = 1
+	}
+
+	m := uint32(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+
+	k := uint32(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &BloomFilter{M: m, K: k, Bits: make([]byte, (m+7)/8)}
+}
+
+// Add adds the item to the filter
+func (f *BloomFilter) Add(item string) {
+	h1, h2 := bloomHashes(item)
+
+	for i := uint32(0); i < f.K; i++ {
+		n := (h1 + i*h2) % f.M
+		f.Bits[n/8] |= 1 << (n % 8)
+	}
+}
+
+// Test returns false if the item is definitely not in the filter
+func (f *BloomFilter) Test(item string) bool {
+	h1, h2 := bloomHashes(item)
+
+	for i := uint32(0); i < f.K; i++ {
+		n := (h1 + i*h2) % f.M
+		if f.Bits[n/8]&(1<<(n%8)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// bloomHashes returns two halves of 64-bit FNV-1a hash, used for double hashing
+func bloomHashes(item string) (h1, h2 uint32) {
+	h := fnv.New64a()
+	h.Write([]byte(item))
+	sum := h.Sum64()
+
+	return uint32(sum), uint32(sum>>32) | 1
+}
@@ -0,0 +1,114 @@
+package es
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// BulkItemsError is returned when ES rejected some documents of the bulk request
+type BulkItemsError struct {
+	// Retry holds documents rejected temporarily, e.g. by es_rejected_execution_exception
+	Retry *bytes.Buffer
+
+	Rejected int
+	Failed   int
+}
+
+func (e *BulkItemsError) Error() string {
+	return fmt.Sprintf("%d documents rejected temporarily, %d failed permanently", e.Rejected, e.Failed)
+}
+
+type bulkResponse struct {
+	Errors bool                  `json:"errors"`
+	Items  []map[string]bulkItem `json:"items"`
+}
+
+type bulkItem struct {
+	Index  string `json:"_index"`
+	ID     string `json:"_id"`
+	Status int    `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+// retryable returns true if the document may be accepted later
+func (i bulkItem) retryable() bool {
+	return i.Status == http.StatusTooManyRequests || i.Status >= http.StatusInternalServerError
+}
+
+// outdated returns true if ES already has the newer version of the document, which is expected for external versions
+func (i bulkItem) outdated() bool {
+	return i.Status == http.StatusConflict
+}
+
+// bulkItemsError logs failed items of the bulk response, returns nil if all documents were accepted
+func bulkItemsError(payload []byte, r *bulkResponse) error {
+	if !r.Errors {
+		return nil
+	}
+
+	var failed, rejected int
+	var retry bytes.Buffer
+
+	scanner := bufio.NewScanner(bytes.NewReader(payload))
+	scanner.Buffer(make([]byte, 64*1024), len(payload)+1)
+
+	// Items follow actions of the payload, every action takes one or two lines depending on its type
+	for _, result := range r.Items {
+		var meta, source []byte
+		var action bulkMeta
+
+		if scanner.Scan() {
+			meta = append([]byte(nil), scanner.Bytes()...)
+
+			if err := json.Unmarshal(meta, &action); err != nil {
+				return fmt.Errorf("invalid bulk payload: %v", err)
+			}
+		}
+
+		if action.hasSource() && scanner.Scan() {
+			source = scanner.Bytes()
+		}
+
+		for _, item := range result {
+			if item.Error == nil || item.outdated() {
+				continue
+			}
+
+			if item.retryable() {
+				rejected++
+
+				retry.Write(meta)
+				retry.WriteByte('\n')
+
+				if action.hasSource() {
+					retry.Write(source)
+					retry.WriteByte('\n')
+				}
+
+				continue
+			}
+
+			failed++
+			log.Printf("Document %s/%s failed: %s: %s", item.Index, item.ID, item.Error.Type, item.Error.Reason)
+
+			if DeadLetters != nil {
+				if err := DeadLetters.Add(item.Index, item.ID, item.Error.Type, item.Error.Reason, meta, source); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if failed == 0 && rejected == 0 {
+		return nil
+	}
+
+	return &BulkItemsError{Retry: &retry, Rejected: rejected, Failed: failed}
+}
@@ -0,0 +1,139 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// CreateSchema creates Astrologer indices, missing indices and fields are created on rerun,
+// returns error if existing indices conflict with their definitions
+func (es *Client) CreateSchema(force bool) error {
+	conflicts := 0
+
+	if LifecyclePolicy != nil {
+		if err := es.putLifecyclePolicy(); err != nil {
+			return err
+		}
+	}
+
+	definitions, err := GetIndexDefinitions()
+	if err != nil {
+		return err
+	}
+
+	for name, def := range definitions {
+		ok, err := es.refreshIndex(name, def, force)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			conflicts++
+		}
+	}
+
+	if conflicts > 0 {
+		return fmt.Errorf("%d indices differ from their definitions, use --force to recreate them", conflicts)
+	}
+
+	return nil
+}
+
+// refreshIndex creates, recreates or updates the index, returns false on conflicts
+func (es *Client) refreshIndex(name IndexName, schema IndexDefinition, force bool) (bool, error) {
+	if DataStreams[name] {
+		return true, es.refreshDataStream(name, schema, force)
+	}
+
+	if isTemplated(name) {
+		return true, es.refreshTemplate(name, schema, force)
+	}
+
+	exists, err := es.IndexExists(name)
+	if err != nil {
+		return false, err
+	}
+
+	if !exists {
+		if err := es.CreateIndex(name, schema); err != nil {
+			return false, err
+		}
+
+		log.Printf("%s index created!", name)
+		return true, nil
+	}
+
+	if force {
+		if err := es.DeleteIndex(name); err != nil {
+			return false, err
+		}
+
+		if err := es.CreateIndex(name, schema); err != nil {
+			return false, err
+		}
+
+		log.Printf("%s index recreated!", name)
+		return true, nil
+	}
+
+	return es.verifyIndex(name, schema)
+}
+
+func (es *Client) verifyIndex(name IndexName, schema IndexDefinition) (bool, error) {
+	diff, err := es.VerifyIndex(name, schema)
+	if err != nil {
+		return false, err
+	}
+
+	if diff.IsEmpty() {
+		log.Printf("%s index found and matches definition, skipping...", name)
+		return true, es.SetSchemaVersion(name)
+	}
+
+	if len(diff.MissingFields) > 0 {
+		if err := es.PutMissingFields(name, diff.MissingFields); err != nil {
+			return false, err
+		}
+
+		log.Printf("%s index found, %d missing fields added", name, len(diff.MissingFields))
+	}
+
+	if len(diff.Conflicts) > 0 {
+		for _, conflict := range diff.Conflicts {
+			log.Printf("%s index: %s", name, conflict)
+		}
+
+		return false, nil
+	}
+
+	return true, es.SetSchemaVersion(name)
+}
+
+// SchemaBodies returns bodies of create index requests as sent by create-index, index templates for data streams
+func SchemaBodies() (map[IndexName]json.RawMessage, error) {
+	bodies := make(map[IndexName]json.RawMessage)
+
+	definitions, err := GetIndexDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	for name, def := range definitions {
+		var body string
+
+		if DataStreams[name] {
+			body, err = dataStreamTemplate(name, def)
+		} else {
+			body, err = withSchemaVersion(name, def)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		bodies[name] = json.RawMessage(body)
+	}
+
+	return bodies, nil
+}
@@ -0,0 +1,80 @@
+package es
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/stellar/go/xdr"
+)
+
+// DataEntryState represents the latest known state of the account data entry
+type DataEntryState struct {
+	ID                 string      `json:"id"`
+	PagingToken        PagingToken `json:"paging_token"`
+	AccountID          string      `json:"account_id"`
+	Name               string      `json:"name"`
+	Value              string      `json:"value"`
+	LastModifiedLedger int         `json:"last_modified_ledger"`
+	LedgerCloseTime    time.Time   `json:"ledger_close_time"`
+	Deleted            bool        `json:"deleted"`
+}
+
+// ProduceDataEntryStates returns data entry states changed by the operation meta
+func ProduceDataEntryStates(changes xdr.LedgerEntryChanges, pagingToken PagingToken, closeTime time.Time) (entries []*DataEntryState) {
+	for _, change := range changes {
+		switch change.Type {
+		case xdr.LedgerEntryChangeTypeLedgerEntryCreated:
+			entry := change.MustCreated()
+			if d, ok := entry.Data.GetData(); ok {
+				entries = append(entries, NewDataEntryState(d, int(entry.LastModifiedLedgerSeq), pagingToken, closeTime))
+			}
+		case xdr.LedgerEntryChangeTypeLedgerEntryUpdated:
+			entry := change.MustUpdated()
+			if d, ok := entry.Data.GetData(); ok {
+				entries = append(entries, NewDataEntryState(d, int(entry.LastModifiedLedgerSeq), pagingToken, closeTime))
+			}
+		case xdr.LedgerEntryChangeTypeLedgerEntryRemoved:
+			key := change.MustRemoved()
+			if k, ok := key.GetData(); ok {
+				d := xdr.DataEntry{AccountId: k.AccountId, DataName: k.DataName}
+				entry := NewDataEntryState(d, pagingToken.LedgerSeq, pagingToken, closeTime)
+				entry.Deleted = true
+
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return entries
+}
+
+// NewDataEntryState creates DataEntryState from DataEntry
+func NewDataEntryState(d xdr.DataEntry, lastModified int, pagingToken PagingToken, closeTime time.Time) *DataEntryState {
+	accountID := d.AccountId.Address()
+	name := string(d.DataName)
+
+	return &DataEntryState{
+		ID:                 accountID + "-" + name,
+		PagingToken:        pagingToken,
+		AccountID:          accountID,
+		Name:               name,
+		Value:              base64.StdEncoding.EncodeToString(d.DataValue),
+		LastModifiedLedger: lastModified,
+		LedgerCloseTime:    closeTime,
+	}
+}
+
+// DocID returns data entry id
+func (d *DataEntryState) DocID() *string {
+	return &d.ID
+}
+
+// IndexName returns data entries index name
+func (d *DataEntryState) IndexName() IndexName {
+	return dataEntriesIndexName
+}
+
+// Version returns document version, newer changes overwrite older ones
+func (d *DataEntryState) Version() (int64, error) {
+	return d.PagingToken.Version()
+}
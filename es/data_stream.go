@@ -0,0 +1,150 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+)
+
+// DataStreams lists indices written as data streams instead of classic indices
+var DataStreams = make(map[IndexName]bool)
+
+// DataStreamPolicy is the ILM policy attached to data stream templates, empty means none
+var DataStreamPolicy string
+
+// appendOnlyIndices can be data streams: their documents are never updated, data streams reject versioned writes
+var appendOnlyIndices = map[IndexName]bool{
+	ledgerHeaderIndexName:  true,
+	txIndexName:            true,
+	opIndexName:            true,
+	balanceIndexName:       true,
+	tradesIndexName:        true,
+	signerHistoryIndexName: true,
+	effectsIndexName:       true,
+	changesIndexName:       true,
+	paymentsIndexName:      true,
+}
+
+// ParseDataStreams checks the indices can be written as data streams
+func ParseDataStreams(indices []string) (map[IndexName]bool, error) {
+	streams := make(map[IndexName]bool, len(indices))
+
+	for _, index := range indices {
+		name := IndexName(index)
+
+		if !appendOnlyIndices[name] {
+			return nil, fmt.Errorf("index %s can not be a data stream, its documents are updated", index)
+		}
+
+		streams[name] = true
+	}
+
+	return streams, nil
+}
+
+// refreshDataStream puts the index template of the data stream and creates the stream if missing
+func (es *Client) refreshDataStream(name IndexName, schema IndexDefinition, force bool) error {
+	exists, err := es.IndexExists(name)
+	if err != nil {
+		return err
+	}
+
+	if force && exists {
+		if err := closeResponse(es.perform(http.MethodDelete, "/_data_stream/"+name.String(), nil)); err != nil {
+			return fmt.Errorf("failed to delete %s data stream: %v", name, err)
+		}
+
+		log.Printf("%s data stream deleted", name)
+		exists = false
+	}
+
+	template, err := dataStreamTemplate(name, schema)
+	if err != nil {
+		return err
+	}
+
+	if err := closeResponse(es.perform(http.MethodPut, "/_index_template/"+name.String(), strings.NewReader(template))); err != nil {
+		return fmt.Errorf("failed to put %s index template: %v", name, err)
+	}
+
+	if exists {
+		log.Printf("%s data stream template updated, mappings apply after the next rollover", name)
+		return nil
+	}
+
+	if err := closeResponse(es.perform(http.MethodPut, "/_data_stream/"+name.String(), nil)); err != nil {
+		return fmt.Errorf("failed to create %s data stream: %v", name, err)
+	}
+
+	log.Printf("%s data stream created!", name)
+
+	return nil
+}
+
+// dataStreamTemplate wraps the index definition into composable index template with @timestamp mapping
+func dataStreamTemplate(name IndexName, schema IndexDefinition) (string, error) {
+	var template map[string]interface{}
+
+	body, err := withSchemaVersion(name, schema)
+	if err != nil {
+		return "", err
+	}
+
+	if err := json.Unmarshal([]byte(body), &template); err != nil {
+		return "", err
+	}
+
+	mappings := template["mappings"].(map[string]interface{})
+	properties, _ := mappings["properties"].(map[string]interface{})
+	if properties == nil {
+		properties = make(map[string]interface{})
+		mappings["properties"] = properties
+	}
+
+	properties["@timestamp"] = map[string]interface{}{"type": "date"}
+
+	if DataStreamPolicy != "" {
+		settings, _ := template["settings"].(map[string]interface{})
+		if settings == nil {
+			settings = make(map[string]interface{})
+			template["settings"] = settings
+		}
+
+		settings["index.lifecycle.name"] = DataStreamPolicy
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"index_patterns": []string{name.String()},
+		"data_stream":    map[string]interface{}{},
+		"template":       template,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+// perform sends the request to API endpoints missing in the client library
+func (es *Client) perform(method, path string, body io.Reader) (*esapi.Response, error) {
+	req, err := http.NewRequest(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := es.rawClient.Perform(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &esapi.Response{StatusCode: res.StatusCode, Body: res.Body, Header: res.Header}, nil
+}
@@ -0,0 +1,139 @@
+package es
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DeadLetters receives documents failed permanently or after all retries, nil means failures are only logged
+var DeadLetters *DeadLetterQueue
+
+// DeadLetter represents the document which failed to be indexed
+type DeadLetter struct {
+	Time      time.Time       `json:"time"`
+	Index     string          `json:"index"`
+	ID        string          `json:"id,omitempty"`
+	ErrorType string          `json:"error_type"`
+	Reason    string          `json:"reason"`
+	Meta      json.RawMessage `json:"meta"`
+	Source    json.RawMessage `json:"source,omitempty"`
+}
+
+// DeadLetterQueue appends failed documents to the JSON lines file, the file is created on the first failure
+type DeadLetterQueue struct {
+	path  string
+	mutex sync.Mutex
+	file  *os.File
+	count int
+}
+
+// NewDeadLetterQueue creates DeadLetterQueue writing to the file
+func NewDeadLetterQueue(path string) *DeadLetterQueue {
+	return &DeadLetterQueue{path: path}
+}
+
+// Add appends the document bulk lines to the queue
+func (q *DeadLetterQueue) Add(index, id, errorType, reason string, meta, source []byte) error {
+	letter := DeadLetter{
+		Time:      time.Now().UTC(),
+		Index:     index,
+		ID:        id,
+		ErrorType: errorType,
+		Reason:    reason,
+		Meta:      json.RawMessage(meta),
+		Source:    json.RawMessage(source),
+	}
+
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.file == nil {
+		q.file, err = os.OpenFile(q.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open dead letter queue: %v", err)
+		}
+	}
+
+	if _, err := q.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write dead letter queue: %v", err)
+	}
+
+	q.count++
+
+	return nil
+}
+
+// AddPayload appends all documents of the bulk payload with the same reason
+func (q *DeadLetterQueue) AddPayload(payload []byte, errorType, reason string) error {
+	var m bulkMeta
+
+	scanner := bufio.NewScanner(bytes.NewReader(payload))
+	scanner.Buffer(make([]byte, 64*1024), len(payload)+1)
+
+	for scanner.Scan() {
+		var source []byte
+
+		meta := append([]byte(nil), scanner.Bytes()...)
+
+		m = bulkMeta{}
+		if err := json.Unmarshal(meta, &m); err != nil {
+			return fmt.Errorf("invalid bulk payload: %v", err)
+		}
+
+		if m.hasSource() {
+			if !scanner.Scan() {
+				break
+			}
+
+			source = scanner.Bytes()
+		}
+
+		if err := q.Add(string(m.Index.Index), m.Index.ID, errorType, reason, meta, source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Count returns the number of documents added
+func (q *DeadLetterQueue) Count() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.count
+}
+
+// ReadDeadLetters reads the queue file
+func ReadDeadLetters(path string) (letters []DeadLetter, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for scanner.Scan() {
+		var letter DeadLetter
+
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			return nil, err
+		}
+
+		letters = append(letters, letter)
+	}
+
+	return letters, scanner.Err()
+}
@@ -0,0 +1,119 @@
+package es
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// maxLedgerDocuments is the maximum number of documents of one index fetched for the ledger
+const maxLedgerDocuments = 10000
+
+// StoredDocument represents the document of the bulk payload or the index
+type StoredDocument struct {
+	Index  IndexName
+	ID     string
+	Source json.RawMessage
+}
+
+// BulkDocuments returns documents of the bulk payload, versioned and audit documents are skipped as BulkDocCounts does
+func BulkDocuments(payload []byte) (docs []StoredDocument, err error) {
+	var m bulkMeta
+
+	scanner := bufio.NewScanner(bytes.NewReader(payload))
+	scanner.Buffer(make([]byte, 64*1024), len(payload)+1)
+
+	for meta := true; scanner.Scan(); meta = !meta {
+		if meta {
+			m = bulkMeta{}
+
+			if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+				return nil, fmt.Errorf("invalid bulk payload: %v", err)
+			}
+
+			continue
+		}
+
+		if m.Index.Version != nil || m.Index.Index == auditIndexName {
+			continue
+		}
+
+		source := make([]byte, len(scanner.Bytes()))
+		copy(source, scanner.Bytes())
+
+		docs = append(docs, StoredDocument{Index: m.Index.Index, ID: m.Index.ID, Source: source})
+	}
+
+	return docs, nil
+}
+
+// LedgerDocuments returns documents of the index belonging to the ledger
+func (es *Client) LedgerDocuments(index IndexName, seq int) ([]StoredDocument, error) {
+	var r struct {
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID     string          `json:"_id"`
+				Source json.RawMessage `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	query := map[string]interface{}{
+		"size":             maxLedgerDocuments,
+		"track_total_hits": true,
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{
+				"paging_token": map[string]interface{}{
+					"gte": PagingToken{LedgerSeq: seq}.String(),
+					"lt":  PagingToken{LedgerSeq: seq + 1}.String(),
+				},
+			},
+		},
+	}
+
+	if err := es.search(index, query, &r); err != nil {
+		return nil, err
+	}
+
+	if r.Hits.Total.Value > maxLedgerDocuments {
+		return nil, fmt.Errorf("%s has %d documents of ledger %d, only %d can be compared", index, r.Hits.Total.Value, seq, maxLedgerDocuments)
+	}
+
+	docs := make([]StoredDocument, len(r.Hits.Hits))
+
+	for n, hit := range r.Hits.Hits {
+		docs[n] = StoredDocument{Index: index, ID: hit.ID, Source: hit.Source}
+	}
+
+	return docs, nil
+}
+
+// ContentHash returns the hash of the document source independent of field order, @timestamp is ignored
+func ContentHash(source []byte) (string, error) {
+	var doc map[string]interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(source))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&doc); err != nil {
+		return "", fmt.Errorf("invalid document source: %v", err)
+	}
+
+	delete(doc, "@timestamp")
+
+	// Map keys are sorted by encoding/json
+	canonical, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(canonical)
+
+	return hex.EncodeToString(hash[:]), nil
+}
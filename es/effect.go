@@ -0,0 +1,76 @@
+package es
+
+import (
+	"time"
+)
+
+// EffectType represents Horizon-style effect type
+type EffectType string
+
+const (
+	EffectAccountCreated                EffectType = "account_created"
+	EffectAccountRemoved                EffectType = "account_removed"
+	EffectAccountCredited               EffectType = "account_credited"
+	EffectAccountDebited                EffectType = "account_debited"
+	EffectAccountThresholdsUpdated      EffectType = "account_thresholds_updated"
+	EffectAccountHomeDomainUpdated      EffectType = "account_home_domain_updated"
+	EffectAccountFlagsUpdated           EffectType = "account_flags_updated"
+	EffectAccountInflationDestUpdated   EffectType = "account_inflation_destination_updated"
+	EffectSignerCreated                 EffectType = "signer_created"
+	EffectSignerRemoved                 EffectType = "signer_removed"
+	EffectSignerUpdated                 EffectType = "signer_updated"
+	EffectTrustlineCreated              EffectType = "trustline_created"
+	EffectTrustlineRemoved              EffectType = "trustline_removed"
+	EffectTrustlineUpdated              EffectType = "trustline_updated"
+	EffectTrustlineAuthorized           EffectType = "trustline_authorized"
+	EffectTrustlineAuthorizedToMaintain EffectType = "trustline_authorized_to_maintain_liabilities"
+	EffectTrustlineDeauthorized         EffectType = "trustline_deauthorized"
+	EffectTrade                         EffectType = "trade"
+	EffectDataCreated                   EffectType = "data_created"
+	EffectDataRemoved                   EffectType = "data_removed"
+	EffectDataUpdated                   EffectType = "data_updated"
+	EffectSequenceBumped                EffectType = "sequence_bumped"
+)
+
+// Effect represents Horizon-style effect of the operation
+type Effect struct {
+	ID              string      `json:"id"`
+	PagingToken     PagingToken `json:"paging_token"`
+	Type            EffectType  `json:"type"`
+	AccountID       string      `json:"account_id"`
+	OperationType   string      `json:"op_type"`
+	TxID            string      `json:"tx_id"`
+	Seq             int         `json:"seq"`
+	TxIndex         int         `json:"tx_idx"`
+	Index           int         `json:"op_idx"`
+	LedgerCloseTime time.Time   `json:"ledger_close_time"`
+
+	Amount        string             `json:"amount,omitempty"`
+	Asset         *Asset             `json:"asset,omitempty"`
+	Limit         string             `json:"limit,omitempty"`
+	Signer        *Signer            `json:"signer,omitempty"`
+	Thresholds    *AccountThresholds `json:"thresholds,omitempty"`
+	HomeDomain    string             `json:"home_domain,omitempty"`
+	SetFlags      *AccountFlags      `json:"set_flags,omitempty"`
+	ClearFlags    *AccountFlags      `json:"clear_flags,omitempty"`
+	InflationDest string             `json:"inflation_dest_id,omitempty"`
+	Data          *DataEntry         `json:"data,omitempty"`
+	BumpTo        int                `json:"bump_to,omitempty"`
+
+	SoldAmount     string `json:"sold_amount,omitempty"`
+	SoldAsset      *Asset `json:"sold_asset,omitempty"`
+	BoughtAmount   string `json:"bought_amount,omitempty"`
+	BoughtAsset    *Asset `json:"bought_asset,omitempty"`
+	CounterpartyID string `json:"counterparty_id,omitempty"`
+	OfferID        int64  `json:"offer_id,omitempty"`
+}
+
+// DocID returns es document id
+func (e *Effect) DocID() *string {
+	return &e.ID
+}
+
+// IndexName returns effects index name
+func (e *Effect) IndexName() IndexName {
+	return effectsIndexName
+}
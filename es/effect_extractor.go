@@ -0,0 +1,259 @@
+package es
+
+import (
+	"github.com/stellar/go/xdr"
+)
+
+// EffectExtractor is used to produce effects of the single operation
+type EffectExtractor struct {
+	operation *Operation
+	source    *xdr.Operation
+	changes   xdr.LedgerEntryChanges
+	trades    []Trade
+
+	effects []*Effect
+}
+
+// ProduceEffects returns effects of the successful operation
+func ProduceEffects(op *Operation, source *xdr.Operation, changes xdr.LedgerEntryChanges, trades []Trade) []*Effect {
+	e := &EffectExtractor{
+		operation: op,
+		source:    source,
+		changes:   changes,
+		trades:    trades,
+	}
+
+	return e.extract()
+}
+
+func (e *EffectExtractor) extract() []*Effect {
+	op := e.operation
+
+	switch e.source.Body.Type {
+	case xdr.OperationTypeCreateAccount:
+		native := NewNativeAsset()
+
+		e.add(EffectAccountCreated, op.DestinationAccountID, func(f *Effect) {
+			f.Amount = op.SourceAmount
+		})
+		e.add(EffectAccountDebited, op.SourceAccountID, func(f *Effect) {
+			f.Amount = op.SourceAmount
+			f.Asset = native
+		})
+		e.add(EffectSignerCreated, op.DestinationAccountID, func(f *Effect) {
+			f.Signer = &Signer{ID: op.DestinationAccountID, Weight: 1, TypeName: "ed25519"}
+		})
+	case xdr.OperationTypePayment:
+		e.credit(op.DestinationAccountID, op.SourceAmount, op.SourceAsset)
+		e.debit(op.SourceAccountID, op.SourceAmount, op.SourceAsset)
+	case xdr.OperationTypePathPaymentStrictReceive, xdr.OperationTypePathPaymentStrictSend:
+		e.credit(op.DestinationAccountID, op.AmountReceived, op.DestinationAsset)
+
+		sent := op.AmountSent
+		if sent == "" {
+			sent = op.SourceAmount
+		}
+
+		e.debit(op.SourceAccountID, sent, op.SourceAsset)
+	case xdr.OperationTypeChangeTrust:
+		e.add(e.trustlineEffectType(), op.SourceAccountID, func(f *Effect) {
+			f.Asset = op.DestinationAsset
+			f.Limit = op.DestinationAmount
+		})
+	case xdr.OperationTypeAllowTrust:
+		t := EffectTrustlineDeauthorized
+
+		switch op.Authorize {
+		case Full:
+			t = EffectTrustlineAuthorized
+		case MaintainLiabilities:
+			t = EffectTrustlineAuthorizedToMaintain
+		}
+
+		e.add(t, op.SourceAccountID, func(f *Effect) {
+			f.Asset = op.DestinationAsset
+			f.CounterpartyID = op.DestinationAccountID
+		})
+	case xdr.OperationTypeAccountMerge:
+		native := NewNativeAsset()
+
+		e.debit(op.SourceAccountID, op.ResultSourceAccountBalance, native)
+		e.credit(op.DestinationAccountID, op.ResultSourceAccountBalance, native)
+		e.add(EffectAccountRemoved, op.SourceAccountID, nil)
+	case xdr.OperationTypeSetOptions:
+		e.setOptions()
+	case xdr.OperationTypeManageData:
+		e.add(e.dataEffectType(), op.SourceAccountID, func(f *Effect) {
+			f.Data = op.Data
+		})
+	case xdr.OperationTypeBumpSequence:
+		e.add(EffectSequenceBumped, op.SourceAccountID, func(f *Effect) {
+			f.BumpTo = op.BumpTo
+		})
+	}
+
+	e.addTrades()
+
+	return e.effects
+}
+
+func (e *EffectExtractor) add(t EffectType, accountID string, assign func(f *Effect)) {
+	op := e.operation
+	pagingToken := PagingToken{EffectIndex: len(e.effects) + 1}.Merge(op.PagingToken)
+
+	effect := &Effect{
+		ID:              pagingToken.String(),
+		PagingToken:     pagingToken,
+		Type:            t,
+		AccountID:       accountID,
+		OperationType:   op.Type,
+		TxID:            op.TxID,
+		Seq:             op.Seq,
+		TxIndex:         op.TxIndex,
+		Index:           op.Index,
+		LedgerCloseTime: op.CloseTime,
+	}
+
+	if assign != nil {
+		assign(effect)
+	}
+
+	e.effects = append(e.effects, effect)
+}
+
+func (e *EffectExtractor) credit(accountID string, amount string, asset *Asset) {
+	e.add(EffectAccountCredited, accountID, func(f *Effect) {
+		f.Amount = amount
+		f.Asset = asset
+	})
+}
+
+func (e *EffectExtractor) debit(accountID string, amount string, asset *Asset) {
+	e.add(EffectAccountDebited, accountID, func(f *Effect) {
+		f.Amount = amount
+		f.Asset = asset
+	})
+}
+
+func (e *EffectExtractor) setOptions() {
+	op := e.operation
+	o := e.source.Body.MustSetOptionsOp()
+
+	if o.HomeDomain != nil {
+		e.add(EffectAccountHomeDomainUpdated, op.SourceAccountID, func(f *Effect) {
+			f.HomeDomain = op.HomeDomain
+		})
+	}
+
+	if op.Thresholds != nil {
+		e.add(EffectAccountThresholdsUpdated, op.SourceAccountID, func(f *Effect) {
+			f.Thresholds = op.Thresholds
+		})
+	}
+
+	if op.SetFlags != nil || op.ClearFlags != nil {
+		e.add(EffectAccountFlagsUpdated, op.SourceAccountID, func(f *Effect) {
+			f.SetFlags = op.SetFlags
+			f.ClearFlags = op.ClearFlags
+		})
+	}
+
+	if op.InflationDest != "" {
+		e.add(EffectAccountInflationDestUpdated, op.SourceAccountID, func(f *Effect) {
+			f.InflationDest = op.InflationDest
+		})
+	}
+
+	if op.Signer != nil {
+		t := EffectSignerUpdated
+
+		if op.Signer.Weight == 0 {
+			t = EffectSignerRemoved
+		} else if !e.signerExisted(o.Signer.Key) {
+			t = EffectSignerCreated
+		}
+
+		e.add(t, op.SourceAccountID, func(f *Effect) {
+			f.Signer = op.Signer
+		})
+	}
+}
+
+func (e *EffectExtractor) addTrades() {
+	for _, trade := range e.trades {
+		trade := trade
+
+		e.add(EffectTrade, trade.SellerID, func(f *Effect) {
+			f.SoldAmount = trade.Sold
+			f.SoldAsset = &trade.AssetSold
+			f.BoughtAmount = trade.Bought
+			f.BoughtAsset = &trade.AssetBought
+			f.CounterpartyID = trade.BuyerID
+			f.OfferID = trade.OfferID
+		})
+	}
+}
+
+// trustlineEffectType detects if the trustline was created, updated or removed using meta changes
+func (e *EffectExtractor) trustlineEffectType() EffectType {
+	switch e.changeTypeFor(xdr.LedgerEntryTypeTrustline) {
+	case xdr.LedgerEntryChangeTypeLedgerEntryCreated:
+		return EffectTrustlineCreated
+	case xdr.LedgerEntryChangeTypeLedgerEntryRemoved:
+		return EffectTrustlineRemoved
+	default:
+		return EffectTrustlineUpdated
+	}
+}
+
+// dataEffectType detects if the data entry was created, updated or removed using meta changes
+func (e *EffectExtractor) dataEffectType() EffectType {
+	switch e.changeTypeFor(xdr.LedgerEntryTypeData) {
+	case xdr.LedgerEntryChangeTypeLedgerEntryCreated:
+		return EffectDataCreated
+	case xdr.LedgerEntryChangeTypeLedgerEntryRemoved:
+		return EffectDataRemoved
+	default:
+		return EffectDataUpdated
+	}
+}
+
+// changeTypeFor returns type of the first create/remove change of given entry type, falls back to update
+func (e *EffectExtractor) changeTypeFor(entryType xdr.LedgerEntryType) xdr.LedgerEntryChangeType {
+	for _, change := range e.changes {
+		switch change.Type {
+		case xdr.LedgerEntryChangeTypeLedgerEntryCreated:
+			if change.MustCreated().Data.Type == entryType {
+				return change.Type
+			}
+		case xdr.LedgerEntryChangeTypeLedgerEntryRemoved:
+			if change.MustRemoved().Type == entryType {
+				return change.Type
+			}
+		}
+	}
+
+	return xdr.LedgerEntryChangeTypeLedgerEntryUpdated
+}
+
+// signerExisted checks if the signer was present in the account state before the operation
+func (e *EffectExtractor) signerExisted(key xdr.SignerKey) bool {
+	for _, change := range e.changes {
+		if change.Type != xdr.LedgerEntryChangeTypeLedgerEntryState {
+			continue
+		}
+
+		account, ok := change.MustState().Data.GetAccount()
+		if !ok {
+			continue
+		}
+
+		for _, signer := range account.Signers {
+			if signer.Key.Address() == key.Address() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,70 @@
+package es
+
+import (
+	"time"
+)
+
+// ExportSummary represents scope and cost of the finished export
+type ExportSummary struct {
+	ID           string    `json:"id"`
+	Ranges       string    `json:"ranges"`
+	Ledgers      int       `json:"ledgers"`
+	Transactions int       `json:"transactions"`
+	Operations   int       `json:"operations"`
+	Balances     int       `json:"balances"`
+	Documents    int       `json:"documents"`
+	Bytes        int64     `json:"bytes"`
+	Retries      int       `json:"retries"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+
+	// Elapsed duration in seconds
+	Elapsed float64 `json:"elapsed"`
+
+	// Rate average ledgers per second
+	Rate float64 `json:"rate"`
+}
+
+// Add accumulates document counts and size of the bulk payload
+func (s *ExportSummary) Add(payload []byte) error {
+	counts, err := BulkDocCounts(payload)
+	if err != nil {
+		return err
+	}
+
+	s.Ledgers += counts[ledgerHeaderIndexName]
+	s.Transactions += counts[txIndexName]
+	s.Operations += counts[opIndexName]
+	s.Balances += counts[balanceIndexName]
+
+	for _, count := range counts {
+		s.Documents += count
+	}
+
+	s.Bytes += int64(len(payload))
+
+	return nil
+}
+
+// Finish sets finish time, elapsed time and rate
+func (s *ExportSummary) Finish() {
+	s.FinishedAt = time.Now()
+	s.ID = s.StartedAt.UTC().Format("20060102-150405.000000000")
+
+	elapsed := s.FinishedAt.Sub(s.StartedAt)
+	s.Elapsed = elapsed.Seconds()
+
+	if s.Elapsed > 0 {
+		s.Rate = float64(s.Ledgers) / s.Elapsed
+	}
+}
+
+// DocID returns es id
+func (s *ExportSummary) DocID() *string {
+	return &s.ID
+}
+
+// IndexName returns index name
+func (s *ExportSummary) IndexName() IndexName {
+	return exportSummariesIndexName
+}
@@ -0,0 +1,78 @@
+package es
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// FailureInjector fails a share of bulk writes to the wrapped output, used to test retries.
+// Half of injected failures happen after the payload was delivered, like connection dropped
+// before the response, so the same documents are written again on retry.
+type FailureInjector struct {
+	Sink
+
+	rate float64
+}
+
+// NewFailureInjector wraps the output, percent is the share of failed bulk writes
+func NewFailureInjector(sink Sink, percent float64) *FailureInjector {
+	log.Printf("Injecting failures into %.1f%% of bulk writes", percent)
+
+	return &FailureInjector{Sink: sink, rate: percent / 100}
+}
+
+// BulkWrite writes the payload to the wrapped output unless the failure is injected
+func (f *FailureInjector) BulkWrite(payload *bytes.Buffer) error {
+	if rand.Float64() >= f.rate {
+		return f.Sink.BulkWrite(payload)
+	}
+
+	if rand.Intn(2) == 0 {
+		return errors.New("injected failure: bulk request rejected")
+	}
+
+	if err := f.Sink.BulkWrite(payload); err != nil {
+		return err
+	}
+
+	return errors.New("injected failure: connection dropped")
+}
+
+// IndexWithRetries writes the payload, retries in case of real or injected failure
+func (f *FailureInjector) IndexWithRetries(payload *bytes.Buffer, retryCount int) (retries int, err error) {
+	for attempt := 1; ; attempt++ {
+		err := f.BulkWrite(payload)
+		if err == nil {
+			break
+		}
+
+		if items, ok := err.(*BulkItemsError); ok {
+			if items.Rejected == 0 {
+				break
+			}
+
+			payload = items.Retry
+		}
+
+		if retryCount > 0 && attempt >= retryCount {
+			return retries, fmt.Errorf("retries for bulk failed: %v", err)
+		}
+
+		delay := time.Duration(attempt) * time.Second
+		log.Printf("Bulk insert failed (attempt %d): %v, retrying in %s", attempt, err, delay)
+		time.Sleep(delay)
+
+		retries = attempt
+	}
+
+	return retries, nil
+}
+
+// Unwrap returns the wrapped output, reads are not affected by injected failures
+func (f *FailureInjector) Unwrap() Sink {
+	return f.Sink
+}
@@ -0,0 +1,94 @@
+package es
+
+import (
+	"bytes"
+	"sync"
+)
+
+// FanOut writes documents to several outputs, reads are served by the primary one.
+// Every output retries failed writes on its own.
+type FanOut struct {
+	Sink
+
+	secondary []Sink
+}
+
+// NewFanOut creates FanOut over the primary and secondary outputs
+func NewFanOut(primary Sink, secondary ...Sink) *FanOut {
+	return &FanOut{Sink: primary, secondary: secondary}
+}
+
+// Unwrap returns the primary output serving reads
+func (f *FanOut) Unwrap() Sink {
+	return f.Sink
+}
+
+// CreateSchema creates schema in every output, returns the first error
+func (f *FanOut) CreateSchema(force bool) error {
+	var first error
+
+	for _, a := range f.all() {
+		if err := a.CreateSchema(force); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+// BulkWrite writes the payload to every output concurrently, returns the first error
+func (f *FanOut) BulkWrite(payload *bytes.Buffer) error {
+	errs := make([]error, len(f.all()))
+
+	f.each(func(n int, a Sink) {
+		errs[n] = a.BulkWrite(bytes.NewBuffer(payload.Bytes()))
+	})
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IndexWithRetries writes the payload to every output concurrently, returns the total number of retries
+// and the first error
+func (f *FanOut) IndexWithRetries(payload *bytes.Buffer, retryCount int) (retries int, err error) {
+	counts := make([]int, len(f.all()))
+	errs := make([]error, len(f.all()))
+
+	f.each(func(n int, a Sink) {
+		counts[n], errs[n] = a.IndexWithRetries(bytes.NewBuffer(payload.Bytes()), retryCount)
+	})
+
+	for n, count := range counts {
+		retries += count
+
+		if errs[n] != nil && err == nil {
+			err = errs[n]
+		}
+	}
+
+	return retries, err
+}
+
+func (f *FanOut) all() []Sink {
+	return append([]Sink{f.Sink}, f.secondary...)
+}
+
+func (f *FanOut) each(fn func(n int, a Sink)) {
+	var wg sync.WaitGroup
+
+	for n, a := range f.all() {
+		wg.Add(1)
+
+		go func(n int, a Sink) {
+			defer wg.Done()
+			fn(n, a)
+		}(n, a)
+	}
+
+	wg.Wait()
+}
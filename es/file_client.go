@@ -0,0 +1,96 @@
+package es
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileClient writes bulk NDJSON payloads to rotated files instead of ElasticSearch,
+// files can be replayed with curl -H 'Content-Type: application/x-ndjson' --data-binary @file $ES_URL/_bulk
+type FileClient struct {
+	writeOnlySink
+
+	dir     string
+	maxSize int64
+
+	mutex   sync.Mutex
+	file    *os.File
+	size    int64
+	counter int
+}
+
+// NewFileClient creates FileClient writing files of at most maxSize bytes into dir
+func NewFileClient(dir string, maxSize int64) (*FileClient, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &FileClient{dir: dir, maxSize: maxSize}, nil
+}
+
+func openFile(u *url.URL, options SinkOptions) (Sink, error) {
+	return NewFileClient(u.Path, options.MaxFileSize)
+}
+
+// BulkWrite appends the payload to the current file, payloads are never split between files
+func (c *FileClient) BulkWrite(payload *bytes.Buffer) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.file == nil || (c.size > 0 && c.size+int64(payload.Len()) > c.maxSize) {
+		if err := c.rotate(); err != nil {
+			return fmt.Errorf("failed to rotate output file: %v", err)
+		}
+	}
+
+	n, err := c.file.Write(payload.Bytes())
+	c.size += int64(n)
+
+	return err
+}
+
+// IndexWithRetries writes the payload, there is nothing to retry for files
+func (c *FileClient) IndexWithRetries(payload *bytes.Buffer, retryCount int) (retries int, err error) {
+	if err := c.BulkWrite(payload); err != nil {
+		return 0, fmt.Errorf("failed to write bulk payload to file: %v", err)
+	}
+
+	return 0, nil
+}
+
+// Close closes the current file
+func (c *FileClient) Close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.file != nil {
+		c.file.Close()
+		c.file = nil
+	}
+}
+
+func (c *FileClient) rotate() error {
+	if c.file != nil {
+		if err := c.file.Close(); err != nil {
+			return err
+		}
+	}
+
+	c.counter++
+	name := fmt.Sprintf("astrologer-%s-%04d.ndjson", time.Now().Format("20060102-150405"), c.counter)
+
+	file, err := os.Create(filepath.Join(c.dir, name))
+	if err != nil {
+		return err
+	}
+
+	c.file = file
+	c.size = 0
+
+	return nil
+}
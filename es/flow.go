@@ -0,0 +1,219 @@
+package es
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+const flowsPageSize = 1000
+
+// UnlabeledCategory is the category of accounts missing in labels
+const UnlabeledCategory = "unlabeled"
+
+// FlowIntervals maps rollup intervals to ES calendar intervals
+var FlowIntervals = map[string]string{
+	"daily":   "day",
+	"weekly":  "week",
+	"monthly": "month",
+}
+
+// Flow represents volume of the asset sent from one account category to another within the interval
+type Flow struct {
+	ID       string    `json:"id"`
+	AssetID  string    `json:"asset_id"`
+	Interval string    `json:"interval"`
+	Time     time.Time `json:"time"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Amount   float64   `json:"amount"`
+	Count    int       `json:"count"`
+
+	version int64
+}
+
+type flowKey struct {
+	time     int64
+	from, to string
+}
+
+type flowsResponse struct {
+	Aggregations struct {
+		Flows struct {
+			AfterKey map[string]interface{} `json:"after_key"`
+			Buckets  []struct {
+				Key struct {
+					Time int64  `json:"time"`
+					From string `json:"from"`
+					To   string `json:"to"`
+				} `json:"key"`
+				DocCount int   `json:"doc_count"`
+				Amount   value `json:"amount"`
+			} `json:"buckets"`
+		} `json:"flows"`
+	} `json:"aggregations"`
+}
+
+// RollupFlows builds sender category to receiver category volume matrices of the asset payments,
+// labels map account IDs to categories
+func (es *Client) RollupFlows(assetID string, interval string, labels map[string]string) error {
+	var b bytes.Buffer
+
+	calendarInterval, ok := FlowIntervals[interval]
+	if !ok {
+		return fmt.Errorf("unknown flows interval %s", interval)
+	}
+
+	res, err := es.rawClient.Indices.Refresh(es.rawClient.Indices.Refresh.WithIndex(paymentsIndexName.String()))
+	if err := closeResponse(res, err); err != nil {
+		return fmt.Errorf("failed to refresh %s: %v", paymentsIndexName, err)
+	}
+
+	flows, err := es.aggregateFlows(assetID, calendarInterval, labels)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]flowKey, 0, len(flows))
+	for key := range flows {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].time < keys[j].time })
+
+	for _, key := range keys {
+		flow := flows[key]
+		flow.ID = fmt.Sprintf("%s-%s-%d-%s-%s", assetID, interval, flow.Time.Unix(), key.from, key.to)
+		flow.AssetID = assetID
+		flow.Interval = interval
+		flow.version = time.Now().UnixNano()
+
+		if err := SerializeForBulk(flow, &b); err != nil {
+			return err
+		}
+
+		if b.Len() > 5*1024*1024 {
+			if _, err := es.IndexWithRetries(&b, 0); err != nil {
+				return err
+			}
+
+			b.Reset()
+		}
+	}
+
+	if b.Len() > 0 {
+		if _, err := es.IndexWithRetries(&b, 0); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("%d %s flows of %s built", len(flows), interval, assetID)
+
+	return nil
+}
+
+// aggregateFlows sums payments by interval and account pair, then by category pair
+func (es *Client) aggregateFlows(assetID, calendarInterval string, labels map[string]string) (map[flowKey]*Flow, error) {
+	var after map[string]interface{}
+
+	flows := make(map[flowKey]*Flow)
+
+	for {
+		var r flowsResponse
+
+		if err := es.search(paymentsIndexName, flowsQuery(assetID, calendarInterval, after), &r); err != nil {
+			return nil, err
+		}
+
+		buckets := r.Aggregations.Flows.Buckets
+
+		for _, bucket := range buckets {
+			key := flowKey{
+				time: bucket.Key.Time,
+				from: category(labels, bucket.Key.From),
+				to:   category(labels, bucket.Key.To),
+			}
+
+			flow, ok := flows[key]
+			if !ok {
+				flow = &Flow{
+					Time: time.Unix(0, bucket.Key.Time*int64(time.Millisecond)).UTC(),
+					From: key.from,
+					To:   key.to,
+				}
+				flows[key] = flow
+			}
+
+			flow.Amount += bucket.Amount.Value
+			flow.Count += bucket.DocCount
+		}
+
+		if len(buckets) < flowsPageSize || r.Aggregations.Flows.AfterKey == nil {
+			return flows, nil
+		}
+
+		after = r.Aggregations.Flows.AfterKey
+	}
+}
+
+func flowsQuery(assetID, calendarInterval string, after map[string]interface{}) map[string]interface{} {
+	terms := func(field string) map[string]interface{} {
+		return map[string]interface{}{"terms": map[string]interface{}{"field": field}}
+	}
+
+	composite := map[string]interface{}{
+		"size": flowsPageSize,
+		"sources": []map[string]interface{}{
+			{"time": map[string]interface{}{"date_histogram": map[string]interface{}{
+				"field":             "close_time",
+				"calendar_interval": calendarInterval,
+			}}},
+			{"from": terms("from")},
+			{"to": terms("to")},
+		},
+	}
+
+	if after != nil {
+		composite["after"] = after
+	}
+
+	return map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"asset.id": assetID},
+		},
+		"aggs": map[string]interface{}{
+			"flows": map[string]interface{}{
+				"composite": composite,
+				"aggs": map[string]interface{}{
+					"amount": map[string]interface{}{"sum": map[string]interface{}{"field": "amount"}},
+				},
+			},
+		},
+	}
+}
+
+func category(labels map[string]string, accountID string) string {
+	if c, ok := labels[accountID]; ok {
+		return c
+	}
+
+	return UnlabeledCategory
+}
+
+// DocID returns es id
+func (f *Flow) DocID() *string {
+	return &f.ID
+}
+
+// IndexName returns index name
+func (f *Flow) IndexName() IndexName {
+	return flowsIndexName
+}
+
+// Version returns document version, later rollups replace earlier ones
+func (f *Flow) Version() (int64, error) {
+	return f.version, nil
+}
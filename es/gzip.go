@@ -0,0 +1,74 @@
+package es
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	goES "github.com/elastic/go-elasticsearch/v7"
+)
+
+// gzipTransport compresses bodies of bulk requests
+type gzipTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip compresses the body of _bulk requests and sends the request
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var buf bytes.Buffer
+
+	if req.Body == nil || !strings.HasSuffix(req.URL.Path, "/_bulk") {
+		return t.next.RoundTrip(req)
+	}
+
+	plain, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	w := gzip.NewWriter(&buf)
+
+	if _, err := w.Write(plain); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	compressed := buf.Bytes()
+
+	r := *req
+	r.Header = make(http.Header, len(req.Header)+1)
+	for key, values := range req.Header {
+		r.Header[key] = values
+	}
+
+	r.Header.Set("Content-Encoding", "gzip")
+	r.ContentLength = int64(len(compressed))
+	r.Body = ioutil.NopCloser(bytes.NewReader(compressed))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(compressed)), nil
+	}
+
+	return t.next.RoundTrip(&r)
+}
+
+// compress makes the client send compressed bulk requests, must wrap signing transports
+func (o SinkOptions) compress(esCfg *goES.Config) {
+	if !o.Gzip {
+		return
+	}
+
+	next := esCfg.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	esCfg.Transport = &gzipTransport{next: next}
+}
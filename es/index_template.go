@@ -0,0 +1,186 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Templates makes create-index install index templates of append-only indices and bootstrap rollover aliases
+// instead of creating concrete indices, so indices created by rollover get current mappings
+var Templates bool
+
+// LifecyclePolicy is the ILM policy create-index installs and attaches to index templates, nil means none
+var LifecyclePolicy *ILMPolicy
+
+// ILMPolicy represents hot/warm/delete ILM policy of rotated indices, ages and sizes use ElasticSearch units
+type ILMPolicy struct {
+	Name string
+
+	// RolloverSize and RolloverAge start the new index once the write index exceeds either of them
+	RolloverSize string
+	RolloverAge  string
+
+	// WarmAfter moves indices to the warm phase after the rollover, empty skips the phase
+	WarmAfter string
+
+	// DeleteAfter deletes indices after the rollover, empty keeps them forever
+	DeleteAfter string
+}
+
+// body returns the put lifecycle policy request body
+func (p *ILMPolicy) body() (string, error) {
+	rollover := make(map[string]interface{})
+
+	if p.RolloverSize != "" {
+		rollover["max_size"] = p.RolloverSize
+	}
+
+	if p.RolloverAge != "" {
+		rollover["max_age"] = p.RolloverAge
+	}
+
+	phases := map[string]interface{}{
+		"hot": map[string]interface{}{
+			"actions": map[string]interface{}{
+				"rollover":     rollover,
+				"set_priority": map[string]interface{}{"priority": 100},
+			},
+		},
+	}
+
+	if p.WarmAfter != "" {
+		phases["warm"] = map[string]interface{}{
+			"min_age": p.WarmAfter,
+			"actions": map[string]interface{}{
+				"forcemerge":   map[string]interface{}{"max_num_segments": 1},
+				"set_priority": map[string]interface{}{"priority": 50},
+			},
+		}
+	}
+
+	if p.DeleteAfter != "" {
+		phases["delete"] = map[string]interface{}{
+			"min_age": p.DeleteAfter,
+			"actions": map[string]interface{}{"delete": map[string]interface{}{}},
+		}
+	}
+
+	result, err := json.Marshal(map[string]interface{}{"policy": map[string]interface{}{"phases": phases}})
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+// putLifecyclePolicy creates or updates LifecyclePolicy
+func (es *Client) putLifecyclePolicy() error {
+	policy, err := LifecyclePolicy.body()
+	if err != nil {
+		return err
+	}
+
+	if err := closeResponse(es.perform(http.MethodPut, "/_ilm/policy/"+LifecyclePolicy.Name, strings.NewReader(policy))); err != nil {
+		return fmt.Errorf("failed to put %s lifecycle policy: %v", LifecyclePolicy.Name, err)
+	}
+
+	log.Printf("%s lifecycle policy installed", LifecyclePolicy.Name)
+
+	return nil
+}
+
+// isTemplated checks if create-index installs the template of the index instead of creating it
+func isTemplated(name IndexName) bool {
+	return Templates && appendOnlyIndices[name] && !DataStreams[name]
+}
+
+// refreshTemplate puts the index template and bootstraps the first index behind the rollover alias if missing
+func (es *Client) refreshTemplate(name IndexName, schema IndexDefinition, force bool) error {
+	exists, err := es.IndexExists(name)
+	if err != nil {
+		return err
+	}
+
+	if force && exists {
+		if err := closeResponse(es.rawClient.Indices.Delete([]string{name.String() + "-*"})); err != nil {
+			return fmt.Errorf("failed to delete %s indices: %v", name, err)
+		}
+
+		// The name belongs to the concrete index created without templates
+		if exists, err = es.IndexExists(name); err != nil {
+			return err
+		}
+
+		if exists {
+			if err := es.DeleteIndex(name); err != nil {
+				return err
+			}
+		}
+
+		log.Printf("%s indices deleted", name)
+		exists = false
+	}
+
+	template, err := indexTemplate(name, schema)
+	if err != nil {
+		return err
+	}
+
+	if err := closeResponse(es.perform(http.MethodPut, "/_index_template/"+name.String(), strings.NewReader(template))); err != nil {
+		return fmt.Errorf("failed to put %s index template: %v", name, err)
+	}
+
+	if exists {
+		log.Printf("%s index template updated, mappings apply after the next rollover", name)
+		return nil
+	}
+
+	first := name.String() + "-000001"
+	body := `{"aliases": {"` + name.String() + `": {"is_write_index": true}}}`
+
+	if err := closeResponse(es.rawClient.Indices.Create(first, es.rawClient.Indices.Create.WithBody(strings.NewReader(body)))); err != nil {
+		return fmt.Errorf("failed to create %s: %v", first, err)
+	}
+
+	log.Printf("%s index template and %s index created!", name, first)
+
+	return nil
+}
+
+// indexTemplate wraps the index definition into composable index template matching indices behind the rollover alias
+func indexTemplate(name IndexName, schema IndexDefinition) (string, error) {
+	var template map[string]interface{}
+
+	body, err := withSchemaVersion(name, schema)
+	if err != nil {
+		return "", err
+	}
+
+	if err := json.Unmarshal([]byte(body), &template); err != nil {
+		return "", err
+	}
+
+	if LifecyclePolicy != nil {
+		settings, _ := template["settings"].(map[string]interface{})
+		if settings == nil {
+			settings = make(map[string]interface{})
+			template["settings"] = settings
+		}
+
+		settings["index.lifecycle.name"] = LifecyclePolicy.Name
+		settings["index.lifecycle.rollover_alias"] = name.String()
+	}
+
+	result, err := json.Marshal(map[string]interface{}{
+		"index_patterns": []string{name.String() + "-*"},
+		"template":       template,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
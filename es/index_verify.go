@@ -0,0 +1,151 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IndexDiff represents differences between the live index and its definition
+type IndexDiff struct {
+	// MissingFields are fields absent from the live mapping, they can be added safely
+	MissingFields map[string]interface{}
+
+	// Conflicts are differences which can only be fixed by index recreation
+	Conflicts []string
+}
+
+// IsEmpty returns true if the live index matches the definition
+func (d *IndexDiff) IsEmpty() bool {
+	return len(d.MissingFields) == 0 && len(d.Conflicts) == 0
+}
+
+type indexBody struct {
+	Settings map[string]interface{} `json:"settings"`
+	Mappings struct {
+		Properties map[string]interface{} `json:"properties"`
+	} `json:"mappings"`
+}
+
+// VerifyIndex compares the live index mappings and settings with the definition
+func (es *Client) VerifyIndex(name IndexName, body IndexDefinition) (*IndexDiff, error) {
+	var def indexBody
+	var live map[string]indexBody
+	var liveSettings map[string]indexBody
+
+	if err := json.Unmarshal([]byte(body), &def); err != nil {
+		return nil, fmt.Errorf("invalid %s index definition: %v", name, err)
+	}
+
+	res, err := es.rawClient.Indices.GetMapping(es.rawClient.Indices.GetMapping.WithIndex(name.String()))
+	if err := responseError(res, err); err != nil {
+		return nil, fmt.Errorf("failed to get %s mapping: %v", name, err)
+	}
+
+	if err := decodeBody(res.Body, &live); err != nil {
+		return nil, err
+	}
+
+	res, err = es.rawClient.Indices.GetSettings(es.rawClient.Indices.GetSettings.WithIndex(name.String()))
+	if err := responseError(res, err); err != nil {
+		return nil, fmt.Errorf("failed to get %s settings: %v", name, err)
+	}
+
+	if err := decodeBody(res.Body, &liveSettings); err != nil {
+		return nil, err
+	}
+
+	diff := &IndexDiff{MissingFields: make(map[string]interface{})}
+
+	// Responses are keyed by the concrete index name, which differs from the alias of reindexed indices
+	for _, index := range live {
+		diffProperties(def.Mappings.Properties, index.Mappings.Properties, "", diff)
+	}
+
+	for _, index := range liveSettings {
+		diffSettings(def.Settings, index.Settings, diff)
+	}
+
+	sort.Strings(diff.Conflicts)
+
+	return diff, nil
+}
+
+// PutMissingFields adds fields absent from the live index mapping
+func (es *Client) PutMissingFields(name IndexName, fields map[string]interface{}) error {
+	var buf strings.Builder
+
+	body := map[string]interface{}{"properties": fields}
+
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return err
+	}
+
+	res, err := es.rawClient.Indices.PutMapping(
+		strings.NewReader(buf.String()),
+		es.rawClient.Indices.PutMapping.WithIndex(name.String()),
+	)
+	if err := responseError(res, err); err != nil {
+		return fmt.Errorf("failed to add %s fields: %v", name, err)
+	}
+
+	res.Body.Close()
+
+	return nil
+}
+
+func diffProperties(def map[string]interface{}, live map[string]interface{}, prefix string, diff *IndexDiff) {
+	for field, d := range def {
+		path := prefix + field
+		l, ok := live[field]
+
+		if !ok {
+			if prefix == "" {
+				diff.MissingFields[field] = d
+			} else {
+				diff.Conflicts = append(diff.Conflicts, fmt.Sprintf("nested field %s is missing", path))
+			}
+			continue
+		}
+
+		defField, _ := d.(map[string]interface{})
+		liveField, _ := l.(map[string]interface{})
+
+		if defField["type"] != nil && defField["type"] != liveField["type"] {
+			diff.Conflicts = append(
+				diff.Conflicts,
+				fmt.Sprintf("field %s has type %v, expected %v", path, liveField["type"], defField["type"]),
+			)
+		}
+
+		if defProps, ok := defField["properties"].(map[string]interface{}); ok {
+			liveProps, _ := liveField["properties"].(map[string]interface{})
+			diffProperties(defProps, liveProps, path+".", diff)
+		}
+	}
+}
+
+func diffSettings(def map[string]interface{}, live map[string]interface{}, diff *IndexDiff) {
+	defIndex, _ := def["index"].(map[string]interface{})
+	liveIndex, _ := live["index"].(map[string]interface{})
+	liveSort, _ := liveIndex["sort"].(map[string]interface{})
+
+	expected := map[string]interface{}{
+		"number_of_shards": liveIndex["number_of_shards"],
+		"sort.field":       liveSort["field"],
+		"sort.order":       liveSort["order"],
+	}
+
+	for key, value := range expected {
+		d, ok := defIndex[key]
+
+		if !ok {
+			continue
+		}
+
+		if fmt.Sprint(d) != fmt.Sprint(value) {
+			diff.Conflicts = append(diff.Conflicts, fmt.Sprintf("setting %s is %v, expected %v", key, value, d))
+		}
+	}
+}
@@ -7,16 +7,29 @@ type IndexName string
 type IndexDefinition string
 
 const (
-	ledgerHeaderIndexName  IndexName = "ledger"
-	txIndexName            IndexName = "tx"
-	opIndexName            IndexName = "op"
-	balanceIndexName       IndexName = "balance"
-	tradesIndexName        IndexName = "trades"
-	signerHistoryIndexName IndexName = "signers"
+	ledgerHeaderIndexName      IndexName = "ledger"
+	txIndexName                IndexName = "tx"
+	opIndexName                IndexName = "op"
+	balanceIndexName           IndexName = "balance"
+	tradesIndexName            IndexName = "trades"
+	signerHistoryIndexName     IndexName = "signers"
+	effectsIndexName           IndexName = "effects"
+	changesIndexName           IndexName = "changes"
+	offersIndexName            IndexName = "offers"
+	trustLinesIndexName        IndexName = "trustlines"
+	accountsIndexName          IndexName = "accounts"
+	dataEntriesIndexName       IndexName = "data_entries"
+	auditIndexName             IndexName = "audit"
+	statsIndexName             IndexName = "stats"
+	paymentsIndexName          IndexName = "payments"
+	tradeAggregationsIndexName IndexName = "trade_aggregations"
+	exportSummariesIndexName   IndexName = "export_summaries"
+	flowsIndexName             IndexName = "flows"
+	accountFiltersIndexName    IndexName = "account_filters"
 )
 
 // GetIndexDefinitions returns ElasticSearch index definitions for Astrologer indices
-func GetIndexDefinitions() map[IndexName]IndexDefinition {
+func GetIndexDefinitions() (map[IndexName]IndexDefinition, error) {
 	m := make(map[IndexName]IndexDefinition)
 
 	m[ledgerHeaderIndexName] = `
@@ -38,12 +51,21 @@ func GetIndexDefinitions() map[IndexName]IndexDefinition {
               "paging_token": { "type": "keyword", "index": true },
               "close_time": { "type": "date" },
               "version": { "type": "long" },
+              "protocol_version": { "type": "integer" },
               "total_coins": { "type": "long" },
               "fee_pool": { "type": "long" },
+              "inflation_seq": { "type": "long" },
               "id_pool": { "type": "long" },
               "base_fee": { "type": "long" },
               "base_reserve": { "type": "long" },
-              "max_tx_set_size": { "type": "long" }
+              "max_tx_set_size": { "type": "long" },
+              "upgrades": {
+                "properties": {
+                  "type": { "type": "integer" },
+                  "type_name": { "type": "keyword", "index": true },
+                  "value": { "type": "long" }
+                }
+              }
             }
           }
         }
@@ -62,16 +84,29 @@ func GetIndexDefinitions() map[IndexName]IndexDefinition {
 			"properties": {
 				"id": { "type": "keyword", "index": true },
 				"idx": { "type": "integer" },
+				"apply_index": { "type": "integer" },
 				"seq": { "type": "long" },
 				"paging_token": { "type": "keyword", "index": true },
 				"max_fee": { "type": "long" },
 				"fee_charged": { "type": "long" },
         "fee_account_id": { "type": "keyword", "index": true },
 				"operation_count": { "type": "integer" },
+				"envelope_size": { "type": "integer" },
 				"close_time": { "type": "date" },
 				"successful": { "type": "boolean" },
 				"result_code": { "type": "integer" },
 				"source_account_id": { "type": "keyword", "index": true },
+				"inner_tx_id": { "type": "keyword", "index": true },
+				"inner_max_fee": { "type": "long" },
+				"inner_result_code": { "type": "integer" },
+				"signatures": {
+					"properties": {
+						"hint": { "type": "keyword" },
+						"signature": { "type": "keyword", "index": false },
+						"signer": { "type": "keyword", "index": true },
+						"inner": { "type": "boolean" }
+					}
+				},
 				"time_bounds": {
 					"properties": {
 						"min_time": { "type": "long" },
@@ -81,7 +116,8 @@ func GetIndexDefinitions() map[IndexName]IndexDefinition {
 				"memo": {
 					"properties": {
 						"type": { "type": "byte" },
-						"value": { "type": "keyword" }
+						"value": { "type": "keyword" },
+						"bytes": { "type": "keyword", "index": false }
 					}
 				}
 			}
@@ -110,10 +146,12 @@ func GetIndexDefinitions() map[IndexName]IndexDefinition {
 				"result_code": { "type": "integer" },
 				"inner_result_code": { "type": "integer" },
 				"tx_source_account_id": { "type": "keyword", "index": true },
+				"memo_required": { "type": "boolean" },
 				"memo": {
 					"properties": {
 						"type": { "type": "byte" },
-						"value": { "type": "keyword" }
+						"value": { "type": "keyword" },
+						"bytes": { "type": "keyword", "index": false }
 					}
 				},
 				"type": { "type": "keyword", "index": true },
@@ -122,7 +160,8 @@ func GetIndexDefinitions() map[IndexName]IndexDefinition {
 					"properties": {
 						"id": { "type": "keyword" },
 						"code": { "type": "keyword" },
-						"issuer": { "type": "keyword" }
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
 					}
 				},
 				"source_amount": { "type": "scaled_float", "scaling_factor": 10000000 },
@@ -131,7 +170,8 @@ func GetIndexDefinitions() map[IndexName]IndexDefinition {
 					"properties": {
 						"id": { "type": "keyword" },
 						"code": { "type": "keyword" },
-						"issuer": { "type": "keyword" }
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
 					}
 				},
 				"destination_amount": { "type": "scaled_float", "scaling_factor": 10000000 },
@@ -150,9 +190,23 @@ func GetIndexDefinitions() map[IndexName]IndexDefinition {
 					"properties": {
 						"id": { "type": "keyword" },
 						"code": { "type": "keyword" },
-						"issuer": { "type": "keyword" }
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
 					}
 				},
+				"source_max": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"destination_min": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"amount_sent": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"amount_received": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"result_path": {
+					"properties": {
+						"id": { "type": "keyword" },
+						"code": { "type": "keyword" },
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
+					}
+				},
+				"result_crossed_offer_ids": { "type": "long" },
 				"thresholds": {
 					"properties": {
 						"low": { "type": "integer" },
@@ -181,7 +235,8 @@ func GetIndexDefinitions() map[IndexName]IndexDefinition {
 					"properties": {
 						"id": { "type": "keyword" },
 						"weight": { "type": "integer" },
-						"type": { "type": "byte" }
+						"type": { "type": "byte" },
+						"type_name": { "type": "keyword" }
 					}
 				},
 				"data": {
@@ -205,14 +260,16 @@ func GetIndexDefinitions() map[IndexName]IndexDefinition {
 							"properties": {
 								"id": { "type": "keyword" },
 								"code": { "type": "keyword" },
-								"issuer": { "type": "keyword" }
+								"issuer": { "type": "keyword" },
+								"legacy_id": { "type": "keyword" }
 							}
 						},
 						"buying": {
 							"properties": {
 								"id": { "type": "keyword" },
 								"code": { "type": "keyword" },
-								"issuer": { "type": "keyword" }
+								"issuer": { "type": "keyword" },
+								"legacy_id": { "type": "keyword" }
 							}
 						},
 						"offer_id": { "type": "long" },
@@ -247,9 +304,22 @@ func GetIndexDefinitions() map[IndexName]IndexDefinition {
 					"properties": {
 						"id": { "type": "keyword" },
 						"code": { "type": "keyword" },
-						"issuer": { "type": "keyword" }
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
 					}
-				}
+				},
+				"buying_liabilities": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"selling_liabilities": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"flags": {
+					"properties": {
+						"required": { "type": "boolean" },
+						"revocable": { "type": "boolean" },
+						"immutable": { "type": "boolean" }
+					}
+				},
+				"authorized": { "type": "boolean" },
+				"authorized_to_maintain_liabilities": { "type": "boolean" },
+				"removed": { "type": "boolean" }
 			}
 		}
 	}
@@ -274,21 +344,44 @@ func GetIndexDefinitions() map[IndexName]IndexDefinition {
 					"properties": {
 						"id": { "type": "keyword" },
 						"code": { "type": "keyword" },
-						"issuer": { "type": "keyword" }
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
 					}
 				},
 				"asset_bought": {
 					"properties": {
 						"id": { "type": "keyword" },
 						"code": { "type": "keyword" },
-						"issuer": { "type": "keyword" }
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
 					}
 				},
-				"offer_id": { "type": "long" },
+				"sold_offer_id": { "type": "long" },
 				"seller_id": { "type": "keyword", "index": true },
 				"buyer_id": { "type": "keyword", "index": true },
 				"price": { "type": "scaled_float", "scaling_factor": 10000000 },
-				"time": { "type": "date" }
+				"ledger_close_time": { "type": "date" },
+				"base_asset": {
+					"properties": {
+						"id": { "type": "keyword" },
+						"code": { "type": "keyword" },
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
+					}
+				},
+				"base_amount": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"base_account_id": { "type": "keyword", "index": true },
+				"counter_asset": {
+					"properties": {
+						"id": { "type": "keyword" },
+						"code": { "type": "keyword" },
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
+					}
+				},
+				"counter_amount": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"counter_account_id": { "type": "keyword", "index": true },
+				"base_price": { "type": "scaled_float", "scaling_factor": 10000000 }
 			}
 		}
 	}
@@ -310,7 +403,9 @@ func GetIndexDefinitions() map[IndexName]IndexDefinition {
 				"account_id": { "type": "keyword", "index": true },
 				"signer": { "type": "keyword", "index": true },
 				"type": { "type": "byte" },
+				"type_name": { "type": "keyword" },
 				"weight": { "type": "integer" },
+				"removed": { "type": "boolean" },
 				"seq": { "type": "integer" },
 				"tx_idx": { "type": "integer" },
 				"idx": { "type": "integer" },
@@ -320,5 +415,491 @@ func GetIndexDefinitions() map[IndexName]IndexDefinition {
 	}
 `
 
-	return m
+	m[effectsIndexName] = `
+	{
+		"settings": {
+			"index" : {
+				"sort.field" : "paging_token",
+				"sort.order" : "desc",
+				"number_of_shards" : 4
+			}
+		},
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword", "index": true },
+				"paging_token": { "type": "keyword", "index": true },
+				"type": { "type": "keyword", "index": true },
+				"account_id": { "type": "keyword", "index": true },
+				"op_type": { "type": "keyword", "index": true },
+				"tx_id": { "type": "keyword", "index": true },
+				"seq": { "type": "long" },
+				"tx_idx": { "type": "integer" },
+				"op_idx": { "type": "integer" },
+				"ledger_close_time": { "type": "date" },
+				"amount": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"asset": {
+					"properties": {
+						"id": { "type": "keyword" },
+						"code": { "type": "keyword" },
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
+					}
+				},
+				"limit": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"signer": {
+					"properties": {
+						"id": { "type": "keyword" },
+						"weight": { "type": "integer" },
+						"type": { "type": "byte" },
+						"type_name": { "type": "keyword" }
+					}
+				},
+				"thresholds": {
+					"properties": {
+						"low": { "type": "integer" },
+						"medium": { "type": "integer" },
+						"high": { "type": "integer" },
+						"master": { "type": "integer" }
+					}
+				},
+				"home_domain": { "type": "keyword" },
+				"set_flags": {
+					"properties": {
+						"required": { "type": "boolean" },
+						"revocable": { "type": "boolean" },
+						"immutable": { "type": "boolean" }
+					}
+				},
+				"clear_flags": {
+					"properties": {
+						"required": { "type": "boolean" },
+						"revocable": { "type": "boolean" },
+						"immutable": { "type": "boolean" }
+					}
+				},
+				"inflation_dest_id": { "type": "keyword" },
+				"data": {
+					"properties": {
+						"name": { "type": "keyword" },
+						"value": { "type": "keyword" }
+					}
+				},
+				"bump_to": { "type": "long" },
+				"sold_amount": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"sold_asset": {
+					"properties": {
+						"id": { "type": "keyword" },
+						"code": { "type": "keyword" },
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
+					}
+				},
+				"bought_amount": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"bought_asset": {
+					"properties": {
+						"id": { "type": "keyword" },
+						"code": { "type": "keyword" },
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
+					}
+				},
+				"counterparty_id": { "type": "keyword", "index": true },
+				"offer_id": { "type": "long" }
+			}
+		}
+	}
+`
+
+	m[changesIndexName] = `
+	{
+		"settings": {
+			"index" : {
+				"sort.field" : "paging_token",
+				"sort.order" : "desc",
+				"number_of_shards" : 4
+			}
+		},
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword", "index": true },
+				"paging_token": { "type": "keyword", "index": true },
+				"type": { "type": "keyword", "index": true },
+				"entry_type": { "type": "keyword", "index": true },
+				"source": { "type": "keyword" },
+				"tx_id": { "type": "keyword", "index": true },
+				"seq": { "type": "long" },
+				"tx_idx": { "type": "integer" },
+				"op_idx": { "type": "integer" },
+				"ledger_close_time": { "type": "date" },
+				"key": { "type": "keyword", "index": true },
+				"before": { "type": "keyword", "index": false },
+				"after": { "type": "keyword", "index": false },
+				"deleted": { "type": "boolean" },
+				"account_id": { "type": "keyword", "index": true },
+				"asset": {
+					"properties": {
+						"id": { "type": "keyword" },
+						"code": { "type": "keyword" },
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
+					}
+				},
+				"offer_id": { "type": "long" },
+				"data_name": { "type": "keyword" }
+			}
+		}
+	}
+`
+
+	m[offersIndexName] = `
+	{
+		"settings": {
+			"index" : {
+				"number_of_shards" : 1
+			}
+		},
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword", "index": true },
+				"paging_token": { "type": "keyword", "index": true },
+				"offer_id": { "type": "long" },
+				"seller_id": { "type": "keyword", "index": true },
+				"selling": {
+					"properties": {
+						"id": { "type": "keyword" },
+						"code": { "type": "keyword" },
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
+					}
+				},
+				"buying": {
+					"properties": {
+						"id": { "type": "keyword" },
+						"code": { "type": "keyword" },
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
+					}
+				},
+				"amount": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"price": { "type": "double" },
+				"price_n_d": {
+					"properties": {
+						"n": { "type": "integer" },
+						"d": { "type": "integer" }
+					}
+				},
+				"passive": { "type": "boolean" },
+				"last_modified_ledger": { "type": "long" },
+				"ledger_close_time": { "type": "date" },
+				"deleted": { "type": "boolean" }
+			}
+		}
+	}
+`
+
+	m[trustLinesIndexName] = `
+	{
+		"settings": {
+			"index" : {
+				"number_of_shards" : 2
+			}
+		},
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword", "index": true },
+				"paging_token": { "type": "keyword", "index": true },
+				"account_id": { "type": "keyword", "index": true },
+				"asset": {
+					"properties": {
+						"id": { "type": "keyword" },
+						"code": { "type": "keyword" },
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
+					}
+				},
+				"limit": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"balance": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"buying_liabilities": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"selling_liabilities": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"authorized": { "type": "boolean" },
+				"authorized_to_maintain_liabilities": { "type": "boolean" },
+				"last_modified_ledger": { "type": "long" },
+				"ledger_close_time": { "type": "date" },
+				"deleted": { "type": "boolean" }
+			}
+		}
+	}
+`
+
+	m[accountsIndexName] = `
+	{
+		"settings": {
+			"index" : {
+				"number_of_shards" : 2
+			}
+		},
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword", "index": true },
+				"paging_token": { "type": "keyword", "index": true },
+				"balance": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"buying_liabilities": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"selling_liabilities": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"seq_num": { "type": "long" },
+				"num_sub_entries": { "type": "integer" },
+				"inflation_dest_id": { "type": "keyword" },
+				"home_domain": { "type": "keyword" },
+				"thresholds": {
+					"properties": {
+						"low": { "type": "integer" },
+						"medium": { "type": "integer" },
+						"high": { "type": "integer" },
+						"master": { "type": "integer" }
+					}
+				},
+				"flags": {
+					"properties": {
+						"required": { "type": "boolean" },
+						"revocable": { "type": "boolean" },
+						"immutable": { "type": "boolean" }
+					}
+				},
+				"signers": {
+					"properties": {
+						"id": { "type": "keyword" },
+						"weight": { "type": "integer" },
+						"type": { "type": "byte" },
+						"type_name": { "type": "keyword" }
+					}
+				},
+				"last_modified_ledger": { "type": "long" },
+				"ledger_close_time": { "type": "date" },
+				"deleted": { "type": "boolean" }
+			}
+		}
+	}
+`
+
+	m[dataEntriesIndexName] = `
+	{
+		"settings": {
+			"index" : {
+				"number_of_shards" : 1
+			}
+		},
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword", "index": true },
+				"paging_token": { "type": "keyword", "index": true },
+				"account_id": { "type": "keyword", "index": true },
+				"name": { "type": "keyword", "index": true },
+				"value": { "type": "keyword" },
+				"last_modified_ledger": { "type": "long" },
+				"ledger_close_time": { "type": "date" },
+				"deleted": { "type": "boolean" }
+			}
+		}
+	}
+`
+
+	m[auditIndexName] = `
+	{
+		"settings": {
+			"index" : {
+				"number_of_shards" : 1
+			}
+		},
+		"mappings": {
+			"properties": {
+				"first": { "type": "long" },
+				"last": { "type": "long" },
+				"batch_hash": { "type": "keyword", "index": true },
+				"prev_hash": { "type": "keyword", "index": true },
+				"hash": { "type": "keyword", "index": true },
+				"created_at": { "type": "date" }
+			}
+		}
+	}
+`
+
+	m[flowsIndexName] = `
+	{
+		"settings": {
+			"index" : {
+				"number_of_shards" : 1
+			}
+		},
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword", "index": true },
+				"asset_id": { "type": "keyword", "index": true },
+				"interval": { "type": "keyword", "index": true },
+				"time": { "type": "date" },
+				"from": { "type": "keyword", "index": true },
+				"to": { "type": "keyword", "index": true },
+				"amount": { "type": "double" },
+				"count": { "type": "long" }
+			}
+		}
+	}
+`
+
+	m[accountFiltersIndexName] = `
+	{
+		"settings": {
+			"index" : {
+				"number_of_shards" : 1
+			}
+		},
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword", "index": true },
+				"first": { "type": "long" },
+				"last": { "type": "long" },
+				"accounts": { "type": "integer" },
+				"filter": { "type": "object", "enabled": false }
+			}
+		}
+	}
+`
+
+	m[exportSummariesIndexName] = `
+	{
+		"settings": {
+			"index" : {
+				"number_of_shards" : 1
+			}
+		},
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword", "index": true },
+				"ranges": { "type": "keyword", "index": true },
+				"ledgers": { "type": "long" },
+				"transactions": { "type": "long" },
+				"operations": { "type": "long" },
+				"balances": { "type": "long" },
+				"bytes": { "type": "long" },
+				"retries": { "type": "integer" },
+				"started_at": { "type": "date" },
+				"finished_at": { "type": "date" },
+				"elapsed": { "type": "double" },
+				"rate": { "type": "double" }
+			}
+		}
+	}
+`
+
+	m[statsIndexName] = `
+	{
+		"settings": {
+			"index" : {
+				"number_of_shards" : 1
+			}
+		},
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword", "index": true },
+				"period": { "type": "keyword", "index": true },
+				"seq": { "type": "long" },
+				"time": { "type": "date" },
+				"ledger_count": { "type": "integer" },
+				"tx_count": { "type": "integer" },
+				"failed_tx_count": { "type": "integer" },
+				"op_count": { "type": "integer" },
+				"fee_charged": { "type": "long" },
+				"unique_accounts": { "type": "integer" },
+				"accounts": { "type": "keyword", "index": true }
+			}
+		}
+	}
+`
+
+	m[paymentsIndexName] = `
+	{
+		"settings": {
+			"index" : {
+				"sort.field" : "paging_token",
+				"sort.order" : "desc",
+				"number_of_shards" : 4
+			}
+		},
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword", "index": true },
+				"paging_token": { "type": "keyword", "index": true },
+				"tx_id": { "type": "keyword", "index": true },
+				"seq": { "type": "long" },
+				"close_time": { "type": "date" },
+				"type": { "type": "keyword", "index": true },
+				"from": { "type": "keyword", "index": true },
+				"to": { "type": "keyword", "index": true },
+				"asset": {
+					"properties": {
+						"id": { "type": "keyword" },
+						"code": { "type": "keyword" },
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
+					}
+				},
+				"amount": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"source_asset": {
+					"properties": {
+						"id": { "type": "keyword" },
+						"code": { "type": "keyword" },
+						"issuer": { "type": "keyword" },
+						"legacy_id": { "type": "keyword" }
+					}
+				},
+				"source_amount": { "type": "scaled_float", "scaling_factor": 10000000 },
+				"memo_required": { "type": "boolean" },
+				"memo": {
+					"properties": {
+						"type": { "type": "byte" },
+						"value": { "type": "keyword" },
+						"bytes": { "type": "keyword", "index": false }
+					}
+				}
+			}
+		}
+	}
+`
+
+	m[tradeAggregationsIndexName] = `
+	{
+		"settings": {
+			"index" : {
+				"number_of_shards" : 1
+			}
+		},
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword", "index": true },
+				"resolution": { "type": "keyword", "index": true },
+				"timestamp": { "type": "date" },
+				"base_asset_id": { "type": "keyword", "index": true },
+				"counter_asset_id": { "type": "keyword", "index": true },
+				"open": { "type": "double" },
+				"high": { "type": "double" },
+				"low": { "type": "double" },
+				"close": { "type": "double" },
+				"base_volume": { "type": "double" },
+				"counter_volume": { "type": "double" },
+				"trade_count": { "type": "integer" }
+			}
+		}
+	}
+`
+
+	for name, def := range m {
+		withNetwork, err := withNetworkMapping(name, def)
+		if err != nil {
+			return nil, err
+		}
+
+		if m[name], err = withSettingsOverrides(name, withNetwork); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
 }
@@ -0,0 +1,165 @@
+package es
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// pagingTokenKeyed lists indices which use paging token as document ID
+var pagingTokenKeyed = map[IndexName]bool{
+	ledgerHeaderIndexName:  true,
+	opIndexName:            true,
+	balanceIndexName:       true,
+	tradesIndexName:        true,
+	signerHistoryIndexName: true,
+}
+
+// KafkaClient publishes documents to Kafka instead of ElasticSearch, one topic per index.
+// Message key is the document ID, message value is the document itself.
+type KafkaClient struct {
+	writeOnlySink
+
+	producer    sarama.SyncProducer
+	topicPrefix string
+	format      string
+}
+
+// NewKafkaClient connects to Kafka brokers, topic names are topicPrefix followed by index name.
+// Messages are encoded in the given format, json or msgpack.
+func NewKafkaClient(brokers []string, topicPrefix string, format string) (*KafkaClient, error) {
+	config := sarama.NewConfig()
+	config.ClientID = "astrologer"
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+
+	producer, err := sarama.NewSyncProducer(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KafkaClient{producer: producer, topicPrefix: topicPrefix, format: format}, nil
+}
+
+func openKafka(u *url.URL, options SinkOptions) (Sink, error) {
+	return NewKafkaClient(kafkaBrokers(u), strings.TrimPrefix(u.Path, "/"), options.KafkaFormat)
+}
+
+// kafkaBrokers returns URL host followed by additional brokers from ?brokers=host:port,host:port
+func kafkaBrokers(u *url.URL) []string {
+	brokers := []string{u.Host}
+
+	if extra := u.Query().Get("brokers"); extra != "" {
+		brokers = append(brokers, strings.Split(extra, ",")...)
+	}
+
+	return brokers
+}
+
+// BulkWrite publishes every document of the bulk payload
+func (c *KafkaClient) BulkWrite(payload *bytes.Buffer) error {
+	messages, err := c.messages(payload.Bytes())
+	if err != nil {
+		return fmt.Errorf("invalid bulk payload: %v", err)
+	}
+
+	return c.producer.SendMessages(messages)
+}
+
+// IndexWithRetries publishes the payload, retries in case of failure, zero retryCount means retry forever
+func (c *KafkaClient) IndexWithRetries(payload *bytes.Buffer, retryCount int) (retries int, err error) {
+	for ; ; retries++ {
+		err := c.BulkWrite(payload)
+		if err == nil {
+			break
+		}
+
+		if retryCount > 0 && retries >= retryCount {
+			return retries, fmt.Errorf("retries for bulk failed: %v", err)
+		}
+
+		delay := time.Duration(retries*retries) * time.Second
+		log.Println("Failed to publish:", err, "retrying in", delay)
+		time.Sleep(delay)
+	}
+
+	return retries, nil
+}
+
+// Close flushes and closes the producer
+func (c *KafkaClient) Close() {
+	if err := c.producer.Close(); err != nil {
+		log.Println("Failed to close Kafka producer:", err)
+	}
+}
+
+// messages converts meta/document line pairs of the bulk payload to Kafka messages
+func (c *KafkaClient) messages(payload []byte) ([]*sarama.ProducerMessage, error) {
+	var messages []*sarama.ProducerMessage
+	var m bulkMeta
+
+	scanner := bufio.NewScanner(bytes.NewReader(payload))
+	scanner.Buffer(make([]byte, 64*1024), len(payload)+1)
+
+	for meta := true; scanner.Scan(); meta = !meta {
+		if meta {
+			m = bulkMeta{}
+
+			if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		doc := append([]byte(nil), scanner.Bytes()...)
+
+		key, err := documentKey(m, doc)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.format == "msgpack" {
+			if doc, err = JSONToMsgpack(doc); err != nil {
+				return nil, err
+			}
+		}
+
+		messages = append(messages, &sarama.ProducerMessage{
+			Topic: c.topicPrefix + m.Index.Index.String(),
+			Key:   sarama.StringEncoder(key),
+			Value: sarama.ByteEncoder(doc),
+		})
+	}
+
+	return messages, scanner.Err()
+}
+
+// documentKey returns DocID of the serialized document
+func documentKey(m bulkMeta, doc []byte) (string, error) {
+	if m.Index.ID != "" {
+		return m.Index.ID, nil
+	}
+
+	var fields struct {
+		ID          string `json:"id"`
+		PagingToken string `json:"paging_token"`
+	}
+
+	if err := json.Unmarshal(doc, &fields); err != nil {
+		return "", err
+	}
+
+	if pagingTokenKeyed[m.Index.Index] {
+		return fields.PagingToken, nil
+	}
+
+	return fields.ID, nil
+}
@@ -0,0 +1,188 @@
+package es
+
+import (
+	"strings"
+	"time"
+
+	"github.com/stellar/go/xdr"
+)
+
+// ChangeSource represents the origin of the ledger entry change
+type ChangeSource string
+
+const (
+	// ChangeSourceFee marks changes from txfeehistory
+	ChangeSourceFee ChangeSource = "fee"
+
+	// ChangeSourceTx marks transaction level changes from meta (sequence number bumps)
+	ChangeSourceTx ChangeSource = "tx"
+
+	// ChangeSourceOp marks operation changes from meta
+	ChangeSourceOp ChangeSource = "op"
+)
+
+// LedgerEntryChange represents normalized ledger entry change
+type LedgerEntryChange struct {
+	ID              string       `json:"id"`
+	PagingToken     PagingToken  `json:"paging_token"`
+	Type            string       `json:"type"`
+	EntryType       string       `json:"entry_type"`
+	Source          ChangeSource `json:"source"`
+	TxID            string       `json:"tx_id"`
+	Seq             int          `json:"seq"`
+	TxIndex         int          `json:"tx_idx"`
+	OpIndex         int          `json:"op_idx,omitempty"`
+	LedgerCloseTime time.Time    `json:"ledger_close_time"`
+
+	Key     string `json:"key"`
+	Before  string `json:"before,omitempty"`
+	After   string `json:"after,omitempty"`
+	Deleted bool   `json:"deleted"`
+
+	AccountID string `json:"account_id,omitempty"`
+	Asset     *Asset `json:"asset,omitempty"`
+	OfferID   int64  `json:"offer_id,omitempty"`
+	DataName  string `json:"data_name,omitempty"`
+}
+
+// changeExtractor pairs entry states with following updates and removals
+type changeExtractor struct {
+	transaction *Transaction
+	operation   *Operation
+	source      ChangeSource
+	pagingToken PagingToken
+	index       int
+
+	states  map[string]string
+	changes []*LedgerEntryChange
+}
+
+// ProduceLedgerEntryChanges returns normalized changes, startIndex is the last effect index used within the paging token
+func ProduceLedgerEntryChanges(changes xdr.LedgerEntryChanges, transaction *Transaction, operation *Operation, source ChangeSource, startIndex int) ([]*LedgerEntryChange, error) {
+	e := &changeExtractor{
+		transaction: transaction,
+		operation:   operation,
+		source:      source,
+		pagingToken: transaction.PagingToken,
+		index:       startIndex,
+		states:      make(map[string]string),
+	}
+
+	if operation != nil {
+		e.pagingToken = operation.PagingToken
+	}
+
+	return e.extract(changes)
+}
+
+func (e *changeExtractor) extract(changes xdr.LedgerEntryChanges) ([]*LedgerEntryChange, error) {
+	for _, change := range changes {
+		var err error
+
+		switch change.Type {
+		case xdr.LedgerEntryChangeTypeLedgerEntryState:
+			entry := change.MustState()
+			err = e.state(entry.LedgerKey(), entry)
+		case xdr.LedgerEntryChangeTypeLedgerEntryCreated:
+			entry := change.MustCreated()
+			err = e.add(change.Type, entry.LedgerKey(), &entry)
+		case xdr.LedgerEntryChangeTypeLedgerEntryUpdated:
+			entry := change.MustUpdated()
+			err = e.add(change.Type, entry.LedgerKey(), &entry)
+		case xdr.LedgerEntryChangeTypeLedgerEntryRemoved:
+			err = e.add(change.Type, change.MustRemoved(), nil)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return e.changes, nil
+}
+
+// state remembers the entry state preceding the following update or removal
+func (e *changeExtractor) state(key xdr.LedgerKey, entry xdr.LedgerEntry) error {
+	encodedKey, err := xdr.MarshalBase64(key)
+	if err != nil {
+		return err
+	}
+
+	if e.states[encodedKey], err = xdr.MarshalBase64(entry); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// add appends the change of the entry, entry is nil for removals
+func (e *changeExtractor) add(t xdr.LedgerEntryChangeType, key xdr.LedgerKey, entry *xdr.LedgerEntry) error {
+	var after string
+
+	e.index++
+
+	pagingToken := PagingToken{EffectIndex: e.index}.Merge(e.pagingToken)
+
+	encodedKey, err := xdr.MarshalBase64(key)
+	if err != nil {
+		return err
+	}
+
+	if entry != nil {
+		if after, err = xdr.MarshalBase64(*entry); err != nil {
+			return err
+		}
+	}
+
+	change := &LedgerEntryChange{
+		ID:              pagingToken.String(),
+		PagingToken:     pagingToken,
+		Type:            strings.Replace(t.String(), "LedgerEntryChangeTypeLedgerEntry", "", 1),
+		EntryType:       strings.Replace(key.Type.String(), "LedgerEntryType", "", 1),
+		Source:          e.source,
+		TxID:            e.transaction.ID,
+		Seq:             e.transaction.Seq,
+		TxIndex:         e.transaction.Index,
+		LedgerCloseTime: e.transaction.CloseTime,
+		Key:             encodedKey,
+		Before:          e.states[encodedKey],
+		After:           after,
+		Deleted:         t == xdr.LedgerEntryChangeTypeLedgerEntryRemoved,
+	}
+
+	if e.operation != nil {
+		change.OpIndex = e.operation.Index
+	}
+
+	switch key.Type {
+	case xdr.LedgerEntryTypeAccount:
+		account := key.MustAccount()
+		change.AccountID = account.AccountId.Address()
+	case xdr.LedgerEntryTypeTrustline:
+		line := key.MustTrustLine()
+		change.AccountID = line.AccountId.Address()
+		change.Asset = NewAsset(&line.Asset)
+	case xdr.LedgerEntryTypeOffer:
+		offer := key.MustOffer()
+		change.AccountID = offer.SellerId.Address()
+		change.OfferID = int64(offer.OfferId)
+	case xdr.LedgerEntryTypeData:
+		data := key.MustData()
+		change.AccountID = data.AccountId.Address()
+		change.DataName = string(data.DataName)
+	}
+
+	e.changes = append(e.changes, change)
+
+	return nil
+}
+
+// DocID returns es document id
+func (c *LedgerEntryChange) DocID() *string {
+	return &c.ID
+}
+
+// IndexName returns changes index name
+func (c *LedgerEntryChange) IndexName() IndexName {
+	return changesIndexName
+}
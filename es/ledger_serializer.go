@@ -3,29 +3,84 @@ package es
 import (
 	"bytes"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/astroband/astrologer/db"
 	"github.com/stellar/go/xdr"
 )
 
+// SerializeOptions represents optional documents produced during serialization
+type SerializeOptions struct {
+	// Changes enables the raw ledger entry changes index
+	Changes bool
+
+	// Stats enables per-ledger stats documents
+	Stats bool
+
+	// Payments enables the denormalized payments index
+	Payments bool
+
+	// Effects enables the effects index
+	Effects bool
+
+	// Offers enables the offers index
+	Offers bool
+
+	// TrustLines enables the trustlines index
+	TrustLines bool
+
+	// Accounts enables the accounts index
+	Accounts bool
+
+	// DataEntries enables the data_entries index
+	DataEntries bool
+
+	// Timestamps selects @timestamp source per index, documents of indices without source have no @timestamp
+	Timestamps map[IndexName]TimestampSource
+
+	// MemoRequired holds accounts requiring memo on incoming payments, payments to them get memo_required flag
+	MemoRequired map[string]bool
+
+	// OpTypes holds types of operations indexed into the operations and payments indices, nil indexes all.
+	// Other documents of filtered out operations are produced as usual.
+	OpTypes map[string]bool
+
+	// DocIDs sets _id of documents, so indexing them again overwrites them instead of adding duplicates
+	DocIDs bool
+}
+
 type ledgerSerializer struct {
 	ledgerRow       db.LedgerHeaderRow
 	transactionRows []db.TxHistoryRow
 	feeRows         []db.TxFeeHistoryRow
 	ledger          *LedgerHeader
+	protocol        ProtocolVersion
+	options         SerializeOptions
+	stats           *Stats
 
 	buffer *bytes.Buffer
+	err    error
 }
 
 // SerializeLedger serializes ledger data into ES bulk index data
-func SerializeLedger(ledgerRow db.LedgerHeaderRow, transactionRows []db.TxHistoryRow, feeRows []db.TxFeeHistoryRow, buffer *bytes.Buffer) error {
-	ledger := NewLedgerHeader(&ledgerRow)
+func SerializeLedger(ledgerRow db.LedgerHeaderRow, transactionRows []db.TxHistoryRow, feeRows []db.TxFeeHistoryRow, buffer *bytes.Buffer, options SerializeOptions) error {
+	ledger, err := NewLedgerHeader(&ledgerRow)
+	if err != nil {
+		return fmt.Errorf("ledger %d: %v", ledgerRow.LedgerSeq, err)
+	}
+
+	// stellar-core assigns txindex in application order, documents must follow it for balance replay
+	sort.SliceStable(transactionRows, func(i, j int) bool { return transactionRows[i].Index < transactionRows[j].Index })
+	sort.SliceStable(feeRows, func(i, j int) bool { return feeRows[i].Index < feeRows[j].Index })
 
 	serializer := &ledgerSerializer{
 		ledgerRow:       ledgerRow,
 		transactionRows: transactionRows,
 		feeRows:         feeRows,
 		ledger:          ledger,
+		protocol:        ProtocolVersion(ledger.Version),
+		options:         options,
 		buffer:          buffer,
 	}
 
@@ -33,7 +88,11 @@ func SerializeLedger(ledgerRow db.LedgerHeaderRow, transactionRows []db.TxHistor
 }
 
 func (s *ledgerSerializer) serialize() error {
-	SerializeForBulk(s.ledger, s.buffer)
+	s.write(s.ledger)
+
+	if s.options.Stats {
+		s.stats = NewLedgerStats(s.ledger)
+	}
 
 	for _, transactionRow := range s.transactionRows {
 		transaction, err := s.NewTransaction(&transactionRow, s.ledger.CloseTime)
@@ -42,20 +101,59 @@ func (s *ledgerSerializer) serialize() error {
 			return err
 		}
 
-		SerializeForBulk(transaction, s.buffer)
+		s.write(transaction)
+
+		if s.stats != nil {
+			s.stats.AddTransaction(transaction)
+		}
+
+		meta, err := NewTransactionMeta(s.protocol, transactionRow.Meta)
+		if err != nil {
+			return fmt.Errorf("transaction %s: %v", transaction.ID, err)
+		}
+
+		feeChanges := s.feeRows[transaction.Index-1].Changes
 
 		if transaction.Successful {
-			changes := s.feeRows[transaction.Index-1].Changes
-			s.serializeBalances(changes, transaction, nil, BalanceSourceFee)
+			s.serializeBalances(feeChanges, transaction, nil, BalanceSourceFee)
+		}
+
+		// Fees are charged before any transaction is applied, so fee changes precede any transaction in the ledger:
+		// they take the zero transaction slot and are ordered by transaction index in the operation slot
+		s.serializeAccounts(feeChanges, PagingToken{LedgerSeq: s.ledger.Seq, OperationOrder: transaction.Index})
+
+		s.serializeAccounts(meta.TxChangesBefore, transaction.PagingToken)
+
+		changesCount := 0
+
+		if s.options.Changes {
+			changesCount = s.serializeChanges(feeChanges, transaction, nil, ChangeSourceFee, 0)
+			changesCount = s.serializeChanges(meta.TxChangesBefore, transaction, nil, ChangeSourceTx, changesCount)
+		}
+
+		if err := s.serializeOperations(transactionRow, transaction, meta); err != nil {
+			return fmt.Errorf("transaction %s: %v", transaction.ID, err)
+		}
+
+		// Changes after operations follow the last operation of the transaction
+		if len(meta.TxChangesAfter) > 0 {
+			after := PagingToken{LedgerSeq: s.ledger.Seq, TransactionOrder: transaction.Index, OperationOrder: len(meta.Operations) + 1}
+			s.serializeAccounts(meta.TxChangesAfter, after)
+
+			if s.options.Changes {
+				s.serializeChanges(meta.TxChangesAfter, transaction, nil, ChangeSourceTx, changesCount)
+			}
 		}
+	}
 
-		s.serializeOperations(transactionRow, transaction)
+	if s.stats != nil {
+		s.write(s.stats)
 	}
 
-	return nil
+	return s.err
 }
 
-func (s *ledgerSerializer) serializeOperations(transactionRow db.TxHistoryRow, transaction *Transaction) error {
+func (s *ledgerSerializer) serializeOperations(transactionRow db.TxHistoryRow, transaction *Transaction, meta *TransactionMeta) error {
 	effectsCount := 0
 	err, xdrs := transactionRow.Operations()
 
@@ -63,27 +161,54 @@ func (s *ledgerSerializer) serializeOperations(transactionRow db.TxHistoryRow, t
 		return err
 	}
 
-	for index, xdr := range xdrs {
+	for index := range xdrs {
 		result := transactionRow.ResultFor(index)
-		operation, err := ProduceOperation(transaction, &xdr, result, index+1)
+		operation, err := ProduceOperation(transaction, &xdrs[index], result, index+1)
 
 		if err != nil {
 			return fmt.Errorf("Failed to serialize operation with index %d in tx %s: %w", index, transaction.ID, err)
 		}
 
-		SerializeForBulk(operation, s.buffer)
+		operation.MemoRequired = isPaymentToMemoRequired(operation, s.options.MemoRequired)
+
+		if s.options.includesOperation(operation) {
+			s.write(operation)
+		}
+
+		if s.stats != nil {
+			s.stats.AddOperation(operation)
+		}
 
 		if transaction.Successful {
-			metas := transactionRow.MetasFor(index)
+			var changes xdr.LedgerEntryChanges
+
+			metas := meta.Operation(index)
 			if metas != nil {
-				effectsCount = s.serializeBalances(metas.Changes, transaction, operation, BalanceSourceMeta)
+				changes = metas.Changes
+				effectsCount = s.serializeBalances(changes, transaction, operation, BalanceSourceMeta)
 			}
 
-			s.serializeTrades(result, transaction, operation, effectsCount)
+			if s.options.Changes {
+				s.serializeChanges(changes, transaction, operation, ChangeSourceOp, 0)
+			}
+
+			s.serializeOffers(changes, operation)
+			s.serializeTrustLines(changes, operation)
+			s.serializeAccounts(changes, operation.PagingToken)
+			s.serializeDataEntries(changes, operation)
+
+			if s.options.Payments && s.options.includesOperation(operation) {
+				if payment := NewPayment(operation); payment != nil {
+					s.write(payment)
+				}
+			}
+
+			trades := s.serializeTrades(result, transaction, operation, effectsCount)
+			s.serializeEffects(operation, &xdrs[index], changes, trades)
 
 			h := ProduceSignerHistory(operation)
 			if h != nil {
-				SerializeForBulk(h, s.buffer)
+				s.write(h)
 			}
 		}
 	}
@@ -109,14 +234,14 @@ func (s *ledgerSerializer) serializeBalances(changes xdr.LedgerEntryChanges, tra
 
 	if len(balances) > 0 {
 		for _, balance := range balances {
-			SerializeForBulk(balance, s.buffer)
+			s.write(balance)
 		}
 	}
 
 	return len(balances)
 }
 
-func (s *ledgerSerializer) serializeTrades(result *xdr.OperationResult, transaction *Transaction, operation *Operation, startIndex int) int {
+func (s *ledgerSerializer) serializeTrades(result *xdr.OperationResult, transaction *Transaction, operation *Operation, startIndex int) []Trade {
 	pagingToken := PagingToken{
 		LedgerSeq:        s.ledger.Seq,
 		TransactionOrder: transaction.Index,
@@ -126,9 +251,98 @@ func (s *ledgerSerializer) serializeTrades(result *xdr.OperationResult, transact
 	trades := ProduceTrades(result, operation, s.ledger.CloseTime, pagingToken, startIndex)
 	if len(trades) > 0 {
 		for _, trade := range trades {
-			SerializeForBulk(&trade, s.buffer)
+			s.write(&trade)
 		}
 	}
 
-	return len(trades)
+	return trades
+}
+
+func (s *ledgerSerializer) serializeChanges(changes xdr.LedgerEntryChanges, transaction *Transaction, operation *Operation, source ChangeSource, startIndex int) int {
+	entries, err := ProduceLedgerEntryChanges(changes, transaction, operation, source, startIndex)
+	if err != nil {
+		s.err = err
+		return startIndex
+	}
+
+	for _, entry := range entries {
+		s.write(entry)
+	}
+
+	return startIndex + len(entries)
+}
+
+func (s *ledgerSerializer) serializeOffers(changes xdr.LedgerEntryChanges, operation *Operation) {
+	if !s.options.Offers {
+		return
+	}
+
+	for _, offer := range ProduceOfferStates(changes, operation.PagingToken, s.ledger.CloseTime) {
+		s.write(offer)
+	}
+}
+
+func (s *ledgerSerializer) serializeTrustLines(changes xdr.LedgerEntryChanges, operation *Operation) {
+	if !s.options.TrustLines {
+		return
+	}
+
+	for _, line := range ProduceTrustLineStates(changes, operation.PagingToken, s.ledger.CloseTime) {
+		s.write(line)
+	}
+}
+
+func (s *ledgerSerializer) serializeAccounts(changes xdr.LedgerEntryChanges, pagingToken PagingToken) {
+	if !s.options.Accounts {
+		return
+	}
+
+	for _, account := range ProduceAccounts(changes, pagingToken, s.ledger.CloseTime) {
+		s.write(account)
+	}
+}
+
+func (s *ledgerSerializer) serializeDataEntries(changes xdr.LedgerEntryChanges, operation *Operation) {
+	if !s.options.DataEntries {
+		return
+	}
+
+	for _, entry := range ProduceDataEntryStates(changes, operation.PagingToken, s.ledger.CloseTime) {
+		s.write(entry)
+	}
+}
+
+func (s *ledgerSerializer) serializeEffects(operation *Operation, source *xdr.Operation, changes xdr.LedgerEntryChanges, trades []Trade) {
+	if !s.options.Effects {
+		return
+	}
+
+	for _, effect := range ProduceEffects(operation, source, changes, trades) {
+		s.write(effect)
+	}
+}
+
+// write serializes the document adding @timestamp if configured for its index,
+// the first failure is kept in s.err and stops further writes
+func (s *ledgerSerializer) write(obj Indexable) {
+	if s.err != nil {
+		return
+	}
+
+	source, ok := s.options.Timestamps[obj.IndexName()]
+	if !ok {
+		source, ok = s.options.Timestamps[AllIndices]
+	}
+
+	if !ok {
+		s.err = serializeForBulk(obj, s.buffer, nil, s.options.DocIDs)
+		return
+	}
+
+	timestamp := s.ledger.CloseTime
+	if source == TimestampIngest {
+		timestamp = time.Now()
+	}
+
+	s.err = serializeForBulk(obj, s.buffer, &timestamp, s.options.DocIDs)
 }
@@ -0,0 +1,55 @@
+package es
+
+import (
+	"fmt"
+
+	"github.com/stellar/go/xdr"
+)
+
+var upgradeTypeNames = map[xdr.LedgerUpgradeType]string{
+	xdr.LedgerUpgradeTypeLedgerUpgradeVersion:      "version",
+	xdr.LedgerUpgradeTypeLedgerUpgradeBaseFee:      "base_fee",
+	xdr.LedgerUpgradeTypeLedgerUpgradeMaxTxSetSize: "max_tx_set_size",
+	xdr.LedgerUpgradeTypeLedgerUpgradeBaseReserve:  "base_reserve",
+}
+
+// LedgerUpgrade represents network parameter upgrade applied by the ledger
+type LedgerUpgrade struct {
+	Type     int    `json:"type"`
+	TypeName string `json:"type_name"`
+	Value    int    `json:"value"`
+}
+
+// NewLedgerUpgrades decodes upgrades from ledger header SCP value
+func NewLedgerUpgrades(header *xdr.LedgerHeader) (upgrades []LedgerUpgrade, err error) {
+	for _, raw := range header.ScpValue.Upgrades {
+		var upgrade xdr.LedgerUpgrade
+
+		if err := xdr.SafeUnmarshal(raw, &upgrade); err != nil {
+			return nil, fmt.Errorf("invalid ledger upgrade: %v", err)
+		}
+
+		upgrades = append(upgrades, LedgerUpgrade{
+			Type:     int(upgrade.Type),
+			TypeName: upgradeTypeNames[upgrade.Type],
+			Value:    upgradeValue(&upgrade),
+		})
+	}
+
+	return upgrades, nil
+}
+
+func upgradeValue(upgrade *xdr.LedgerUpgrade) int {
+	switch upgrade.Type {
+	case xdr.LedgerUpgradeTypeLedgerUpgradeVersion:
+		return int(upgrade.MustNewLedgerVersion())
+	case xdr.LedgerUpgradeTypeLedgerUpgradeBaseFee:
+		return int(upgrade.MustNewBaseFee())
+	case xdr.LedgerUpgradeTypeLedgerUpgradeMaxTxSetSize:
+		return int(upgrade.MustNewMaxTxSetSize())
+	case xdr.LedgerUpgradeTypeLedgerUpgradeBaseReserve:
+		return int(upgrade.MustNewBaseReserve())
+	}
+
+	return 0
+}
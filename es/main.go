@@ -1,8 +1,9 @@
 package es
 
 import (
-	"bytes"
-	"log"
+	"encoding/json"
+	"net/url"
+	"time"
 
 	goES "github.com/elastic/go-elasticsearch/v7"
 )
@@ -13,17 +14,35 @@ type Indexable interface {
 	IndexName() IndexName
 }
 
-// Adapter represents the ledger storage backend
+// Adapter represents ElasticSearch capabilities on top of Sink, commands reading indexed data
+// get it with AsAdapter
 type Adapter interface {
-	MinMaxSeq() (min, max int)
-	LedgerSeqRangeQuery(ranges []map[string]interface{}) map[string]interface{}
-	GetLedgerSeqsInRange(min, max int) []int
-	LedgerCountInRange(min, max int) int
-	IndexExists(name IndexName) bool
-	CreateIndex(name IndexName, body IndexDefinition)
-	DeleteIndex(name IndexName)
-	BulkInsert(payload *bytes.Buffer) (success bool)
-	IndexWithRetries(payload *bytes.Buffer, retriesCount int)
+	Sink
+
+	MinMaxRange() (min, max int, err error)
+	LastLedgerCloseTime() (time.Time, error)
+	LastIndexedLedger() (int, bool, error)
+	LedgerSeqRangeQuery(ranges []map[string]interface{}) (map[string]interface{}, error)
+	GetLedgerSeqsInRange(min, max int) ([]int, error)
+	LedgerHistogram(first, last, interval int) (map[int]int, error)
+	LedgerCountInRange(min, max int) (int, error)
+	IndexedLedgers(indices []IndexName) (map[IndexName]int, error)
+	IndicesStats(indices []IndexName) ([]IndexStats, error)
+	ClusterHealth() (string, error)
+	CheckSchemaVersion() error
+	VerifyBatch(expected map[IndexName]int, first, last int) ([]string, error)
+	DeleteLedgerRange(indices []IndexName, first, last int) error
+	LedgerDocuments(index IndexName, seq int) ([]StoredDocument, error)
+	Reindex(name IndexName, script string, deleteOld bool) error
+	AccountHistory(accountID string) (map[IndexName][]json.RawMessage, error)
+	AccountStateAt(accountID string, seq int) (*AccountState, error)
+	LastAuditRecord(seq int) (*AuditRecord, error)
+	RollupHourlyStats(hours []time.Time) error
+	RollupTradeAggregations(from, to time.Time) error
+	RollupFlows(assetID string, interval string, labels map[string]string) error
+	RelaxIndices(indices []IndexName) (map[IndexName]IndexSettings, error)
+	RestoreIndices(settings map[IndexName]IndexSettings) error
+	ForceMerge(indices []IndexName, maxNumSegments int) error
 }
 
 // Client is a wrapper type around ElasticSearch raw client
@@ -32,15 +51,35 @@ type Client struct {
 }
 
 // Connect creates a Client configured to work with the ElasticSearch cluster
-func Connect(url string) *Client {
-	esCfg := goES.Config{
-		Addresses: []string{url},
-	}
+func Connect(url string) (*Client, error) {
+	return ConnectWithConfig(goES.Config{Addresses: []string{url}})
+}
 
+// ConnectWithConfig creates a Client with the given configuration, e.g. for Elastic Cloud deployments
+func ConnectWithConfig(esCfg goES.Config) (*Client, error) {
 	client, err := goES.NewClient(esCfg)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	return &Client{rawClient: client}
+	return &Client{rawClient: client}, nil
+}
+
+// openElasticSearch connects to the cluster at u, Cloud ID replaces the URL if given
+func openElasticSearch(u *url.URL, options SinkOptions) (Sink, error) {
+	esCfg := goES.Config{APIKey: options.APIKey}
+
+	if options.CloudID != "" {
+		esCfg.CloudID = options.CloudID
+	} else {
+		esCfg.Addresses = []string{u.String()}
+	}
+
+	if err := options.configure(&esCfg); err != nil {
+		return nil, err
+	}
+
+	options.compress(&esCfg)
+
+	return ConnectWithConfig(esCfg)
 }
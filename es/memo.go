@@ -1,7 +1,50 @@
 package es
 
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/astroband/astrologer/util"
+	"github.com/stellar/go/xdr"
+)
+
 // Memo represents transaction memo
 type Memo struct {
 	Type  int    `json:"type"`
 	Value string `json:"value"`
+
+	// Bytes holds base64 encoded raw text memo when it is not a valid UTF-8 string
+	Bytes string `json:"bytes,omitempty"`
+}
+
+// NewMemo creates Memo from xdr.Memo, returns nil for MemoNone
+func NewMemo(m xdr.Memo) *Memo {
+	memo := &Memo{Type: int(m.Type)}
+
+	switch m.Type {
+	case xdr.MemoTypeMemoText:
+		text := m.MustText()
+
+		if !utf8.ValidString(text) {
+			memo.Bytes = base64.StdEncoding.EncodeToString([]byte(text))
+			text = util.ScrubUTF8(text)
+		}
+
+		memo.Value = strings.Replace(text, "\x00", "", -1)
+	case xdr.MemoTypeMemoId:
+		memo.Value = strconv.FormatUint(uint64(m.MustId()), 10)
+	case xdr.MemoTypeMemoHash:
+		hash := m.MustHash()
+		memo.Value = hex.EncodeToString(hash[:])
+	case xdr.MemoTypeMemoReturn:
+		hash := m.MustRetHash()
+		memo.Value = hex.EncodeToString(hash[:])
+	default:
+		return nil
+	}
+
+	return memo
 }
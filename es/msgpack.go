@@ -0,0 +1,127 @@
+package es
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// JSONToMsgpack transcodes serialized document to MessagePack, structure and field names are kept as is
+func JSONToMsgpack(data []byte) ([]byte, error) {
+	var value interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := writeMsgpack(&b, value); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func writeMsgpack(b *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		b.WriteByte(0xc0)
+	case bool:
+		if v {
+			b.WriteByte(0xc3)
+		} else {
+			b.WriteByte(0xc2)
+		}
+	case json.Number:
+		return writeMsgpackNumber(b, v)
+	case string:
+		writeMsgpackHeader(b, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		b.WriteString(v)
+	case []interface{}:
+		writeMsgpackHeader(b, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+
+		for _, item := range v {
+			if err := writeMsgpack(b, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		writeMsgpackHeader(b, len(v), 0x80, 16, 0, 0xde, 0xdf)
+
+		for _, key := range keys {
+			writeMsgpack(b, key)
+
+			if err := writeMsgpack(b, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("can not encode %T to msgpack", value)
+	}
+
+	return nil
+}
+
+// writeMsgpackNumber writes integers as int64/uint64 and everything else as float64
+func writeMsgpackNumber(b *bytes.Buffer, n json.Number) error {
+	if i, err := n.Int64(); err == nil {
+		switch {
+		case i >= 0 && i < 128:
+			b.WriteByte(byte(i))
+		case i < 0 && i >= -32:
+			b.WriteByte(byte(int8(i)))
+		default:
+			b.WriteByte(0xd3)
+			binary.Write(b, binary.BigEndian, i)
+		}
+
+		return nil
+	}
+
+	if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+		b.WriteByte(0xcf)
+		binary.Write(b, binary.BigEndian, u)
+		return nil
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+
+	b.WriteByte(0xcb)
+	binary.Write(b, binary.BigEndian, math.Float64bits(f))
+
+	return nil
+}
+
+// writeMsgpackHeader writes length prefix of string, array or map using the smallest format available,
+// str8 is the only 8-bit length format, it is skipped for arrays and maps by passing 0
+func writeMsgpackHeader(b *bytes.Buffer, n int, fix byte, fixMax int, f8, f16, f32 byte) {
+	switch {
+	case n < fixMax:
+		b.WriteByte(fix | byte(n))
+	case f8 != 0 && n <= math.MaxUint8:
+		b.WriteByte(f8)
+		b.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		b.WriteByte(f16)
+		binary.Write(b, binary.BigEndian, uint16(n))
+	default:
+		b.WriteByte(f32)
+		binary.Write(b, binary.BigEndian, uint32(n))
+	}
+}
@@ -0,0 +1,80 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Network is stored in the network field of every document, e.g. pubnet
+var Network string
+
+// IndexPrefix is prepended to names of all indices in the cluster, e.g. pubnet_
+var IndexPrefix string
+
+var networkNames = map[string]string{
+	"Public Global Stellar Network ; September 2015": "pubnet",
+	"Test SDF Network ; September 2015":              "testnet",
+	"Test SDF Future Network ; October 2022":         "futurenet",
+}
+
+// NetworkName returns the short name of the well-known network, empty string for other passphrases
+func NetworkName(passphrase string) string {
+	return networkNames[passphrase]
+}
+
+// String returns the name of the index in the cluster
+func (n IndexName) String() string {
+	return IndexPrefix + string(n)
+}
+
+// UnmarshalText strips IndexPrefix from index names returned by the cluster or read from bulk payloads
+func (n *IndexName) UnmarshalText(text []byte) error {
+	*n = IndexName(strings.TrimPrefix(string(text), IndexPrefix))
+	return nil
+}
+
+// withNetwork inserts the network field as the first field of serialized object
+func withNetwork(data []byte) []byte {
+	if Network == "" {
+		return data
+	}
+
+	field := fmt.Sprintf(`{"network":%q`, Network)
+
+	if len(data) > 2 {
+		field += ","
+	}
+
+	return append([]byte(field), data[1:]...)
+}
+
+// withNetworkMapping adds the network keyword to the index definition
+func withNetworkMapping(name IndexName, body IndexDefinition) (IndexDefinition, error) {
+	var def map[string]interface{}
+
+	if err := json.Unmarshal([]byte(body), &def); err != nil {
+		return "", fmt.Errorf("invalid %s index definition: %v", name, err)
+	}
+
+	mappings, _ := def["mappings"].(map[string]interface{})
+	if mappings == nil {
+		mappings = make(map[string]interface{})
+		def["mappings"] = mappings
+	}
+
+	properties, _ := mappings["properties"].(map[string]interface{})
+	if properties == nil {
+		properties = make(map[string]interface{})
+		mappings["properties"] = properties
+	}
+
+	properties["network"] = map[string]interface{}{"type": "keyword", "index": true}
+
+	result, err := json.Marshal(def)
+	if err != nil {
+		return "", err
+	}
+
+	return IndexDefinition(result), nil
+}
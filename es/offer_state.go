@@ -0,0 +1,111 @@
+package es
+
+import (
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/xdr"
+)
+
+// OfferState represents the latest known state of the offer in the order book
+type OfferState struct {
+	ID                 string      `json:"id"`
+	PagingToken        PagingToken `json:"paging_token"`
+	OfferID            int64       `json:"offer_id"`
+	SellerID           string      `json:"seller_id"`
+	Selling            *Asset      `json:"selling,omitempty"`
+	Buying             *Asset      `json:"buying,omitempty"`
+	Amount             string      `json:"amount"`
+	Price              float64     `json:"price"`
+	PriceND            Price       `json:"price_n_d"`
+	Passive            bool        `json:"passive"`
+	LastModifiedLedger int         `json:"last_modified_ledger"`
+	LedgerCloseTime    time.Time   `json:"ledger_close_time"`
+	Deleted            bool        `json:"deleted"`
+}
+
+// ProduceOfferStates returns offer states changed by the operation meta
+func ProduceOfferStates(changes xdr.LedgerEntryChanges, pagingToken PagingToken, closeTime time.Time) (offers []*OfferState) {
+	states := make(map[xdr.Int64]xdr.OfferEntry)
+
+	for _, change := range changes {
+		switch change.Type {
+		case xdr.LedgerEntryChangeTypeLedgerEntryState:
+			if o, ok := change.MustState().Data.GetOffer(); ok {
+				states[o.OfferId] = o
+			}
+		case xdr.LedgerEntryChangeTypeLedgerEntryCreated:
+			entry := change.MustCreated()
+			if o, ok := entry.Data.GetOffer(); ok {
+				offers = append(offers, NewOfferState(o, int(entry.LastModifiedLedgerSeq), pagingToken, closeTime))
+			}
+		case xdr.LedgerEntryChangeTypeLedgerEntryUpdated:
+			entry := change.MustUpdated()
+			if o, ok := entry.Data.GetOffer(); ok {
+				offers = append(offers, NewOfferState(o, int(entry.LastModifiedLedgerSeq), pagingToken, closeTime))
+			}
+		case xdr.LedgerEntryChangeTypeLedgerEntryRemoved:
+			key := change.MustRemoved()
+			if k, ok := key.GetOffer(); ok {
+				o, found := states[k.OfferId]
+
+				if !found {
+					o = xdr.OfferEntry{SellerId: k.SellerId, OfferId: k.OfferId}
+				}
+
+				offer := NewOfferState(o, pagingToken.LedgerSeq, pagingToken, closeTime)
+				offer.Amount = amount.String(0)
+				offer.Deleted = true
+
+				if !found {
+					offer.Selling, offer.Buying = nil, nil
+				}
+
+				offers = append(offers, offer)
+			}
+		}
+	}
+
+	return offers
+}
+
+// NewOfferState creates OfferState from OfferEntry
+func NewOfferState(o xdr.OfferEntry, lastModified int, pagingToken PagingToken, closeTime time.Time) *OfferState {
+	var price float64
+
+	if o.Price.D != 0 {
+		price, _ = big.NewRat(int64(o.Price.N), int64(o.Price.D)).Float64()
+	}
+
+	return &OfferState{
+		ID:                 strconv.FormatInt(int64(o.OfferId), 10),
+		PagingToken:        pagingToken,
+		OfferID:            int64(o.OfferId),
+		SellerID:           o.SellerId.Address(),
+		Selling:            NewAsset(&o.Selling),
+		Buying:             NewAsset(&o.Buying),
+		Amount:             amount.String(o.Amount),
+		Price:              price,
+		PriceND:            Price{int(o.Price.N), int(o.Price.D)},
+		Passive:            o.Flags&xdr.Uint32(xdr.OfferEntryFlagsPassiveFlag) != 0,
+		LastModifiedLedger: lastModified,
+		LedgerCloseTime:    closeTime,
+	}
+}
+
+// DocID returns offer id
+func (o *OfferState) DocID() *string {
+	return &o.ID
+}
+
+// IndexName returns offers index name
+func (o *OfferState) IndexName() IndexName {
+	return offersIndexName
+}
+
+// Version returns document version, newer changes overwrite older ones
+func (o *OfferState) Version() (int64, error) {
+	return o.PagingToken.Version()
+}
@@ -0,0 +1,136 @@
+package es
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	goES "github.com/elastic/go-elasticsearch/v7"
+)
+
+// sigV4Transport signs requests to AWS OpenSearch Service with AWS Signature Version 4
+type sigV4Transport struct {
+	signer  *v4.Signer
+	region  string
+	service string
+	next    http.RoundTripper
+}
+
+// RoundTrip signs and sends the request, body is buffered because the signature covers its hash
+func (t *sigV4Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body io.ReadSeeker
+
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		body = bytes.NewReader(b)
+	}
+
+	if _, err := t.signer.Sign(req, body, t.service, t.region, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// ConnectOpenSearch creates a Client configured to work with OpenSearch cluster at url.
+// Requests are signed with SigV4 when region is given, credentials are taken from the standard AWS chain.
+// Service is "es" for OpenSearch Service domains and "aoss" for OpenSearch Serverless.
+func ConnectOpenSearch(url string, region string, service string, options SinkOptions) (*Client, error) {
+	esCfg := goES.Config{
+		Addresses: []string{url},
+	}
+
+	if err := options.configure(&esCfg); err != nil {
+		return nil, err
+	}
+
+	if region != "" {
+		sess, err := session.NewSession()
+		if err != nil {
+			return nil, err
+		}
+
+		next := esCfg.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+
+		esCfg.Transport = &sigV4Transport{
+			signer:  v4.NewSigner(sess.Config.Credentials),
+			region:  region,
+			service: service,
+			next:    next,
+		}
+	}
+
+	options.compress(&esCfg)
+
+	es, err := ConnectWithConfig(esCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := es.checkDistribution(); err != nil {
+		return nil, err
+	}
+
+	return es, nil
+}
+
+// checkDistribution logs OpenSearch version reported by the cluster, warns if the cluster is not OpenSearch
+func (es *Client) checkDistribution() error {
+	var r struct {
+		Version struct {
+			Number       string `json:"number"`
+			Distribution string `json:"distribution"`
+		} `json:"version"`
+	}
+
+	res, err := es.rawClient.Info()
+	if err := responseError(res, err); err != nil {
+		return fmt.Errorf("failed to get cluster info: %v", err)
+	}
+
+	if err := decodeBody(res.Body, &r); err != nil {
+		return err
+	}
+
+	if r.Version.Distribution != "opensearch" {
+		log.Printf("Cluster reports version %s, not OpenSearch, consider plain ElasticSearch output", r.Version.Number)
+		return nil
+	}
+
+	log.Println("Connected to OpenSearch", r.Version.Number)
+
+	return nil
+}
+
+// openOpenSearch handles opensearch+https://host?region=us-east-1&service=es URLs
+func openOpenSearch(u *url.URL, options SinkOptions) (Sink, error) {
+	query := u.Query()
+
+	service := query.Get("service")
+	if service == "" {
+		service = "es"
+	}
+
+	target := *u
+	target.Scheme = strings.TrimPrefix(u.Scheme, "opensearch+")
+	target.RawQuery = ""
+
+	return ConnectOpenSearch(target.String(), query.Get("region"), service, options)
+}
@@ -36,6 +36,8 @@ type Operation struct {
 	DestinationAccountID string             `json:"destination_account_id,omitempty"`
 	DestinationAsset     *Asset             `json:"destination_asset,omitempty"`
 	DestinationAmount    string             `json:"destination_amount,omitempty"`
+	SourceMax            string             `json:"source_max,omitempty"`
+	DestinationMin       string             `json:"destination_min,omitempty"`
 	OfferPrice           float64            `json:"offer_price,omitempty"`
 	OfferPriceND         *Price             `json:"offer_price_n_d,omitempty"`
 	OfferID              int                `json:"offer_id,omitempty"`
@@ -55,10 +57,15 @@ type Operation struct {
 	ResultOffer                *Offer `json:"result_offer,omitempty"`
 	ResultOfferEffect          string `json:"result_offer_effect,omitempty"`
 
-	ResultLastAmount      string `json:"result_last_amount,omitempty"`
-	ResultLastAsset       *Asset `json:"result_last_asset,omitempty"`
-	ResultLastDestination string `json:"result_last_destination,omitempty"`
-	ResultNoIssuer        *Asset `json:"result_no_issuer,omitempty"`
+	ResultLastAmount      string   `json:"result_last_amount,omitempty"`
+	ResultLastAsset       *Asset   `json:"result_last_asset,omitempty"`
+	ResultLastDestination string   `json:"result_last_destination,omitempty"`
+	ResultPath            []*Asset `json:"result_path,omitempty"`
+	ResultCrossedOfferIDs []int64  `json:"result_crossed_offer_ids,omitempty"`
+	ResultNoIssuer        *Asset   `json:"result_no_issuer,omitempty"`
+
+	// MemoRequired is true for payments to accounts requiring memo (SEP-29)
+	MemoRequired bool `json:"memo_required,omitempty"`
 
 	*Memo `json:"memo,omitempty"`
 }
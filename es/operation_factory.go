@@ -163,7 +163,9 @@ func (f *operationFactory) assignPathPaymentStrictReceive(o xdr.PathPaymentStric
 	f.operation.DestinationAmount = amount.String(o.DestAmount)
 	f.operation.DestinationAsset = NewAsset(&o.DestAsset)
 
+	// SourceAmount is replaced with the actual amount sent by the result
 	f.operation.SourceAmount = amount.String(o.SendMax)
+	f.operation.SourceMax = f.operation.SourceAmount
 	f.operation.SourceAsset = NewAsset(&o.SendAsset)
 
 	f.operation.Path = make([]*Asset, len(o.Path))
@@ -183,7 +185,9 @@ func (f *operationFactory) assignPathPaymentStrictSend(o xdr.PathPaymentStrictSe
 		return err
 	}
 
+	// DestinationAmount is replaced with the actual amount received by the result
 	f.operation.DestinationAmount = amount.String(o.DestMin)
+	f.operation.DestinationMin = f.operation.DestinationAmount
 	f.operation.DestinationAsset = NewAsset(&o.DestAsset)
 
 	f.operation.SourceAmount = amount.String(o.SendAmount)
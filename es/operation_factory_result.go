@@ -69,14 +69,10 @@ func (f *operationFactory) assignPathPaymentStrictReceiveResult(r xdr.PathPaymen
 	f.operation.Successful = r.Code == xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveSuccess
 
 	if s, ok := r.GetSuccess(); ok {
-		if len(s.Offers) > 0 {
-			f.operation.AmountSent = amount.String(s.Offers[0].AmountBought)
-		}
-		f.operation.ResultLastAmount = amount.String(s.Last.Amount)
-		f.operation.AmountReceived = f.operation.ResultLastAmount
+		sendAsset := f.source.Body.MustPathPaymentStrictReceiveOp().SendAsset
 
-		f.operation.ResultLastAsset = NewAsset(&s.Last.Asset)
-		f.operation.ResultLastDestination = s.Last.Destination.Address()
+		f.assignRealizedPath(sendAsset, s.Offers, s.Last)
+		f.operation.SourceAmount = f.operation.AmountSent
 	}
 
 	if a, ok := r.GetNoIssuer(); ok {
@@ -90,20 +86,54 @@ func (f *operationFactory) assignPathPaymentStrictSendResult(r xdr.PathPaymentSt
 	f.operation.Successful = r.Code == xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendSuccess
 
 	if s, ok := r.GetSuccess(); ok {
-		if len(s.Offers) > 0 {
-			f.operation.AmountSent = amount.String(s.Offers[0].AmountBought)
-		}
-		f.operation.ResultLastAmount = amount.String(s.Last.Amount)
-		f.operation.AmountReceived = f.operation.ResultLastAmount
+		sendAsset := f.source.Body.MustPathPaymentStrictSendOp().SendAsset
 
-		f.operation.ResultLastAsset = NewAsset(&s.Last.Asset)
-		f.operation.ResultLastDestination = s.Last.Destination.Address()
+		f.assignRealizedPath(sendAsset, s.Offers, s.Last)
+		f.operation.DestinationAmount = f.operation.AmountReceived
 	}
+
 	if a, ok := r.GetNoIssuer(); ok {
 		f.operation.ResultNoIssuer = NewAsset(&a)
 	}
 }
 
+// assignRealizedPath assigns amounts actually sent and received, offers crossed and assets the payment went through
+func (f *operationFactory) assignRealizedPath(sendAsset xdr.Asset, offers []xdr.ClaimOfferAtom, last xdr.SimplePaymentResult) {
+	var sent xdr.Int64
+
+	current := sendAsset
+	firstHop := true
+
+	for _, o := range offers {
+		// Offers of the first hop come first, their owners buy what the payment sends.
+		// Later hops may buy the send asset again on circular paths, those amounts are not sent by the source.
+		if firstHop && o.AssetBought.Equals(sendAsset) {
+			sent += o.AmountBought
+		} else {
+			firstHop = false
+		}
+
+		if o.AssetBought.Equals(current) && !o.AssetSold.Equals(last.Asset) {
+			f.operation.ResultPath = append(f.operation.ResultPath, NewAsset(&o.AssetSold))
+			current = o.AssetSold
+		}
+
+		f.operation.ResultCrossedOfferIDs = append(f.operation.ResultCrossedOfferIDs, int64(o.OfferId))
+	}
+
+	// Payment without offers crossed sends the same asset it delivers
+	if len(offers) == 0 {
+		sent = last.Amount
+	}
+
+	f.operation.AmountSent = amount.String(sent)
+	f.operation.ResultLastAmount = amount.String(last.Amount)
+	f.operation.AmountReceived = f.operation.ResultLastAmount
+
+	f.operation.ResultLastAsset = NewAsset(&last.Asset)
+	f.operation.ResultLastDestination = last.Destination.Address()
+}
+
 func (f *operationFactory) assignManageSellOfferResult(r xdr.ManageSellOfferResult) {
 	f.operation.InnerResultCode = int(r.Code)
 	f.operation.Successful = r.Code == xdr.ManageSellOfferResultCodeManageSellOfferSuccess
@@ -0,0 +1,39 @@
+package es
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stellar/go/xdr"
+)
+
+// operationTypeAliases maps Horizon names of renamed operations to current ones
+var operationTypeAliases = map[string]string{
+	"manage_offer":         "manage_sell_offer",
+	"create_passive_offer": "create_passive_sell_offer",
+	"path_payment":         "path_payment_strict_receive",
+}
+
+// ParseOperationType converts snake_case operation type name (e.g. manage_sell_offer) to the type of operation documents
+func ParseOperationType(name string) (string, error) {
+	if alias, ok := operationTypeAliases[name]; ok {
+		name = alias
+	}
+
+	normalized := strings.Replace(name, "_", "", -1)
+
+	for i := int32(0); xdr.OperationType(0).ValidEnum(i); i++ {
+		t := strings.Replace(xdr.OperationType(i).String(), "OperationType", "", 1)
+
+		if strings.EqualFold(t, normalized) {
+			return t, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown operation type %q", name)
+}
+
+// includesOperation checks if the operation document passes --op-type filter
+func (o SerializeOptions) includesOperation(operation *Operation) bool {
+	return o.OpTypes == nil || o.OpTypes[operation.Type]
+}
@@ -0,0 +1,158 @@
+package es
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// IndexSettings holds index settings relaxed for the duration of backfill, nil means cluster default
+type IndexSettings struct {
+	RefreshInterval  *string `json:"refresh_interval"`
+	NumberOfReplicas *string `json:"number_of_replicas"`
+}
+
+// LedgerIndices returns indices filled from ledgers with the given options
+func LedgerIndices(options SerializeOptions) []IndexName {
+	indices := []IndexName{
+		ledgerHeaderIndexName, txIndexName, opIndexName, balanceIndexName, tradesIndexName, signerHistoryIndexName,
+	}
+
+	if options.Changes {
+		indices = append(indices, changesIndexName)
+	}
+
+	if options.Stats {
+		indices = append(indices, statsIndexName)
+	}
+
+	if options.Payments {
+		indices = append(indices, paymentsIndexName)
+	}
+
+	if options.Effects {
+		indices = append(indices, effectsIndexName)
+	}
+
+	if options.Offers {
+		indices = append(indices, offersIndexName)
+	}
+
+	if options.TrustLines {
+		indices = append(indices, trustLinesIndexName)
+	}
+
+	if options.Accounts {
+		indices = append(indices, accountsIndexName)
+	}
+
+	if options.DataEntries {
+		indices = append(indices, dataEntriesIndexName)
+	}
+
+	return indices
+}
+
+// AppendOnlyLedgerIndices returns indices of LedgerIndices whose documents are never updated, versioned state indices are skipped
+func AppendOnlyLedgerIndices(options SerializeOptions) (indices []IndexName) {
+	for _, index := range LedgerIndices(options) {
+		if appendOnlyIndices[index] {
+			indices = append(indices, index)
+		}
+	}
+
+	return indices
+}
+
+// RelaxIndices disables refresh and replicas of the indices, returns previous settings
+func (es *Client) RelaxIndices(indices []IndexName) (map[IndexName]IndexSettings, error) {
+	var r map[IndexName]struct {
+		Settings struct {
+			Index IndexSettings `json:"index"`
+		} `json:"settings"`
+	}
+
+	res, err := es.rawClient.Indices.GetSettings(
+		es.rawClient.Indices.GetSettings.WithIndex(indexNames(indices)...),
+		es.rawClient.Indices.GetSettings.WithName("index.refresh_interval", "index.number_of_replicas"),
+	)
+	if err := responseError(res, err); err != nil {
+		return nil, fmt.Errorf("failed to get index settings: %v", err)
+	}
+
+	if err := decodeBody(res.Body, &r); err != nil {
+		return nil, err
+	}
+
+	saved := make(map[IndexName]IndexSettings, len(r))
+	for name, index := range r {
+		saved[name] = index.Settings.Index
+	}
+
+	refresh, replicas := "-1", "0"
+	if err := es.putSettings(indices, IndexSettings{RefreshInterval: &refresh, NumberOfReplicas: &replicas}); err != nil {
+		return nil, err
+	}
+
+	log.Println("Refresh and replicas disabled for", len(indices), "indices")
+
+	return saved, nil
+}
+
+// RestoreIndices puts back settings returned by RelaxIndices
+func (es *Client) RestoreIndices(settings map[IndexName]IndexSettings) error {
+	for name, s := range settings {
+		if err := es.putSettings([]IndexName{name}, s); err != nil {
+			return err
+		}
+	}
+
+	log.Println("Refresh and replicas settings restored for", len(settings), "indices")
+
+	return nil
+}
+
+// ForceMerge merges segments of the indices down to maxNumSegments
+func (es *Client) ForceMerge(indices []IndexName, maxNumSegments int) error {
+	log.Println("Force merging", len(indices), "indices to", maxNumSegments, "segment(s)")
+
+	res, err := es.rawClient.Indices.Forcemerge(
+		es.rawClient.Indices.Forcemerge.WithIndex(indexNames(indices)...),
+		es.rawClient.Indices.Forcemerge.WithMaxNumSegments(maxNumSegments),
+	)
+	if err := closeResponse(res, err); err != nil {
+		return fmt.Errorf("force merge failed: %v", err)
+	}
+
+	log.Println("Force merge finished")
+
+	return nil
+}
+
+func (es *Client) putSettings(indices []IndexName, settings IndexSettings) error {
+	var buf bytes.Buffer
+
+	if err := json.NewEncoder(&buf).Encode(map[string]IndexSettings{"index": settings}); err != nil {
+		return err
+	}
+
+	res, err := es.rawClient.Indices.PutSettings(
+		&buf,
+		es.rawClient.Indices.PutSettings.WithIndex(indexNames(indices)...),
+	)
+	if err := closeResponse(res, err); err != nil {
+		return fmt.Errorf("failed to put index settings: %v", err)
+	}
+
+	return nil
+}
+
+func indexNames(indices []IndexName) []string {
+	names := make([]string, len(indices))
+	for i, index := range indices {
+		names[i] = index.String()
+	}
+
+	return names
+}
@@ -0,0 +1,224 @@
+package es
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/gcs"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go-source/s3"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// ParquetClient writes ledgers, transactions, operations and balances as Parquet files partitioned
+// by index and day, e.g. op/date=2020-06-01/part-....parquet. Other documents are skipped.
+type ParquetClient struct {
+	writeOnlySink
+
+	// storage is file, s3 or gs
+	storage string
+	bucket  string
+	prefix  string
+	project string
+
+	mutex   sync.Mutex
+	counter int
+}
+
+// NewParquetClient creates ParquetClient writing to local directory (storage "file", empty bucket),
+// S3 bucket (storage "s3") or GCS bucket (storage "gs", project is required)
+func NewParquetClient(storage, bucket, prefix, project string) (*ParquetClient, error) {
+	switch storage {
+	case "file", "s3":
+	case "gs":
+		if project == "" {
+			return nil, fmt.Errorf("GCS project is required for Parquet output, use ?project=")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported Parquet storage %s", storage)
+	}
+
+	return &ParquetClient{storage: storage, bucket: bucket, prefix: prefix, project: project}, nil
+}
+
+func openParquet(u *url.URL, options SinkOptions) (Sink, error) {
+	if u.Scheme == "parquet+file" {
+		return NewParquetClient("file", "", u.Path, "")
+	}
+
+	storage := strings.TrimPrefix(u.Scheme, "parquet+")
+	prefix := strings.TrimPrefix(u.Path, "/")
+
+	return NewParquetClient(storage, u.Host, prefix, u.Query().Get("project"))
+}
+
+// BulkWrite writes documents of the bulk payload, one file per index and day
+func (c *ParquetClient) BulkWrite(payload *bytes.Buffer) error {
+	partitions, err := parquetPartitions(payload.Bytes())
+	if err != nil {
+		return fmt.Errorf("invalid bulk payload: %v", err)
+	}
+
+	return c.writePartitions(partitions, make(map[string]bool))
+}
+
+// IndexWithRetries writes the payload, retries in case of failure, zero retryCount means retry forever.
+// Partitions written by earlier attempts are not written again.
+func (c *ParquetClient) IndexWithRetries(payload *bytes.Buffer, retryCount int) (retries int, err error) {
+	partitions, err := parquetPartitions(payload.Bytes())
+	if err != nil {
+		return 0, fmt.Errorf("invalid bulk payload: %v", err)
+	}
+
+	written := make(map[string]bool)
+
+	for ; ; retries++ {
+		err := c.writePartitions(partitions, written)
+		if err == nil {
+			break
+		}
+
+		if retryCount > 0 && retries >= retryCount {
+			return retries, fmt.Errorf("retries for bulk failed: %v", err)
+		}
+
+		delay := time.Duration(retries*retries) * time.Second
+		log.Println("Failed to write Parquet:", err, "retrying in", delay)
+		time.Sleep(delay)
+	}
+
+	return retries, nil
+}
+
+// writePartitions writes partitions missing in written in key order, marking them as written
+func (c *ParquetClient) writePartitions(partitions map[string][]parquetRow, written map[string]bool) error {
+	keys := make([]string, 0, len(partitions))
+	for key := range partitions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if written[key] {
+			continue
+		}
+
+		if err := c.write(path.Join(c.prefix, key, c.fileName()), partitions[key]); err != nil {
+			return err
+		}
+
+		written[key] = true
+	}
+
+	return nil
+}
+
+// parquetPartitions converts documents of the bulk payload to rows grouped by <index>/date=<day>
+func parquetPartitions(payload []byte) (map[string][]parquetRow, error) {
+	var m bulkMeta
+
+	partitions := make(map[string][]parquetRow)
+
+	scanner := bufio.NewScanner(bytes.NewReader(payload))
+	scanner.Buffer(make([]byte, 64*1024), len(payload)+1)
+
+	for meta := true; scanner.Scan(); meta = !meta {
+		if meta {
+			m = bulkMeta{}
+
+			if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		newRow, ok := parquetRows[m.Index.Index]
+		if !ok {
+			continue
+		}
+
+		row := newRow()
+		if err := json.Unmarshal(scanner.Bytes(), row); err != nil {
+			return nil, err
+		}
+
+		day := row.convert().UTC().Format("2006-01-02")
+		key := fmt.Sprintf("%s/date=%s", m.Index.Index, day)
+
+		partitions[key] = append(partitions[key], row)
+	}
+
+	return partitions, scanner.Err()
+}
+
+// write writes rows of the same type into the new file
+func (c *ParquetClient) write(key string, rows []parquetRow) error {
+	file, err := c.create(key)
+	if err != nil {
+		return err
+	}
+
+	pw, err := writer.NewParquetWriter(file, rows[0], 4)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	for _, row := range rows {
+		if err := pw.Write(row); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	if err := pw.WriteStop(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// create opens the file for writing in the configured storage
+func (c *ParquetClient) create(key string) (source.ParquetFile, error) {
+	switch c.storage {
+	case "s3":
+		return s3.NewS3FileWriter(context.Background(), c.bucket, key, nil)
+	case "gs":
+		return gcs.NewGcsFileWriter(context.Background(), c.project, c.bucket, key)
+	}
+
+	name := filepath.FromSlash(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return nil, err
+	}
+
+	return local.NewLocalFileWriter(name)
+}
+
+// fileName returns unique name of the next file
+func (c *ParquetClient) fileName() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.counter++
+
+	return fmt.Sprintf("part-%s-%06d.snappy.parquet", time.Now().UTC().Format("20060102-150405"), c.counter)
+}
@@ -0,0 +1,147 @@
+package es
+
+import (
+	"time"
+)
+
+// parquetRow represents flat columnar form of the document
+type parquetRow interface {
+	// convert fills columns which can not be unmarshalled from the document directly,
+	// returns time used for partitioning
+	convert() time.Time
+}
+
+// parquetRows lists indices exported to Parquet along with their row constructors
+var parquetRows = map[IndexName]func() parquetRow{
+	ledgerHeaderIndexName: func() parquetRow { return &ParquetLedger{} },
+	txIndexName:           func() parquetRow { return &ParquetTransaction{} },
+	opIndexName:           func() parquetRow { return &ParquetOperation{} },
+	balanceIndexName:      func() parquetRow { return &ParquetBalance{} },
+}
+
+// ParquetLedger represents ledger row of Parquet export
+type ParquetLedger struct {
+	ID              string `json:"id" parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Hash            string `json:"hash" parquet:"name=hash, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PrevHash        string `json:"prev_hash" parquet:"name=prev_hash, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Seq             int64  `json:"seq" parquet:"name=seq, type=INT64"`
+	PagingToken     string `json:"paging_token" parquet:"name=paging_token, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CloseTimeMillis int64  `parquet:"name=close_time, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	ProtocolVersion int32  `json:"protocol_version" parquet:"name=protocol_version, type=INT32"`
+	TotalCoins      int64  `json:"total_coins" parquet:"name=total_coins, type=INT64"`
+	FeePool         int64  `json:"fee_pool" parquet:"name=fee_pool, type=INT64"`
+	BaseFee         int64  `json:"base_fee" parquet:"name=base_fee, type=INT64"`
+	BaseReserve     int64  `json:"base_reserve" parquet:"name=base_reserve, type=INT64"`
+	MaxTxSetSize    int64  `json:"max_tx_set_size" parquet:"name=max_tx_set_size, type=INT64"`
+
+	CloseTime time.Time `json:"close_time"`
+}
+
+func (r *ParquetLedger) convert() time.Time {
+	r.CloseTimeMillis = millis(r.CloseTime)
+	return r.CloseTime
+}
+
+// ParquetTransaction represents transaction row of Parquet export
+type ParquetTransaction struct {
+	ID              string `json:"id" parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Seq             int64  `json:"seq" parquet:"name=seq, type=INT64"`
+	PagingToken     string `json:"paging_token" parquet:"name=paging_token, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Index           int32  `json:"idx" parquet:"name=idx, type=INT32"`
+	ApplyIndex      int32  `json:"apply_index" parquet:"name=apply_index, type=INT32"`
+	CloseTimeMillis int64  `parquet:"name=close_time, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Successful      bool   `json:"successful" parquet:"name=successful, type=BOOLEAN"`
+	ResultCode      int32  `json:"result_code" parquet:"name=result_code, type=INT32"`
+	SourceAccountID string `json:"source_account_id" parquet:"name=source_account_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	FeeAccountID    string `json:"fee_account_id" parquet:"name=fee_account_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	MaxFee          int64  `json:"max_fee" parquet:"name=max_fee, type=INT64"`
+	FeeCharged      int64  `json:"fee_charged" parquet:"name=fee_charged, type=INT64"`
+	OperationCount  int32  `json:"operation_count" parquet:"name=operation_count, type=INT32"`
+	EnvelopeSize    int32  `json:"envelope_size" parquet:"name=envelope_size, type=INT32"`
+	InnerTxID       string `json:"inner_tx_id" parquet:"name=inner_tx_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	MemoType        int32  `parquet:"name=memo_type, type=INT32"`
+	MemoValue       string `parquet:"name=memo_value, type=BYTE_ARRAY, convertedtype=UTF8"`
+
+	CloseTime time.Time `json:"close_time"`
+	Memo      *Memo     `json:"memo"`
+}
+
+func (r *ParquetTransaction) convert() time.Time {
+	r.CloseTimeMillis = millis(r.CloseTime)
+
+	if r.Memo != nil {
+		r.MemoType = int32(r.Memo.Type)
+		r.MemoValue = r.Memo.Value
+	}
+
+	return r.CloseTime
+}
+
+// ParquetOperation represents operation row of Parquet export
+type ParquetOperation struct {
+	ID                   string  `json:"id" parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	TxID                 string  `json:"tx_id" parquet:"name=tx_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	TxIndex              int32   `json:"tx_idx" parquet:"name=tx_idx, type=INT32"`
+	Index                int32   `json:"idx" parquet:"name=idx, type=INT32"`
+	Seq                  int64   `json:"seq" parquet:"name=seq, type=INT64"`
+	PagingToken          string  `json:"paging_token" parquet:"name=paging_token, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CloseTimeMillis      int64   `parquet:"name=close_time, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Successful           bool    `json:"successful" parquet:"name=successful, type=BOOLEAN"`
+	ResultCode           int32   `json:"result_code" parquet:"name=result_code, type=INT32"`
+	Type                 string  `json:"type" parquet:"name=type, type=BYTE_ARRAY, convertedtype=UTF8"`
+	TxSourceAccountID    string  `json:"tx_source_account_id" parquet:"name=tx_source_account_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SourceAccountID      string  `json:"source_account_id" parquet:"name=source_account_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DestinationAccountID string  `json:"destination_account_id" parquet:"name=destination_account_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SourceAssetID        string  `parquet:"name=source_asset_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SourceAmount         string  `json:"source_amount" parquet:"name=source_amount, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DestinationAssetID   string  `parquet:"name=destination_asset_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DestinationAmount    string  `json:"destination_amount" parquet:"name=destination_amount, type=BYTE_ARRAY, convertedtype=UTF8"`
+	OfferID              int64   `json:"offer_id" parquet:"name=offer_id, type=INT64"`
+	OfferPrice           float64 `json:"offer_price" parquet:"name=offer_price, type=DOUBLE"`
+
+	CloseTime        time.Time `json:"close_time"`
+	SourceAsset      *Asset    `json:"source_asset"`
+	DestinationAsset *Asset    `json:"destination_asset"`
+}
+
+func (r *ParquetOperation) convert() time.Time {
+	r.CloseTimeMillis = millis(r.CloseTime)
+
+	if r.SourceAsset != nil {
+		r.SourceAssetID = r.SourceAsset.ID
+	}
+
+	if r.DestinationAsset != nil {
+		r.DestinationAssetID = r.DestinationAsset.ID
+	}
+
+	return r.CloseTime
+}
+
+// ParquetBalance represents balance row of Parquet export
+type ParquetBalance struct {
+	ID              string `json:"id" parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PagingToken     string `json:"paging_token" parquet:"name=paging_token, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AccountID       string `json:"account_id" parquet:"name=account_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AssetID         string `parquet:"name=asset_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Value           string `json:"value" parquet:"name=value, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Diff            string `json:"diff" parquet:"name=diff, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Positive        bool   `json:"positive" parquet:"name=positive, type=BOOLEAN"`
+	Source          string `json:"source" parquet:"name=source, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Removed         bool   `json:"removed" parquet:"name=removed, type=BOOLEAN"`
+	CreatedAtMillis int64  `parquet:"name=created_at, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+
+	CreatedAt time.Time `json:"created_at"`
+	Asset     Asset     `json:"asset"`
+}
+
+func (r *ParquetBalance) convert() time.Time {
+	r.CreatedAtMillis = millis(r.CreatedAt)
+	r.AssetID = r.Asset.ID
+
+	return r.CreatedAt
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
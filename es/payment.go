@@ -0,0 +1,89 @@
+package es
+
+import (
+	"time"
+)
+
+// Payment represents payment-like operation flattened for wallet history queries
+type Payment struct {
+	ID           string      `json:"id"`
+	PagingToken  PagingToken `json:"paging_token"`
+	TxID         string      `json:"tx_id"`
+	Seq          int         `json:"seq"`
+	CloseTime    time.Time   `json:"close_time"`
+	Type         string      `json:"type"`
+	From         string      `json:"from"`
+	To           string      `json:"to"`
+	Asset        *Asset      `json:"asset"`
+	Amount       string      `json:"amount"`
+	SourceAsset  *Asset      `json:"source_asset,omitempty"`
+	SourceAmount string      `json:"source_amount,omitempty"`
+
+	*Memo `json:"memo,omitempty"`
+
+	// MemoRequired is true if the destination requires memo on incoming payments
+	MemoRequired bool `json:"memo_required,omitempty"`
+}
+
+// NewPayment creates Payment from the operation, returns nil if the operation does not move funds between accounts
+func NewPayment(op *Operation) *Payment {
+	payment := &Payment{
+		ID:          op.PagingToken.String(),
+		PagingToken: op.PagingToken,
+		TxID:        op.TxID,
+		Seq:         op.Seq,
+		CloseTime:   op.CloseTime,
+		Type:        op.Type,
+		From:        op.SourceAccountID,
+		To:          op.DestinationAccountID,
+		Memo:        op.Memo,
+
+		MemoRequired: op.MemoRequired,
+	}
+
+	switch op.Type {
+	case "CreateAccount":
+		payment.Asset = NewNativeAsset()
+		payment.Amount = op.SourceAmount
+	case "Payment":
+		payment.Asset = op.SourceAsset
+		payment.Amount = op.SourceAmount
+	case "PathPaymentStrictReceive":
+		payment.Asset = op.DestinationAsset
+		payment.Amount = op.DestinationAmount
+		payment.SourceAsset = op.SourceAsset
+		payment.SourceAmount = op.SourceAmount
+	case "PathPaymentStrictSend":
+		payment.Asset = op.DestinationAsset
+		payment.Amount = op.DestinationAmount
+		payment.SourceAsset = op.SourceAsset
+		payment.SourceAmount = op.SourceAmount
+	case "AccountMerge":
+		payment.Asset = NewNativeAsset()
+		payment.Amount = op.ResultSourceAccountBalance
+	default:
+		return nil
+	}
+
+	return payment
+}
+
+// isPaymentToMemoRequired returns true if the operation sends funds to an existing account requiring memo
+func isPaymentToMemoRequired(op *Operation, memoRequired map[string]bool) bool {
+	switch op.Type {
+	case "Payment", "PathPaymentStrictReceive", "PathPaymentStrictSend", "AccountMerge":
+		return memoRequired[op.DestinationAccountID]
+	}
+
+	return false
+}
+
+// DocID returns es id
+func (p *Payment) DocID() *string {
+	return &p.ID
+}
+
+// IndexName returns index name
+func (p *Payment) IndexName() IndexName {
+	return paymentsIndexName
+}
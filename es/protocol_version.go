@@ -0,0 +1,27 @@
+package es
+
+// ProtocolVersion represents ledger protocol version
+type ProtocolVersion int
+
+// Feature represents the protocol feature which has to be gated by protocol version
+type Feature int
+
+const (
+	// FeatureFeeBump fee bump transactions (CAP-15)
+	FeatureFeeBump Feature = iota
+
+	// FeatureSoroban smart contracts meta (CAP-46)
+	FeatureSoroban
+)
+
+var featureVersions = map[Feature]ProtocolVersion{
+	FeatureFeeBump: 13,
+	FeatureSoroban: 20,
+}
+
+// Supports returns true if the feature is valid for the given protocol version
+func (v ProtocolVersion) Supports(f Feature) bool {
+	min, ok := featureVersions[f]
+
+	return ok && v >= min
+}
@@ -0,0 +1,109 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	goES "github.com/elastic/go-elasticsearch/v7"
+
+	"github.com/astroband/astrologer/es"
+)
+
+// Client runs queries against Astrologer indices
+type Client struct {
+	es *goES.Client
+}
+
+type searchResponse struct {
+	Hits struct {
+		Hits []struct {
+			Source json.RawMessage `json:"_source"`
+			Sort   []interface{}   `json:"sort"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// New creates Client on top of ElasticSearch client
+func New(client *goES.Client) *Client {
+	return &Client{es: client}
+}
+
+// Ledgers returns a page of ledger headers and the cursor of the next page
+func (c *Client) Ledgers(ctx context.Context, q *Query) (r []es.LedgerHeader, next string, err error) {
+	next, err = c.Search(ctx, q, &r)
+	return
+}
+
+// Transactions returns a page of transactions and the cursor of the next page
+func (c *Client) Transactions(ctx context.Context, q *Query) (r []es.Transaction, next string, err error) {
+	next, err = c.Search(ctx, q, &r)
+	return
+}
+
+// Operations returns a page of operations and the cursor of the next page
+func (c *Client) Operations(ctx context.Context, q *Query) (r []es.Operation, next string, err error) {
+	next, err = c.Search(ctx, q, &r)
+	return
+}
+
+// Balances returns a page of balance changes and the cursor of the next page
+func (c *Client) Balances(ctx context.Context, q *Query) (r []es.Balance, next string, err error) {
+	next, err = c.Search(ctx, q, &r)
+	return
+}
+
+// Search runs the query decoding documents into dest, which must be a pointer to a slice.
+// Returns the cursor of the next page, empty if there are no more results.
+func (c *Client) Search(ctx context.Context, q *Query, dest interface{}) (next string, err error) {
+	var buf bytes.Buffer
+	var r searchResponse
+
+	if err = json.NewEncoder(&buf).Encode(q.Body()); err != nil {
+		return "", err
+	}
+
+	res, err := c.es.Search(
+		c.es.Search.WithContext(ctx),
+		c.es.Search.WithIndex(es.IndexPrefix+q.index.name),
+		c.es.Search.WithBody(&buf),
+	)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return "", fmt.Errorf("search in %s failed: %s", q.index.name, res.String())
+	}
+
+	if err = json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return "", err
+	}
+
+	sources := make([]json.RawMessage, len(r.Hits.Hits))
+	for i, hit := range r.Hits.Hits {
+		sources[i] = hit.Source
+	}
+
+	raw, err := json.Marshal(sources)
+	if err != nil {
+		return "", err
+	}
+
+	if err = json.Unmarshal(raw, dest); err != nil {
+		return "", err
+	}
+
+	if len(r.Hits.Hits) < q.limit {
+		return "", nil
+	}
+
+	last := r.Hits.Hits[len(r.Hits.Hits)-1]
+	if len(last.Sort) > 0 {
+		next, _ = last.Sort[0].(string)
+	}
+
+	return next, nil
+}
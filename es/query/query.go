@@ -0,0 +1,192 @@
+// Package query provides typed query builders over Astrologer indices for Go services consuming the exported data.
+//
+//	q := query.Operations().ByAccount("GABC...").Between(from, to).Limit(50)
+//	ops, next, err := client.Operations(ctx, q)
+//	ops, next, err = client.Operations(ctx, q.Cursor(next))
+package query
+
+import (
+	"time"
+
+	"github.com/astroband/astrologer/paging"
+)
+
+const defaultLimit = 20
+
+// Order represents sort order of results by paging token
+type Order string
+
+const (
+	// Asc returns the oldest records first
+	Asc Order = "asc"
+
+	// Desc returns the newest records first
+	Desc Order = "desc"
+)
+
+// index describes fields of the queried index
+type index struct {
+	name          string
+	timeField     string
+	accountFields []string
+	assetFields   []string
+}
+
+var (
+	ledgerIndex = index{name: "ledger", timeField: "close_time"}
+
+	txIndex = index{
+		name:          "tx",
+		timeField:     "close_time",
+		accountFields: []string{"source_account_id", "fee_account_id"},
+	}
+
+	opIndex = index{
+		name:          "op",
+		timeField:     "close_time",
+		accountFields: []string{"source_account_id", "tx_source_account_id", "destination_account_id"},
+		assetFields:   []string{"source_asset.id", "destination_asset.id"},
+	}
+
+	balanceIndex = index{
+		name:          "balance",
+		timeField:     "created_at",
+		accountFields: []string{"account_id"},
+		assetFields:   []string{"asset.id"},
+	}
+)
+
+// Query represents search over one of Astrologer indices
+type Query struct {
+	index   index
+	filters []map[string]interface{}
+	cursor  string
+	limit   int
+	order   Order
+}
+
+// Ledgers starts query over ledger headers
+func Ledgers() *Query { return newQuery(ledgerIndex) }
+
+// Transactions starts query over transactions
+func Transactions() *Query { return newQuery(txIndex) }
+
+// Operations starts query over operations
+func Operations() *Query { return newQuery(opIndex) }
+
+// Balances starts query over balance changes
+func Balances() *Query { return newQuery(balanceIndex) }
+
+func newQuery(i index) *Query {
+	return &Query{index: i, limit: defaultLimit, order: Desc}
+}
+
+// ByAccount limits results to records involving the account, ignored for ledgers
+func (q *Query) ByAccount(id string) *Query {
+	return q.anyOf(q.index.accountFields, id)
+}
+
+// ByAsset limits results to records involving the asset id ("native" or "CODE:ISSUER"), ignored for ledgers and transactions
+func (q *Query) ByAsset(id string) *Query {
+	return q.anyOf(q.index.assetFields, id)
+}
+
+// Range limits results to the inclusive range of ledgers
+func (q *Query) Range(first, last int) *Query {
+	return q.filter(map[string]interface{}{
+		"range": map[string]interface{}{
+			"paging_token": map[string]interface{}{
+				"gte": paging.ForLedger(first).String(),
+				"lt":  paging.ForLedger(last + 1).String(),
+			},
+		},
+	})
+}
+
+// Between limits results to records closed within [from, to)
+func (q *Query) Between(from, to time.Time) *Query {
+	return q.filter(map[string]interface{}{
+		"range": map[string]interface{}{
+			q.index.timeField: map[string]interface{}{
+				"gte": from,
+				"lt":  to,
+			},
+		},
+	})
+}
+
+// Cursor continues results after the given paging token, as returned by the previous page
+func (q *Query) Cursor(token string) *Query {
+	c := *q
+	c.cursor = token
+
+	return &c
+}
+
+// Limit sets page size
+func (q *Query) Limit(n int) *Query {
+	q.limit = n
+	return q
+}
+
+// Order sets sort order by paging token
+func (q *Query) Order(o Order) *Query {
+	q.order = o
+	return q
+}
+
+// Body returns ES search request body
+func (q *Query) Body() map[string]interface{} {
+	filters := q.filters
+
+	if q.cursor != "" {
+		op := "lt"
+		if q.order == Asc {
+			op = "gt"
+		}
+
+		filters = append(filters, map[string]interface{}{
+			"range": map[string]interface{}{
+				"paging_token": map[string]interface{}{op: q.cursor},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"size": q.limit,
+		"sort": []map[string]interface{}{
+			{"paging_token": string(q.order)},
+		},
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": filters,
+			},
+		},
+	}
+}
+
+func (q *Query) anyOf(fields []string, value string) *Query {
+	if len(fields) == 0 {
+		return q
+	}
+
+	should := make([]map[string]interface{}, len(fields))
+
+	for i, field := range fields {
+		should[i] = map[string]interface{}{
+			"term": map[string]interface{}{field: value},
+		}
+	}
+
+	return q.filter(map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should":               should,
+			"minimum_should_match": 1,
+		},
+	})
+}
+
+func (q *Query) filter(f map[string]interface{}) *Query {
+	q.filters = append(q.filters, f)
+	return q
+}
@@ -0,0 +1,220 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// reindexPollInterval is the interval of reindex task progress checks
+const reindexPollInterval = 10 * time.Second
+
+// Reindex copies documents of the index into the new index created with the current definition,
+// then points the index name to the new index as an alias. Script is the optional painless source
+// transforming documents. Old index is deleted if deleteOld is true, which is required when the name
+// belongs to the concrete index reindexed for the first time.
+func (es *Client) Reindex(name IndexName, script string, deleteOld bool) error {
+	if DataStreams[name] {
+		return fmt.Errorf("%s is a data stream, it can not be reindexed", name)
+	}
+
+	definitions, err := GetIndexDefinitions()
+	if err != nil {
+		return err
+	}
+
+	def, ok := definitions[name]
+	if !ok {
+		return fmt.Errorf("unknown index %s", name)
+	}
+
+	exists, err := es.IndexExists(name)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("%s index does not exist", name)
+	}
+
+	old, err := es.aliasedIndices(name)
+	if err != nil {
+		return err
+	}
+
+	// Alias can not share the name with the index, so the concrete index can not be kept
+	if old == nil && !deleteOld {
+		return fmt.Errorf("%s is a concrete index which is replaced by the alias, pass --delete-old to delete it", name)
+	}
+
+	target := fmt.Sprintf("%s-%s", name.String(), time.Now().UTC().Format("20060102150405"))
+
+	body, err := withSchemaVersion(name, def)
+	if err != nil {
+		return err
+	}
+
+	res, err := es.rawClient.Indices.Create(target, es.rawClient.Indices.Create.WithBody(strings.NewReader(body)))
+	if err := responseError(res, err); err != nil {
+		return fmt.Errorf("failed to create %s: %v", target, err)
+	}
+
+	res.Body.Close()
+
+	log.Printf("%s index created, reindexing %s into it", target, name)
+
+	if err := es.copyDocuments(name, target, script); err != nil {
+		return err
+	}
+
+	return es.swapAlias(name, target, deleteOld)
+}
+
+// copyDocuments runs _reindex as the background task and waits for its completion
+func (es *Client) copyDocuments(name IndexName, target, script string) error {
+	var task struct {
+		Task string `json:"task"`
+	}
+
+	request := map[string]interface{}{
+		"source": map[string]interface{}{"index": name.String()},
+		"dest":   map[string]interface{}{"index": target},
+	}
+
+	if script != "" {
+		request["script"] = map[string]interface{}{"lang": "painless", "source": script}
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	res, err := es.rawClient.Reindex(
+		strings.NewReader(string(body)),
+		es.rawClient.Reindex.WithWaitForCompletion(false),
+	)
+	if err := responseError(res, err); err != nil {
+		return fmt.Errorf("failed to reindex %s: %v", name, err)
+	}
+
+	if err := decodeBody(res.Body, &task); err != nil {
+		return err
+	}
+
+	for {
+		var status struct {
+			Completed bool `json:"completed"`
+			Task      struct {
+				Status struct {
+					Total   int `json:"total"`
+					Created int `json:"created"`
+				} `json:"status"`
+			} `json:"task"`
+			Error    json.RawMessage `json:"error"`
+			Response struct {
+				Failures []json.RawMessage `json:"failures"`
+			} `json:"response"`
+		}
+
+		time.Sleep(reindexPollInterval)
+
+		res, err := es.rawClient.Tasks.Get(task.Task)
+		if err := responseError(res, err); err != nil {
+			return fmt.Errorf("failed to check reindex task %s: %v", task.Task, err)
+		}
+
+		if err := decodeBody(res.Body, &status); err != nil {
+			return err
+		}
+
+		if len(status.Error) > 0 {
+			return fmt.Errorf("reindex of %s failed: %s", name, status.Error)
+		}
+
+		if len(status.Response.Failures) > 0 {
+			return fmt.Errorf("reindex of %s failed for %d documents, first: %s", name, len(status.Response.Failures), status.Response.Failures[0])
+		}
+
+		log.Printf("%s: %d of %d documents reindexed", name, status.Task.Status.Created, status.Task.Status.Total)
+
+		if status.Completed {
+			return nil
+		}
+	}
+}
+
+// aliasedIndices returns indices the alias points to, nil if the name belongs to the concrete index
+func (es *Client) aliasedIndices(name IndexName) ([]string, error) {
+	var aliases map[string]interface{}
+
+	res, err := es.rawClient.Indices.GetAlias(es.rawClient.Indices.GetAlias.WithName(name.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, nil
+	}
+
+	if err := responseError(res, nil); err != nil {
+		return nil, err
+	}
+
+	if err := decodeBody(res.Body, &aliases); err != nil {
+		return nil, err
+	}
+
+	indices := make([]string, 0, len(aliases))
+	for index := range aliases {
+		indices = append(indices, index)
+	}
+
+	return indices, nil
+}
+
+// swapAlias points the alias to the target index in a single atomic _aliases request. Index having
+// the name of the alias is removed in the same request, so readers never see the name missing.
+func (es *Client) swapAlias(name IndexName, target string, deleteOld bool) error {
+	old, err := es.aliasedIndices(name)
+	if err != nil {
+		return err
+	}
+
+	actions := []map[string]interface{}{
+		{"add": map[string]interface{}{"index": target, "alias": name.String()}},
+	}
+
+	if old == nil {
+		// The name belongs to the concrete index, it was never reindexed
+		actions = append(actions, map[string]interface{}{"remove_index": map[string]interface{}{"index": name.String()}})
+	}
+
+	for _, index := range old {
+		if deleteOld {
+			actions = append(actions, map[string]interface{}{"remove_index": map[string]interface{}{"index": index}})
+		} else {
+			actions = append(actions, map[string]interface{}{"remove": map[string]interface{}{"index": index, "alias": name.String()}})
+		}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return err
+	}
+
+	res, err := es.rawClient.Indices.UpdateAliases(strings.NewReader(string(body)))
+	if err := responseError(res, err); err != nil {
+		return fmt.Errorf("failed to point %s to %s: %v", name, target, err)
+	}
+
+	res.Body.Close()
+
+	log.Printf("%s now points to %s", name, target)
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SchemaVersion is the version of index definitions produced by this binary, bump it when documents change
+const SchemaVersion = 4
+
+// SchemaVersionError is returned when the index was created or last updated by a different schema version.
+// Found is zero for indices created before schema versioning was introduced.
+type SchemaVersionError struct {
+	Index    IndexName
+	Found    int
+	Expected int
+}
+
+func (e *SchemaVersionError) Error() string {
+	return fmt.Sprintf(
+		"%s index has schema version %d, this binary produces version %d, run create-index to update it",
+		e.Index, e.Found, e.Expected,
+	)
+}
+
+type schemaMeta struct {
+	SchemaVersion int `json:"schema_version"`
+}
+
+// CheckSchemaVersion compares schema versions stored in existing indices metadata with SchemaVersion,
+// returns *SchemaVersionError for the first mismatching index
+func (es *Client) CheckSchemaVersion() error {
+	var names []string
+
+	definitions, err := GetIndexDefinitions()
+	if err != nil {
+		return err
+	}
+
+	for name := range definitions {
+		names = append(names, string(name))
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		exists, err := es.IndexExists(IndexName(name))
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			continue
+		}
+
+		found, err := es.schemaVersionOf(IndexName(name))
+		if err != nil {
+			return err
+		}
+
+		if found != SchemaVersion {
+			return &SchemaVersionError{Index: IndexName(name), Found: found, Expected: SchemaVersion}
+		}
+	}
+
+	return nil
+}
+
+// SetSchemaVersion stores SchemaVersion in the index metadata
+func (es *Client) SetSchemaVersion(name IndexName) error {
+	body, err := json.Marshal(map[string]interface{}{"_meta": schemaMeta{SchemaVersion}})
+	if err != nil {
+		return err
+	}
+
+	res, err := es.rawClient.Indices.PutMapping(
+		strings.NewReader(string(body)),
+		es.rawClient.Indices.PutMapping.WithIndex(name.String()),
+	)
+	if err := responseError(res, err); err != nil {
+		return fmt.Errorf("failed to set %s schema version: %v", name, err)
+	}
+
+	res.Body.Close()
+
+	return nil
+}
+
+func (es *Client) schemaVersionOf(name IndexName) (int, error) {
+	var live map[string]struct {
+		Mappings struct {
+			Meta schemaMeta `json:"_meta"`
+		} `json:"mappings"`
+	}
+
+	res, err := es.rawClient.Indices.GetMapping(es.rawClient.Indices.GetMapping.WithIndex(name.String()))
+	if err := responseError(res, err); err != nil {
+		return 0, fmt.Errorf("failed to get %s mapping: %v", name, err)
+	}
+
+	if err := decodeBody(res.Body, &live); err != nil {
+		return 0, err
+	}
+
+	// Keyed by the concrete index name, which differs from the alias of reindexed indices
+	for _, index := range live {
+		return index.Mappings.Meta.SchemaVersion, nil
+	}
+
+	return 0, nil
+}
+
+// withSchemaVersion adds SchemaVersion to the index definition metadata
+func withSchemaVersion(name IndexName, body IndexDefinition) (string, error) {
+	var def map[string]interface{}
+
+	if err := json.Unmarshal([]byte(body), &def); err != nil {
+		return "", fmt.Errorf("invalid %s index definition: %v", name, err)
+	}
+
+	mappings, _ := def["mappings"].(map[string]interface{})
+	if mappings == nil {
+		mappings = make(map[string]interface{})
+		def["mappings"] = mappings
+	}
+
+	mappings["_meta"] = schemaMeta{SchemaVersion}
+
+	result, err := json.Marshal(def)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
@@ -4,18 +4,61 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"log"
+	"time"
 )
 
+// VersionedIndexable represents object stored under its own id, where only a newer version may overwrite the document
+type VersionedIndexable interface {
+	Indexable
+	Version() (int64, error)
+}
+
 // SerializeForBulk returns object serialized for elastic bulk indexing
-func SerializeForBulk(obj Indexable, b *bytes.Buffer) {
-	meta := fmt.Sprintf(
-		`{ "index": { "_index": "%s", "_type": "_doc" } }%s`, obj.IndexName(), "\n",
-	)
+func SerializeForBulk(obj Indexable, b *bytes.Buffer) error {
+	return serializeForBulk(obj, b, nil, false)
+}
+
+// serializeForBulk serializes object adding @timestamp field if timestamp is given, _id if docID is true
+func serializeForBulk(obj Indexable, b *bytes.Buffer, timestamp *time.Time, docID bool) error {
+	var meta string
+
+	if DataStreams[obj.IndexName()] && docID {
+		meta = fmt.Sprintf(
+			`{ "create": { "_index": "%s", "_id": "%s" } }%s`, obj.IndexName().String(), *obj.DocID(), "\n",
+		)
+	} else if DataStreams[obj.IndexName()] {
+		meta = fmt.Sprintf(
+			`{ "create": { "_index": "%s" } }%s`, obj.IndexName().String(), "\n",
+		)
+	} else if v, ok := obj.(VersionedIndexable); ok {
+		version, err := v.Version()
+		if err != nil {
+			return fmt.Errorf("%s document %s: %v", obj.IndexName(), *obj.DocID(), err)
+		}
+
+		meta = fmt.Sprintf(
+			`{ "index": { "_index": "%s", "_id": "%s", "version": %d, "version_type": "external_gte" } }%s`,
+			obj.IndexName().String(), *obj.DocID(), version, "\n",
+		)
+	} else if docID {
+		meta = fmt.Sprintf(
+			`{ "index": { "_index": "%s", "_id": "%s" } }%s`, obj.IndexName().String(), *obj.DocID(), "\n",
+		)
+	} else {
+		meta = fmt.Sprintf(
+			`{ "index": { "_index": "%s" } }%s`, obj.IndexName().String(), "\n",
+		)
+	}
 
 	data, err := json.Marshal(obj)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("%s document: %v", obj.IndexName(), err)
+	}
+
+	data = withNetwork(data)
+
+	if timestamp != nil {
+		data = withTimestamp(data, *timestamp)
 	}
 
 	data = append(data, "\n"...)
@@ -23,4 +66,17 @@ func SerializeForBulk(obj Indexable, b *bytes.Buffer) {
 	b.Grow(len(meta) + len(data))
 	b.Write([]byte(meta))
 	b.Write(data)
+
+	return nil
+}
+
+// withTimestamp inserts @timestamp as the first field of serialized object
+func withTimestamp(data []byte, timestamp time.Time) []byte {
+	field := fmt.Sprintf(`{"@timestamp":"%s"`, timestamp.UTC().Format(time.RFC3339Nano))
+
+	if len(data) > 2 {
+		field += ","
+	}
+
+	return append([]byte(field), data[1:]...)
 }
@@ -0,0 +1,77 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// SettingsOverrides holds index settings replacing settings of index definitions, e.g. number_of_shards.
+// Settings of AllIndices apply to every index, settings of the index override them.
+var SettingsOverrides = make(map[IndexName]map[string]interface{})
+
+// ReadSettingsOverrides reads the JSON file of index settings keyed by index name or "*"
+func ReadSettingsOverrides(path string) (map[IndexName]map[string]interface{}, error) {
+	var overrides map[IndexName]map[string]interface{}
+
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(body, &overrides); err != nil {
+		return nil, fmt.Errorf("invalid settings file %s: %v", path, err)
+	}
+
+	definitions, err := GetIndexDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	for name := range overrides {
+		if _, ok := definitions[name]; !ok && name != AllIndices {
+			return nil, fmt.Errorf("unknown index %s in settings file %s", name, path)
+		}
+	}
+
+	return overrides, nil
+}
+
+// withSettingsOverrides puts SettingsOverrides into the index settings of the definition
+func withSettingsOverrides(name IndexName, body IndexDefinition) (IndexDefinition, error) {
+	if len(SettingsOverrides[AllIndices]) == 0 && len(SettingsOverrides[name]) == 0 {
+		return body, nil
+	}
+
+	var def map[string]interface{}
+
+	if err := json.Unmarshal([]byte(body), &def); err != nil {
+		return "", fmt.Errorf("invalid %s index definition: %v", name, err)
+	}
+
+	settings, _ := def["settings"].(map[string]interface{})
+	if settings == nil {
+		settings = make(map[string]interface{})
+		def["settings"] = settings
+	}
+
+	index, _ := settings["index"].(map[string]interface{})
+	if index == nil {
+		index = make(map[string]interface{})
+		settings["index"] = index
+	}
+
+	for _, overrides := range []map[string]interface{}{SettingsOverrides[AllIndices], SettingsOverrides[name]} {
+		for key, value := range overrides {
+			index[strings.TrimPrefix(key, "index.")] = value
+		}
+	}
+
+	result, err := json.Marshal(def)
+	if err != nil {
+		return "", err
+	}
+
+	return IndexDefinition(result), nil
+}
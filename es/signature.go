@@ -0,0 +1,129 @@
+package es
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/astroband/astrologer/db"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+)
+
+// Signature represents transaction decorated signature
+type Signature struct {
+	Hint      string `json:"hint"`
+	Signature string `json:"signature"`
+	Signer    string `json:"signer,omitempty"`
+	Inner     bool   `json:"inner,omitempty"`
+}
+
+// signerHints maps signature hint to the signer key which might have produced the signature
+type signerHints map[string]string
+
+// NewSignatures returns transaction signatures, resolving signer keys from envelope and meta where possible
+func NewSignatures(row *db.TxHistoryRow) []Signature {
+	envelope := row.Envelope
+	hints := make(signerHints)
+
+	hints.addMuxedAccount(envelope.SourceAccount())
+
+	for _, op := range envelope.Operations() {
+		if op.SourceAccount != nil {
+			hints.addMuxedAccount(*op.SourceAccount)
+		}
+	}
+
+	if meta, err := decodeMeta(row.Meta); err == nil {
+		for _, change := range meta.Changes() {
+			hints.addChange(change)
+		}
+	}
+
+	if envelope.IsFeeBump() {
+		hints.addMuxedAccount(envelope.FeeBumpAccount())
+	}
+
+	signatures := hints.decorate(envelope.Signatures(), false)
+
+	if envelope.IsFeeBump() {
+		signatures = append(signatures, hints.decorate(envelope.FeeBump.Tx.InnerTx.V1.Signatures, true)...)
+	}
+
+	return signatures
+}
+
+func (h signerHints) decorate(source []xdr.DecoratedSignature, inner bool) []Signature {
+	signatures := make([]Signature, len(source))
+
+	for i, s := range source {
+		hint := hex.EncodeToString(s.Hint[:])
+
+		signatures[i] = Signature{
+			Hint:      hint,
+			Signature: base64.StdEncoding.EncodeToString(s.Signature),
+			Signer:    h[hint],
+			Inner:     inner,
+		}
+	}
+
+	return signatures
+}
+
+func (h signerHints) addKey(key xdr.Uint256, version strkey.VersionByte) {
+	address, err := strkey.Encode(version, key[:])
+
+	if err != nil {
+		return
+	}
+
+	h[hex.EncodeToString(key[len(key)-4:])] = address
+}
+
+func (h signerHints) addAccountID(a xdr.AccountId) {
+	if a.Ed25519 != nil {
+		h.addKey(*a.Ed25519, strkey.VersionByteAccountID)
+	}
+}
+
+func (h signerHints) addMuxedAccount(a xdr.MuxedAccount) {
+	h.addAccountID(a.ToAccountId())
+}
+
+func (h signerHints) addSignerKey(k xdr.SignerKey) {
+	switch k.Type {
+	case xdr.SignerKeyTypeSignerKeyTypeEd25519:
+		h.addKey(k.MustEd25519(), strkey.VersionByteAccountID)
+	case xdr.SignerKeyTypeSignerKeyTypePreAuthTx:
+		h.addKey(k.MustPreAuthTx(), strkey.VersionByteHashTx)
+	case xdr.SignerKeyTypeSignerKeyTypeHashX:
+		h.addKey(k.MustHashX(), strkey.VersionByteHashX)
+	}
+}
+
+func (h signerHints) addChange(change xdr.LedgerEntryChange) {
+	var entry *xdr.LedgerEntry
+
+	switch change.Type {
+	case xdr.LedgerEntryChangeTypeLedgerEntryState:
+		e := change.MustState()
+		entry = &e
+	case xdr.LedgerEntryChangeTypeLedgerEntryCreated:
+		e := change.MustCreated()
+		entry = &e
+	case xdr.LedgerEntryChangeTypeLedgerEntryUpdated:
+		e := change.MustUpdated()
+		entry = &e
+	}
+
+	if entry == nil {
+		return
+	}
+
+	if account, ok := entry.Data.GetAccount(); ok {
+		h.addAccountID(account.AccountId)
+
+		for _, signer := range account.Signers {
+			h.addSignerKey(signer.Key)
+		}
+	}
+}
@@ -2,11 +2,18 @@ package es
 
 import "github.com/stellar/go/xdr"
 
+var signerTypeNames = map[xdr.SignerKeyType]string{
+	xdr.SignerKeyTypeSignerKeyTypeEd25519:   "ed25519",
+	xdr.SignerKeyTypeSignerKeyTypePreAuthTx: "pre_auth_tx",
+	xdr.SignerKeyTypeSignerKeyTypeHashX:     "hash_x",
+}
+
 // Signer represents signer as export
 type Signer struct {
-	ID     string `json:"id"`
-	Weight int    `json:"weight"`
-	Type   int    `json:"type"`
+	ID       string `json:"id"`
+	Weight   int    `json:"weight"`
+	Type     int    `json:"type"`
+	TypeName string `json:"type_name"`
 }
 
 // NewSigner returns new Signer
@@ -19,5 +26,6 @@ func NewSigner(signer *xdr.Signer) *Signer {
 		signer.Key.Address(),
 		int(signer.Weight),
 		int(signer.Key.Type),
+		signerTypeNames[signer.Key.Type],
 	}
 }
@@ -11,7 +11,9 @@ type SignerHistory struct {
 	AccountID       string      `json:"account_id"`
 	Signer          string      `json:"signer"`
 	Type            int         `json:"type"`
+	TypeName        string      `json:"type_name"`
 	Weight          int         `json:"weight"`
+	Removed         bool        `json:"removed"`
 	TxIndex         int         `json:"tx_idx"`
 	Index           int         `json:"idx"`
 	Seq             int         `json:"seq"`
@@ -38,7 +40,9 @@ func ProduceSignerHistory(o *Operation) (h *SignerHistory) {
 		AccountID:       o.SourceAccountID,
 		Signer:          o.Signer.ID,
 		Type:            o.Signer.Type,
+		TypeName:        o.Signer.TypeName,
 		Weight:          o.Signer.Weight,
+		Removed:         o.Signer.Weight == 0,
 		TxIndex:         o.TxIndex,
 		Index:           o.Index,
 		Seq:             o.Seq,
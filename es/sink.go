@@ -0,0 +1,101 @@
+package es
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+)
+
+// Sink represents the document output: ElasticSearch cluster, files or message queue
+type Sink interface {
+	// CreateSchema creates indices or their counterparts, force recreates existing ones
+	CreateSchema(force bool) error
+
+	// BulkWrite writes documents of the bulk NDJSON payload
+	BulkWrite(payload *bytes.Buffer) error
+
+	// IndexWithRetries writes the payload retrying failures, zero retryCount means retry forever.
+	// Returns the number of retries made, error if retries are exhausted.
+	IndexWithRetries(payload *bytes.Buffer, retryCount int) (retries int, err error)
+}
+
+// unwrapper is implemented by sinks wrapping other sinks
+type unwrapper interface {
+	Unwrap() Sink
+}
+
+// AsAdapter returns ElasticSearch Adapter behind the sink, false if the output is write-only.
+// Wrapped sinks are unwrapped, reads of FanOut are served by its primary output.
+func AsAdapter(s Sink) (Adapter, bool) {
+	for {
+		if a, ok := s.(Adapter); ok {
+			return a, true
+		}
+
+		w, ok := s.(unwrapper)
+		if !ok {
+			return nil, false
+		}
+
+		s = w.Unwrap()
+	}
+}
+
+// SinkOptions represents settings of outputs given by command line flags
+type SinkOptions struct {
+	MaxFileSize int64
+	KafkaFormat string
+
+	// APIKey and CloudID are used to connect to Elastic Cloud
+	APIKey  string
+	CloudID string
+
+	// Username and Password are used for basic authentication, override credentials in the URL
+	Username string
+	Password string
+
+	// CACert, ClientCert and ClientKey are PEM file paths
+	CACert             string
+	ClientCert         string
+	ClientKey          string
+	InsecureSkipVerify bool
+
+	// Gzip compresses bulk requests
+	Gzip bool
+}
+
+// SinkFactory creates Sink writing to the output URL
+type SinkFactory func(u *url.URL, options SinkOptions) (Sink, error)
+
+var sinks = map[string]SinkFactory{
+	"http":             openElasticSearch,
+	"https":            openElasticSearch,
+	"opensearch+http":  openOpenSearch,
+	"opensearch+https": openOpenSearch,
+	"file":             openFile,
+	"kafka":            openKafka,
+	"parquet+file":     openParquet,
+	"parquet+s3":       openParquet,
+	"parquet+gs":       openParquet,
+}
+
+// RegisterSink makes the output available by URL scheme, existing scheme is replaced
+func RegisterSink(scheme string, factory SinkFactory) {
+	sinks[scheme] = factory
+}
+
+// OpenSink creates Sink for the output URL
+func OpenSink(u *url.URL, options SinkOptions) (Sink, error) {
+	factory, ok := sinks[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported output %s", u)
+	}
+
+	// Failed factories may return nil client pointer wrapped into non-nil Sink
+	sink, err := factory(u, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s output: %v", u.Scheme, err)
+	}
+
+	return sink, nil
+}
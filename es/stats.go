@@ -0,0 +1,192 @@
+package es
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// StatsPeriodLedger stats document covering a single ledger
+	StatsPeriodLedger = "ledger"
+
+	// StatsPeriodHour stats document covering all ledgers closed within an hour
+	StatsPeriodHour = "hour"
+
+	statsAccountsPrecision = 40000
+)
+
+// Stats represents precomputed network statistics for the ledger or the hour
+type Stats struct {
+	ID             string    `json:"id"`
+	Period         string    `json:"period"`
+	Seq            int       `json:"seq,omitempty"`
+	Time           time.Time `json:"time"`
+	LedgerCount    int       `json:"ledger_count"`
+	TxCount        int       `json:"tx_count"`
+	FailedTxCount  int       `json:"failed_tx_count"`
+	OpCount        int       `json:"op_count"`
+	FeeCharged     int       `json:"fee_charged"`
+	UniqueAccounts int       `json:"unique_accounts"`
+	Accounts       []string  `json:"accounts,omitempty"`
+
+	version int64
+	seen    map[string]bool
+}
+
+type statsRollupResponse struct {
+	Aggregations struct {
+		LedgerCount   struct{ Value float64 } `json:"ledger_count"`
+		TxCount       struct{ Value float64 } `json:"tx_count"`
+		FailedTxCount struct{ Value float64 } `json:"failed_tx_count"`
+		OpCount       struct{ Value float64 } `json:"op_count"`
+		FeeCharged    struct{ Value float64 } `json:"fee_charged"`
+		Accounts      struct{ Value float64 } `json:"accounts"`
+	} `json:"aggregations"`
+}
+
+// NewLedgerStats creates empty Stats for the ledger
+func NewLedgerStats(ledger *LedgerHeader) *Stats {
+	return &Stats{
+		ID:          fmt.Sprintf("%s-%d", StatsPeriodLedger, ledger.Seq),
+		Period:      StatsPeriodLedger,
+		Seq:         ledger.Seq,
+		Time:        ledger.CloseTime,
+		LedgerCount: 1,
+		version:     int64(ledger.Seq),
+		seen:        make(map[string]bool),
+	}
+}
+
+// AddTransaction accounts transaction in stats
+func (s *Stats) AddTransaction(transaction *Transaction) {
+	s.TxCount++
+	s.OpCount += transaction.OperationCount
+	s.FeeCharged += transaction.FeeCharged
+
+	if !transaction.Successful {
+		s.FailedTxCount++
+	}
+
+	s.addAccount(transaction.SourceAccountID)
+	s.addAccount(transaction.FeeAccountID)
+}
+
+// AddOperation accounts operation source account in stats
+func (s *Stats) AddOperation(operation *Operation) {
+	s.addAccount(operation.SourceAccountID)
+}
+
+func (s *Stats) addAccount(id string) {
+	if id == "" || s.seen[id] {
+		return
+	}
+
+	s.seen[id] = true
+	s.Accounts = append(s.Accounts, id)
+	s.UniqueAccounts = len(s.Accounts)
+}
+
+// RollupHourlyStats aggregates ledger stats within the given hours into hourly stats documents
+func (es *Client) RollupHourlyStats(hours []time.Time) error {
+	var b bytes.Buffer
+
+	if len(hours) == 0 {
+		return nil
+	}
+
+	res, err := es.rawClient.Indices.Refresh(es.rawClient.Indices.Refresh.WithIndex(statsIndexName.String()))
+	if err := closeResponse(res, err); err != nil {
+		return fmt.Errorf("failed to refresh %s: %v", statsIndexName, err)
+	}
+
+	for _, hour := range hours {
+		stats, err := es.hourlyStats(hour.Truncate(time.Hour))
+		if err != nil {
+			return err
+		}
+
+		if err := SerializeForBulk(stats, &b); err != nil {
+			return err
+		}
+	}
+
+	if _, err := es.IndexWithRetries(&b, 0); err != nil {
+		return err
+	}
+
+	log.Println("Stats rolled up for", len(hours), "hour(s)")
+
+	return nil
+}
+
+func (es *Client) hourlyStats(hour time.Time) (*Stats, error) {
+	var r statsRollupResponse
+
+	sum := func(field string) map[string]interface{} {
+		return map[string]interface{}{"sum": map[string]interface{}{"field": field}}
+	}
+
+	query := map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []map[string]interface{}{
+					{"term": map[string]interface{}{"period": StatsPeriodLedger}},
+					{"range": map[string]interface{}{"time": map[string]interface{}{
+						"gte": hour,
+						"lt":  hour.Add(time.Hour),
+					}}},
+				},
+			},
+		},
+		"aggs": map[string]interface{}{
+			"ledger_count":    sum("ledger_count"),
+			"tx_count":        sum("tx_count"),
+			"failed_tx_count": sum("failed_tx_count"),
+			"op_count":        sum("op_count"),
+			"fee_charged":     sum("fee_charged"),
+			"accounts": map[string]interface{}{
+				"cardinality": map[string]interface{}{
+					"field":               "accounts",
+					"precision_threshold": statsAccountsPrecision,
+				},
+			},
+		},
+	}
+
+	if err := es.search(statsIndexName, query, &r); err != nil {
+		return nil, err
+	}
+
+	aggs := r.Aggregations
+
+	return &Stats{
+		ID:             fmt.Sprintf("%s-%d", StatsPeriodHour, hour.Unix()),
+		Period:         StatsPeriodHour,
+		Time:           hour,
+		LedgerCount:    int(aggs.LedgerCount.Value),
+		TxCount:        int(aggs.TxCount.Value),
+		FailedTxCount:  int(aggs.FailedTxCount.Value),
+		OpCount:        int(aggs.OpCount.Value),
+		FeeCharged:     int(aggs.FeeCharged.Value),
+		UniqueAccounts: int(aggs.Accounts.Value),
+		version:        time.Now().UnixNano(),
+	}, nil
+}
+
+// DocID returns es id
+func (s *Stats) DocID() *string {
+	return &s.ID
+}
+
+// IndexName returns index name
+func (s *Stats) IndexName() IndexName {
+	return statsIndexName
+}
+
+// Version returns document version, ledger stats are immutable, hourly stats are replaced by later rollups
+func (s *Stats) Version() int64 {
+	return s.version
+}
@@ -0,0 +1,158 @@
+package es
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/astroband/astrologer/paging"
+)
+
+// IndexStats represents document count, disk usage and ledger range of the index
+type IndexStats struct {
+	Index IndexName
+	Docs  int64
+
+	// SizeBytes is the disk usage including replicas
+	SizeBytes int64
+
+	// MinLedger and MaxLedger are zero if the index is empty
+	MinLedger int
+	MaxLedger int
+}
+
+// IndexedLedgers returns the latest ledger of every index by the greatest paging token, missing and empty indices are skipped
+func (es *Client) IndexedLedgers(indices []IndexName) (map[IndexName]int, error) {
+	ledgers := make(map[IndexName]int, len(indices))
+
+	for _, index := range indices {
+		exists, err := es.IndexExists(index)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			continue
+		}
+
+		seq, ok, err := es.edgeLedger(index, "desc")
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			ledgers[index] = seq
+		}
+	}
+
+	return ledgers, nil
+}
+
+// IndicesStats returns stats of every index, missing indices are skipped
+func (es *Client) IndicesStats(indices []IndexName) ([]IndexStats, error) {
+	var stats []IndexStats
+
+	for _, index := range indices {
+		var r struct {
+			All struct {
+				Primaries struct {
+					Docs struct {
+						Count int64 `json:"count"`
+					} `json:"docs"`
+				} `json:"primaries"`
+				Total struct {
+					Store struct {
+						SizeInBytes int64 `json:"size_in_bytes"`
+					} `json:"store"`
+				} `json:"total"`
+			} `json:"_all"`
+		}
+
+		exists, err := es.IndexExists(index)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			continue
+		}
+
+		res, err := es.rawClient.Indices.Stats(
+			es.rawClient.Indices.Stats.WithIndex(index.String()),
+			es.rawClient.Indices.Stats.WithMetric("docs", "store"),
+		)
+		if err := responseError(res, err); err != nil {
+			return nil, fmt.Errorf("failed to get %s stats: %v", index, err)
+		}
+
+		if err := decodeBody(res.Body, &r); err != nil {
+			return nil, err
+		}
+
+		s := IndexStats{Index: index, Docs: r.All.Primaries.Docs.Count, SizeBytes: r.All.Total.Store.SizeInBytes}
+
+		if s.MinLedger, _, err = es.edgeLedger(index, "asc"); err != nil {
+			return nil, err
+		}
+
+		if s.MaxLedger, _, err = es.edgeLedger(index, "desc"); err != nil {
+			return nil, err
+		}
+
+		stats = append(stats, s)
+	}
+
+	return stats, nil
+}
+
+// edgeLedger returns the ledger of the first or the last document of the index by paging token, false if the index is empty
+func (es *Client) edgeLedger(index IndexName, order string) (int, bool, error) {
+	var r struct {
+		Hits struct {
+			Hits []struct {
+				Source struct {
+					PagingToken string `json:"paging_token"`
+				} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	query := map[string]interface{}{
+		"size":    1,
+		"_source": []string{"paging_token"},
+		"sort":    []map[string]interface{}{{"paging_token": order}},
+	}
+
+	if err := es.search(index, query, &r); err != nil {
+		return 0, false, err
+	}
+
+	if len(r.Hits.Hits) == 0 {
+		return 0, false, nil
+	}
+
+	token, err := paging.Parse(r.Hits.Hits[0].Source.PagingToken)
+	if err != nil {
+		log.Printf("%s: %v", index, err)
+		return 0, false, nil
+	}
+
+	return token.LedgerSeq, true, nil
+}
+
+// ClusterHealth returns the cluster health status: green, yellow or red
+func (es *Client) ClusterHealth() (string, error) {
+	var r struct {
+		Status string `json:"status"`
+	}
+
+	res, err := es.rawClient.Cluster.Health()
+	if err := responseError(res, err); err != nil {
+		return "", fmt.Errorf("failed to get cluster health: %v", err)
+	}
+
+	if err := decodeBody(res.Body, &r); err != nil {
+		return "", err
+	}
+
+	return r.Status, nil
+}
@@ -0,0 +1,46 @@
+package es
+
+import (
+	"fmt"
+)
+
+// TimestampSource represents the time used as @timestamp of documents
+type TimestampSource string
+
+const (
+	// TimestampClose uses ledger close time, backfilled documents land in ILM phases matching their age
+	TimestampClose TimestampSource = "close"
+
+	// TimestampIngest uses the time document was produced
+	TimestampIngest TimestampSource = "ingest"
+
+	// AllIndices configures timestamp source for indices not configured explicitly
+	AllIndices IndexName = "*"
+)
+
+// ParseTimestampSources parses index=source pairs
+func ParseTimestampSources(values map[string]string) (map[IndexName]TimestampSource, error) {
+	definitions, err := GetIndexDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[IndexName]TimestampSource, len(values))
+
+	for index, source := range values {
+		name := IndexName(index)
+
+		if _, ok := definitions[name]; !ok && name != AllIndices {
+			return nil, fmt.Errorf("unknown index %s in timestamp configuration", index)
+		}
+
+		switch TimestampSource(source) {
+		case TimestampClose, TimestampIngest:
+			sources[name] = TimestampSource(source)
+		default:
+			return nil, fmt.Errorf("unknown timestamp source %s for index %s, expected close or ingest", source, index)
+		}
+	}
+
+	return sources, nil
+}
@@ -0,0 +1,69 @@
+package es
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	goES "github.com/elastic/go-elasticsearch/v7"
+)
+
+// configure sets credentials and TLS options of the secured cluster
+func (o SinkOptions) configure(esCfg *goES.Config) error {
+	esCfg.Username = o.Username
+	esCfg.Password = o.Password
+
+	if o.CACert == "" && o.ClientCert == "" && !o.InsecureSkipVerify {
+		return nil
+	}
+
+	transport, err := o.transport()
+	if err != nil {
+		return err
+	}
+
+	esCfg.Transport = transport
+
+	return nil
+}
+
+// transport returns HTTP transport with custom CA, client certificate and verification options
+func (o SinkOptions) transport() (*http.Transport, error) {
+
+	tlsCfg := &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify}
+
+	if o.CACert != "" {
+		pem, err := ioutil.ReadFile(o.CACert)
+		if err != nil {
+			return nil, err
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", o.CACert)
+		}
+
+		tlsCfg.RootCAs = pool
+	}
+
+	if o.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(o.ClientCert, o.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     tlsCfg,
+		MaxIdleConnsPerHost: 10,
+	}, nil
+}
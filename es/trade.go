@@ -17,6 +17,14 @@ type Trade struct {
 	BuyerID         string      `json:"buyer_id"`
 	Price           string      `json:"price"`
 	LedgerCloseTime time.Time   `json:"ledger_close_time"`
+
+	BaseAsset        Asset  `json:"base_asset"`
+	BaseAmount       string `json:"base_amount"`
+	BaseAccountID    string `json:"base_account_id"`
+	CounterAsset     Asset  `json:"counter_asset"`
+	CounterAmount    string `json:"counter_amount"`
+	CounterAccountID string `json:"counter_account_id"`
+	BasePrice        string `json:"base_price"`
 }
 
 // DocID balance es document id
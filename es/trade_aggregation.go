@@ -0,0 +1,236 @@
+package es
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+const tradeAggregationsPageSize = 500
+
+// TradeAggregationResolutions are candle sizes built from trades
+var TradeAggregationResolutions = map[string]time.Duration{
+	"1m": time.Minute,
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// TradeAggregation represents OHLCV candle of the asset pair
+type TradeAggregation struct {
+	ID             string    `json:"id"`
+	Resolution     string    `json:"resolution"`
+	Timestamp      time.Time `json:"timestamp"`
+	BaseAssetID    string    `json:"base_asset_id"`
+	CounterAssetID string    `json:"counter_asset_id"`
+	Open           float64   `json:"open"`
+	High           float64   `json:"high"`
+	Low            float64   `json:"low"`
+	Close          float64   `json:"close"`
+	BaseVolume     float64   `json:"base_volume"`
+	CounterVolume  float64   `json:"counter_volume"`
+	TradeCount     int       `json:"trade_count"`
+
+	version int64
+}
+
+type priceHits struct {
+	Hits struct {
+		Hits []struct {
+			Source struct {
+				BasePrice string `json:"base_price"`
+			} `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+type value struct {
+	Value float64 `json:"value"`
+}
+
+type tradeAggregationsResponse struct {
+	Aggregations struct {
+		Candles struct {
+			AfterKey map[string]interface{} `json:"after_key"`
+			Buckets  []struct {
+				Key struct {
+					Base    string `json:"base"`
+					Counter string `json:"counter"`
+					Time    int64  `json:"time"`
+				} `json:"key"`
+				DocCount      int       `json:"doc_count"`
+				High          value     `json:"high"`
+				Low           value     `json:"low"`
+				BaseVolume    value     `json:"base_volume"`
+				CounterVolume value     `json:"counter_volume"`
+				Open          priceHits `json:"open"`
+				Close         priceHits `json:"close"`
+			} `json:"buckets"`
+		} `json:"candles"`
+	} `json:"aggregations"`
+}
+
+// RollupTradeAggregations builds candles of all resolutions covering trades closed within [from, to]
+func (es *Client) RollupTradeAggregations(from, to time.Time) error {
+	res, err := es.rawClient.Indices.Refresh(es.rawClient.Indices.Refresh.WithIndex(tradesIndexName.String()))
+	if err := closeResponse(res, err); err != nil {
+		return fmt.Errorf("failed to refresh %s: %v", tradesIndexName, err)
+	}
+
+	for resolution, interval := range TradeAggregationResolutions {
+		count, err := es.rollupTradeAggregations(resolution, from.Truncate(interval), to.Truncate(interval).Add(interval))
+		if err != nil {
+			return err
+		}
+
+		log.Printf("%d %s trade aggregations built", count, resolution)
+	}
+
+	return nil
+}
+
+func (es *Client) rollupTradeAggregations(resolution string, from, to time.Time) (count int, err error) {
+	var after map[string]interface{}
+
+	for {
+		var b bytes.Buffer
+		var r tradeAggregationsResponse
+
+		if err := es.search(tradesIndexName, tradeAggregationsQuery(resolution, from, to, after), &r); err != nil {
+			return count, err
+		}
+
+		candles := r.Aggregations.Candles
+
+		for _, bucket := range candles.Buckets {
+			timestamp := time.Unix(0, bucket.Key.Time*int64(time.Millisecond)).UTC()
+
+			openPrice, err := firstPrice(bucket.Open)
+			if err != nil {
+				return count, err
+			}
+
+			closePrice, err := firstPrice(bucket.Close)
+			if err != nil {
+				return count, err
+			}
+
+			err = SerializeForBulk(&TradeAggregation{
+				ID:             fmt.Sprintf("%s-%s-%s-%d", resolution, bucket.Key.Base, bucket.Key.Counter, timestamp.Unix()),
+				Resolution:     resolution,
+				Timestamp:      timestamp,
+				BaseAssetID:    bucket.Key.Base,
+				CounterAssetID: bucket.Key.Counter,
+				Open:           openPrice,
+				High:           bucket.High.Value,
+				Low:            bucket.Low.Value,
+				Close:          closePrice,
+				BaseVolume:     bucket.BaseVolume.Value,
+				CounterVolume:  bucket.CounterVolume.Value,
+				TradeCount:     bucket.DocCount,
+				version:        time.Now().UnixNano(),
+			}, &b)
+
+			if err != nil {
+				return count, err
+			}
+		}
+
+		if len(candles.Buckets) > 0 {
+			if _, err := es.IndexWithRetries(&b, 0); err != nil {
+				return count, err
+			}
+
+			count += len(candles.Buckets)
+		}
+
+		if len(candles.Buckets) < tradeAggregationsPageSize || candles.AfterKey == nil {
+			return count, nil
+		}
+
+		after = candles.AfterKey
+	}
+}
+
+func tradeAggregationsQuery(resolution string, from, to time.Time, after map[string]interface{}) map[string]interface{} {
+	price := func(order string) map[string]interface{} {
+		return map[string]interface{}{
+			"top_hits": map[string]interface{}{
+				"size":    1,
+				"_source": []string{"base_price"},
+				"sort":    []map[string]interface{}{{"paging_token": order}},
+			},
+		}
+	}
+
+	metric := func(kind, field string) map[string]interface{} {
+		return map[string]interface{}{kind: map[string]interface{}{"field": field}}
+	}
+
+	composite := map[string]interface{}{
+		"size": tradeAggregationsPageSize,
+		"sources": []map[string]interface{}{
+			{"base": map[string]interface{}{"terms": map[string]interface{}{"field": "base_asset.id"}}},
+			{"counter": map[string]interface{}{"terms": map[string]interface{}{"field": "counter_asset.id"}}},
+			{"time": map[string]interface{}{"date_histogram": map[string]interface{}{
+				"field":          "ledger_close_time",
+				"fixed_interval": resolution,
+			}}},
+		},
+	}
+
+	if after != nil {
+		composite["after"] = after
+	}
+
+	return map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{
+				"ledger_close_time": map[string]interface{}{"gte": from, "lt": to},
+			},
+		},
+		"aggs": map[string]interface{}{
+			"candles": map[string]interface{}{
+				"composite": composite,
+				"aggs": map[string]interface{}{
+					"open":           price("asc"),
+					"close":          price("desc"),
+					"high":           metric("max", "base_price"),
+					"low":            metric("min", "base_price"),
+					"base_volume":    metric("sum", "base_amount"),
+					"counter_volume": metric("sum", "counter_amount"),
+				},
+			},
+		},
+	}
+}
+
+func firstPrice(h priceHits) (float64, error) {
+	if len(h.Hits.Hits) == 0 {
+		return 0, nil
+	}
+
+	price, err := strconv.ParseFloat(h.Hits.Hits[0].Source.BasePrice, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid trade price: %v", err)
+	}
+
+	return price, nil
+}
+
+// DocID returns es id
+func (a *TradeAggregation) DocID() *string {
+	return &a.ID
+}
+
+// IndexName returns index name
+func (a *TradeAggregation) IndexName() IndexName {
+	return tradeAggregationsIndexName
+}
+
+// Version returns document version, later rollups replace earlier ones
+func (a *TradeAggregation) Version() int64 {
+	return a.version
+}
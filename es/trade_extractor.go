@@ -179,9 +179,39 @@ func (e *TradeExtractor) fetchClaims(claims []xdr.ClaimOfferAtom, accountID stri
 			tradeB.Price = "0.0"
 		}
 
+		assignBaseCounter(&tradeA, claim.AmountSold, claim.AmountBought)
+		assignBaseCounter(&tradeB, claim.AmountBought, claim.AmountSold)
+
 		trades = append(trades, tradeA)
 		trades = append(trades, tradeB)
 	}
 
 	return trades
 }
+
+// assignBaseCounter sets base and counter sides of the trade, native asset or the lesser asset is the base one.
+// Assets are compared by legacy keys, so sides do not depend on the asset key format.
+func assignBaseCounter(t *Trade, sold xdr.Int64, bought xdr.Int64) {
+	soldIsBase := t.AssetSold.ID == "native" ||
+		(t.AssetBought.ID != "native" && t.AssetSold.legacyKey() < t.AssetBought.legacyKey())
+
+	if soldIsBase {
+		t.BaseAsset, t.CounterAsset = t.AssetSold, t.AssetBought
+		t.BaseAmount, t.CounterAmount = t.Sold, t.Bought
+		t.BaseAccountID, t.CounterAccountID = t.SellerID, t.BuyerID
+		t.BasePrice = formatRatio(bought, sold)
+	} else {
+		t.BaseAsset, t.CounterAsset = t.AssetBought, t.AssetSold
+		t.BaseAmount, t.CounterAmount = t.Bought, t.Sold
+		t.BaseAccountID, t.CounterAccountID = t.BuyerID, t.SellerID
+		t.BasePrice = formatRatio(sold, bought)
+	}
+}
+
+func formatRatio(n xdr.Int64, d xdr.Int64) string {
+	if d == 0 {
+		return "0.0"
+	}
+
+	return strconv.FormatFloat(float64(n)/float64(d), 'f', 7, 64)
+}
@@ -1,6 +1,8 @@
 package es
 
 import (
+	"encoding/hex"
+	"io/ioutil"
 	"time"
 
 	"github.com/astroband/astrologer/db"
@@ -11,16 +13,22 @@ import (
 type Transaction struct {
 	ID              string      `json:"id"`
 	Index           int         `json:"idx"`
+	ApplyIndex      int         `json:"apply_index"`
 	Seq             int         `json:"seq"`
 	PagingToken     PagingToken `json:"paging_token"`
 	MaxFee          int         `json:"max_fee"`
 	FeeCharged      int         `json:"fee_charged"`
 	FeeAccountID    string      `json:"fee_account_id"`
 	OperationCount  int         `json:"operation_count"`
+	EnvelopeSize    int         `json:"envelope_size"`
 	CloseTime       time.Time   `json:"close_time"`
 	Successful      bool        `json:"successful"`
 	ResultCode      int         `json:"result_code"`
 	SourceAccountID string      `json:"source_account_id"`
+	InnerTxID       string      `json:"inner_tx_id,omitempty"`
+	InnerMaxFee     int         `json:"inner_max_fee,omitempty"`
+	InnerResultCode *int        `json:"inner_result_code,omitempty"`
+	Signatures      []Signature `json:"signatures,omitempty"`
 
 	*TimeBounds `json:"time_bounds,omitempty"`
 	*Memo       `json:"memo,omitempty"`
@@ -33,9 +41,10 @@ func (s *ledgerSerializer) NewTransaction(row *db.TxHistoryRow, t time.Time) (*T
 		envelope = row.Envelope
 		result   = row.Result.Result.Result
 		success  bool
+		feeBump  = s.protocol.Supports(FeatureFeeBump) && envelope.IsFeeBump()
 	)
 
-	if envelope.IsFeeBump() {
+	if feeBump {
 		success = result.Code == xdr.TransactionResultCodeTxFeeBumpInnerSuccess
 	} else {
 		success = result.Code == xdr.TransactionResultCodeTxSuccess
@@ -51,6 +60,7 @@ func (s *ledgerSerializer) NewTransaction(row *db.TxHistoryRow, t time.Time) (*T
 	transaction := &Transaction{
 		ID:              row.ID,
 		Index:           row.Index,
+		ApplyIndex:      row.Index,
 		Seq:             row.LedgerSeq,
 		MaxFee:          int(envelope.Fee()),
 		PagingToken:     PagingToken{LedgerSeq: row.LedgerSeq, TransactionOrder: row.Index},
@@ -60,9 +70,10 @@ func (s *ledgerSerializer) NewTransaction(row *db.TxHistoryRow, t time.Time) (*T
 		ResultCode:      int(result.Code),
 		OperationCount:  len(envelope.Operations()),
 		SourceAccountID: sourceAccountAddress,
+		Signatures:      NewSignatures(row),
 	}
 
-	if envelope.IsFeeBump() {
+	if feeBump {
 		feeSourceAccountId := envelope.FeeBumpAccount().ToAccountId()
 		feeSourceAddress, err := (&feeSourceAccountId).GetAddress()
 
@@ -71,16 +82,24 @@ func (s *ledgerSerializer) NewTransaction(row *db.TxHistoryRow, t time.Time) (*T
 		}
 
 		transaction.FeeAccountID = feeSourceAddress
+		transaction.InnerMaxFee = transaction.MaxFee
 		transaction.MaxFee = int(envelope.FeeBumpFee())
-	}
 
-	if envelope.Memo().Type != xdr.MemoTypeMemoNone {
-		transaction.Memo = &Memo{
-			Type:  int(envelope.Memo().Type),
-			Value: row.MemoValue().String,
+		if pair, ok := result.GetInnerResultPair(); ok {
+			innerResultCode := int(pair.Result.Result.Code)
+
+			transaction.InnerTxID = hex.EncodeToString(pair.TransactionHash[:])
+			transaction.InnerResultCode = &innerResultCode
 		}
 	}
 
+	transaction.Memo = NewMemo(envelope.Memo())
+
+	// Size of XDR encoded envelope is the size of the transaction in the tx set
+	if transaction.EnvelopeSize, err = xdr.Marshal(ioutil.Discard, envelope); err != nil {
+		return nil, err
+	}
+
 	if envelope.TimeBounds() != nil {
 		transaction.TimeBounds = &TimeBounds{
 			MinTime: int64(envelope.TimeBounds().MinTime),
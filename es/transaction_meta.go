@@ -0,0 +1,63 @@
+package es
+
+import (
+	"fmt"
+
+	"github.com/stellar/go/xdr"
+)
+
+// TransactionMeta represents transaction meta normalized across meta versions written by different core versions
+type TransactionMeta struct {
+	// TxChangesBefore are applied before operations (e.g. sequence number bump), empty in V0 meta
+	TxChangesBefore xdr.LedgerEntryChanges
+
+	Operations []xdr.OperationMeta
+
+	// TxChangesAfter are applied after operations, present in V2 meta only
+	TxChangesAfter xdr.LedgerEntryChanges
+}
+
+// NewTransactionMeta decodes the meta of the transaction applied in the ledger of the given protocol version
+func NewTransactionMeta(protocol ProtocolVersion, meta xdr.TransactionMeta) (*TransactionMeta, error) {
+	if protocol.Supports(FeatureSoroban) {
+		return nil, fmt.Errorf("protocol %d ledgers are not supported by this build", protocol)
+	}
+
+	return decodeMeta(meta)
+}
+
+// decodeMeta branches on the meta version: V0 before protocol 10, V1 since protocol 10, V2 since core 13
+func decodeMeta(meta xdr.TransactionMeta) (*TransactionMeta, error) {
+	switch meta.V {
+	case 0:
+		return &TransactionMeta{Operations: meta.MustOperations()}, nil
+	case 1:
+		v1 := meta.MustV1()
+		return &TransactionMeta{TxChangesBefore: v1.TxChanges, Operations: v1.Operations}, nil
+	case 2:
+		v2 := meta.MustV2()
+		return &TransactionMeta{TxChangesBefore: v2.TxChangesBefore, Operations: v2.Operations, TxChangesAfter: v2.TxChangesAfter}, nil
+	default:
+		return nil, fmt.Errorf("unsupported transaction meta version %d", meta.V)
+	}
+}
+
+// Operation returns meta of the operation, nil if there is none (e.g. failed transaction)
+func (m *TransactionMeta) Operation(index int) *xdr.OperationMeta {
+	if index >= len(m.Operations) {
+		return nil
+	}
+
+	return &m.Operations[index]
+}
+
+// Changes returns all ledger entry changes of the transaction in application order
+func (m *TransactionMeta) Changes() (changes []xdr.LedgerEntryChange) {
+	changes = append(changes, m.TxChangesBefore...)
+
+	for _, op := range m.Operations {
+		changes = append(changes, op.Changes...)
+	}
+
+	return append(changes, m.TxChangesAfter...)
+}
@@ -0,0 +1,97 @@
+package es
+
+import (
+	"time"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/xdr"
+)
+
+// TrustLineState represents the latest known state of the trustline
+type TrustLineState struct {
+	ID                       string      `json:"id"`
+	PagingToken              PagingToken `json:"paging_token"`
+	AccountID                string      `json:"account_id"`
+	Asset                    Asset       `json:"asset"`
+	Limit                    string      `json:"limit"`
+	Balance                  string      `json:"balance"`
+	BuyingLiabilities        string      `json:"buying_liabilities"`
+	SellingLiabilities       string      `json:"selling_liabilities"`
+	Authorized               bool        `json:"authorized"`
+	AuthorizedToMaintainLiab bool        `json:"authorized_to_maintain_liabilities"`
+	LastModifiedLedger       int         `json:"last_modified_ledger"`
+	LedgerCloseTime          time.Time   `json:"ledger_close_time"`
+	Deleted                  bool        `json:"deleted"`
+}
+
+// ProduceTrustLineStates returns trustline states changed by the operation meta
+func ProduceTrustLineStates(changes xdr.LedgerEntryChanges, pagingToken PagingToken, closeTime time.Time) (lines []*TrustLineState) {
+	for _, change := range changes {
+		switch change.Type {
+		case xdr.LedgerEntryChangeTypeLedgerEntryCreated:
+			entry := change.MustCreated()
+			if t, ok := entry.Data.GetTrustLine(); ok {
+				lines = append(lines, NewTrustLineState(t, int(entry.LastModifiedLedgerSeq), pagingToken, closeTime))
+			}
+		case xdr.LedgerEntryChangeTypeLedgerEntryUpdated:
+			entry := change.MustUpdated()
+			if t, ok := entry.Data.GetTrustLine(); ok {
+				lines = append(lines, NewTrustLineState(t, int(entry.LastModifiedLedgerSeq), pagingToken, closeTime))
+			}
+		case xdr.LedgerEntryChangeTypeLedgerEntryRemoved:
+			key := change.MustRemoved()
+			if k, ok := key.GetTrustLine(); ok {
+				t := xdr.TrustLineEntry{AccountId: k.AccountId, Asset: k.Asset}
+				line := NewTrustLineState(t, pagingToken.LedgerSeq, pagingToken, closeTime)
+				line.Deleted = true
+
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	return lines
+}
+
+// NewTrustLineState creates TrustLineState from TrustLineEntry
+func NewTrustLineState(t xdr.TrustLineEntry, lastModified int, pagingToken PagingToken, closeTime time.Time) *TrustLineState {
+	var buying, selling xdr.Int64
+
+	if v1, ok := t.Ext.GetV1(); ok {
+		buying, selling = v1.Liabilities.Buying, v1.Liabilities.Selling
+	}
+
+	flags := xdr.TrustLineFlags(t.Flags)
+	accountID := t.AccountId.Address()
+	asset := NewAsset(&t.Asset)
+
+	return &TrustLineState{
+		ID:                       accountID + "-" + asset.legacyKey(),
+		PagingToken:              pagingToken,
+		AccountID:                accountID,
+		Asset:                    *asset,
+		Limit:                    amount.String(t.Limit),
+		Balance:                  amount.String(t.Balance),
+		BuyingLiabilities:        amount.String(buying),
+		SellingLiabilities:       amount.String(selling),
+		Authorized:               flags.IsAuthorized(),
+		AuthorizedToMaintainLiab: flags.IsAuthorizedToMaintainLiabilitiesFlag(),
+		LastModifiedLedger:       lastModified,
+		LedgerCloseTime:          closeTime,
+	}
+}
+
+// DocID returns trustline id
+func (t *TrustLineState) DocID() *string {
+	return &t.ID
+}
+
+// IndexName returns trustlines index name
+func (t *TrustLineState) IndexName() IndexName {
+	return trustLinesIndexName
+}
+
+// Version returns document version, newer changes overwrite older ones
+func (t *TrustLineState) Version() (int64, error) {
+	return t.PagingToken.Version()
+}
@@ -0,0 +1,7 @@
+package es
+
+// writeOnlySink implements Sink methods of outputs which can only receive documents, such as files and queues
+type writeOnlySink struct{}
+
+// CreateSchema does nothing, there are no indices to create
+func (writeOnlySink) CreateSchema(force bool) error { return nil }
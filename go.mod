@@ -3,8 +3,10 @@ module github.com/astroband/astrologer
 go 1.12
 
 require (
+	github.com/Shopify/sarama v1.19.0
 	github.com/alecthomas/template v0.0.0-20190718012654-fb15b899a751 // indirect
 	github.com/alecthomas/units v0.0.0-20190924025748-f65c72e2690d // indirect
+	github.com/aws/aws-sdk-go v1.30.19
 	github.com/elastic/go-elasticsearch/v7 v7.7.0
 	github.com/gammazero/deque v0.0.0-20200310222745-50fa758af896 // indirect
 	github.com/gammazero/workerpool v0.0.0-20200311205957-7b00833861c6
@@ -13,9 +15,10 @@ require (
 	github.com/lib/pq v1.5.2
 	github.com/mattn/go-runewidth v0.0.9 // indirect
 	github.com/olekukonko/tablewriter v0.0.4
-	github.com/pkg/errors v0.9.1 // indirect
 	github.com/schollz/progressbar/v2 v2.15.0
 	github.com/stellar/go v0.0.0-20200526231405-08ec13c54232
 	github.com/stellar/go-xdr v0.0.0-20200331223602-71a1e6d555f2 // indirect
+	github.com/xitongsys/parquet-go v1.5.4
+	github.com/xitongsys/parquet-go-source v0.0.0-20200817004010-026bad9b25d0
 	gopkg.in/alecthomas/kingpin.v2 v2.2.6
 )
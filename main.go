@@ -1,6 +1,14 @@
 package main
 
 import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/astroband/astrologer/archive"
 	cmd "github.com/astroband/astrologer/commands"
 	cfg "github.com/astroband/astrologer/config"
 	"github.com/astroband/astrologer/db"
@@ -8,37 +16,525 @@ import (
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
+// ExitCodeInterrupted is returned when the command was stopped by a signal
+const ExitCodeInterrupted = 130
+
 func main() {
 	kingpin.Version(cfg.Version)
 	commandName := kingpin.Parse()
 
-	esClient := es.Connect((*cfg.EsURL).String())
+	if *cfg.Profile != "" {
+		stop := startProfile(*cfg.Profile, *cfg.ProfileDir)
+		defer stop()
+	}
+
+	esClient := connectOutput()
+
+	if *cfg.DLQ != "" {
+		es.DeadLetters = es.NewDeadLetterQueue(*cfg.DLQ)
+	}
+	es.LegacyAssetKeys = *cfg.LegacyAssetKeys
+
+	es.Network = *cfg.Network
+	if es.Network == "" {
+		es.Network = es.NetworkName(*cfg.NetworkPassphrase)
+	}
+
+	if es.Network == "" {
+		log.Fatal("Unknown network passphrase, set the network name with --network")
+	}
+
+	if *cfg.NetworkIndexPrefix {
+		es.IndexPrefix = es.Network + "_"
+	}
+
+	timestamps, err := es.ParseTimestampSources(*cfg.Timestamps)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	es.DataStreams, err = es.ParseDataStreams(*cfg.DataStreams)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	es.DataStreamPolicy = *cfg.DataStreamPolicy
+
+	for name := range es.DataStreams {
+		if _, ok := timestamps[name]; !ok {
+			if _, ok := timestamps[es.AllIndices]; !ok {
+				timestamps[name] = es.TimestampClose
+			}
+		}
+	}
+
+	serializeOptions := es.SerializeOptions{
+		Changes:     *cfg.IndexChanges,
+		Stats:       *cfg.IndexStats,
+		Payments:    *cfg.IndexPayments,
+		Effects:     *cfg.IndexEffects,
+		Offers:      *cfg.IndexOffers,
+		TrustLines:  *cfg.IndexTrustLines,
+		Accounts:    *cfg.IndexAccounts,
+		DataEntries: *cfg.IndexDataEntries,
+		Timestamps:  timestamps,
+		OpTypes:     opTypeSet(*cfg.OpTypes),
+	}
 
 	var command cmd.Command
 
 	switch commandName {
 	case "stats":
-		dbClient := db.Connect(*cfg.DatabaseURL)
-		command = &cmd.StatsCommand{ES: esClient, DB: dbClient}
+		dbClient := connectDatabase()
+		config := cmd.StatsCommandConfig{Start: *cfg.StatsStart, Count: *cfg.StatsCount}
+		command = &cmd.StatsCommand{ES: requireAdapter(esClient, commandName), DB: dbClient, Config: config}
 	case "create-index":
+		es.SettingsOverrides = settingsOverrides()
+		es.Templates = *cfg.CreateIndexTemplates
+
+		if *cfg.CreateIndexILMPolicy != "" {
+			es.LifecyclePolicy = &es.ILMPolicy{
+				Name:         *cfg.CreateIndexILMPolicy,
+				RolloverSize: *cfg.CreateIndexILMRolloverSize,
+				RolloverAge:  *cfg.CreateIndexILMRolloverAge,
+				WarmAfter:    *cfg.CreateIndexILMWarmAfter,
+				DeleteAfter:  *cfg.CreateIndexILMDeleteAfter,
+			}
+		}
+
 		config := cmd.CreateIndexCommandConfig{Force: *cfg.ForceRecreateIndexes}
 		command = &cmd.CreateIndexCommand{ES: esClient, Config: config}
+	case "dump-mappings":
+		config := cmd.DumpMappingsCommandConfig{Indices: *cfg.DumpMappingsIndices, Out: *cfg.DumpMappingsOut}
+		command = &cmd.DumpMappingsCommand{Config: config}
 	case "export":
-		dbClient := db.Connect(*cfg.DatabaseURL)
+		checkSchemaVersion(esClient)
+		dbClient := connectSource()
+
+		// Following is ingestion, it waits for the database the same way
+		if c, ok := dbClient.(*db.Client); ok && *cfg.ExportFollow {
+			c.SetRetries(db.RetryForever)
+		}
+
+		serializeOptions.MemoRequired = memoRequiredAccounts(dbClient)
+		ranges := *cfg.Ranges
+
+		if *cfg.RangesFile != "" {
+			fileRanges, err := cfg.ReadLedgerRangesFile(*cfg.RangesFile)
+
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			ranges = append(ranges, fileRanges...)
+		}
+
 		config := cmd.ExportCommandConfig{
 			Start:      *cfg.Start,
 			Count:      *cfg.Count,
-			DryRun:     *cfg.ExportDryRun,
+			End:        *cfg.End,
+			From:       cfg.From.Time,
+			To:         cfg.To.Time,
+			Ranges:     ranges,
+			DryRun:     *cfg.ExportDryRun || *cfg.ExportDryRunOutput != "",
+			Clamp:      *cfg.ExportClamp,
+			Verify:     *cfg.ExportVerify,
 			RetryCount: *cfg.Retries,
 			BatchSize:  *cfg.BatchSize,
+
+			Concurrency:       *cfg.Concurrency,
+			Verbose:           *cfg.Verbose,
+			BalanceBatches:    *cfg.BalanceBatches,
+			MaxDuration:       *cfg.MaxDuration,
+			Audit:             *cfg.Audit,
+			CheckChain:        *cfg.CheckChain,
+			TradeAggregations: *cfg.TradeAggregations,
+			IndexSummary:      *cfg.ExportIndexSummary,
+			Optimize:          *cfg.ExportOptimize,
+			MaxNumSegments:    *cfg.ExportMaxNumSegments,
+			MaxReplicationLag: *cfg.ExportMaxReplicationLag,
+			AccountFilters:    *cfg.ExportAccountFilters,
+			MaxDocs:           *cfg.ExportMaxDocs,
+			MaxBytes:          int64(*cfg.ExportMaxBytes),
+			Follow:            *cfg.ExportFollow,
+			Reverse:           *cfg.ExportReverse,
+			MaxBatchInflight:  *cfg.ExportMaxBatchInflight,
+			Checkpoint:        *cfg.ExportCheckpoint,
+			Resume:            *cfg.ExportResume,
+			DryRunOutput:      *cfg.ExportDryRunOutput,
+			Accounts:          accountSet(*cfg.ExportAccounts),
+			Assets:            assetSet(*cfg.ExportAssets),
+			SerializeOptions:  serializeOptions,
 		}
 		command = &cmd.ExportCommand{ES: esClient, DB: dbClient, Config: config}
 	case "ingest":
-		dbClient := db.Connect(*cfg.DatabaseURL)
-		command = &cmd.IngestCommand{ES: esClient, DB: dbClient}
+		checkSchemaVersion(esClient)
+		dbClient := connectDatabase()
+		dbClient.SetRetries(db.RetryForever)
+		serializeOptions.MemoRequired = memoRequiredAccounts(dbClient)
+		config := cmd.IngestCommandConfig{
+			MaxDuration:       *cfg.MaxDuration,
+			Audit:             *cfg.Audit,
+			CheckChain:        *cfg.CheckChain,
+			TradeAggregations: *cfg.TradeAggregations,
+			SerializeOptions:  serializeOptions,
+			Resume:            *cfg.IngestResume,
+			FillGaps:          *cfg.IngestFillGaps,
+			Start:             *cfg.StartIngest,
+			DBMaxQPS:          *cfg.DBMaxQPS,
+			FlowsAssets:       *cfg.IngestFlowsAssets,
+			FlowsInterval:     *cfg.IngestFlowsInterval,
+			FlowsLabelsFile:   *cfg.IngestFlowsLabels,
+		}
+
+		if *cfg.LiveConfigFile != "" {
+			liveConfig, err := cfg.WatchLiveConfig(*cfg.LiveConfigFile, cmd.ValidateLiveSettings)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			config.LiveConfig = liveConfig
+		}
+
+		if *cfg.IngestListen {
+			listener, err := db.Listen((*cfg.DatabaseURL)[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			config.Listener = listener
+		}
+
+		command = &cmd.IngestCommand{ES: esClient, DB: dbClient, Config: config}
+	case "export-account":
+		dbClient := connectDatabase()
+		config := cmd.ExportAccountCommandConfig{AccountID: *cfg.ExportAccountID, Out: *cfg.ExportAccountOut}
+		command = &cmd.ExportAccountCommand{ES: requireAdapter(esClient, commandName), DB: dbClient, Config: config}
+	case "state-at":
+		config := cmd.StateAtCommandConfig{
+			AccountID: *cfg.StateAtAccountID,
+			Seq:       *cfg.StateAtLedger,
+			JSON:      *cfg.StateAtJSON,
+		}
+		command = &cmd.StateAtCommand{ES: requireAdapter(esClient, commandName), Config: config}
 	case "es-stats":
-		command = &cmd.EsStatsCommand{ES: esClient}
+		config := cmd.EsStatsCommandConfig{
+			Watch:            *cfg.EsStatsWatch,
+			MetricsFile:      *cfg.EsStatsMetricsFile,
+			SerializeOptions: serializeOptions,
+		}
+		command = &cmd.EsStatsCommand{ES: requireAdapter(esClient, commandName), Config: config}
+	case "status":
+		dbClient := connectDatabase()
+		config := cmd.StatusCommandConfig{MaxLag: *cfg.StatusMaxLag, SerializeOptions: serializeOptions}
+		command = &cmd.StatusCommand{ES: requireAdapter(esClient, commandName), DB: dbClient, Config: config}
+	case "verify":
+		dbClient := connectDatabase()
+		serializeOptions.MemoRequired = memoRequiredAccounts(dbClient)
+		config := cmd.VerifyCommandConfig{
+			Start:            *cfg.VerifyStart,
+			Count:            *cfg.VerifyCount,
+			Sample:           *cfg.VerifySample,
+			SerializeOptions: serializeOptions,
+		}
+		command = &cmd.VerifyCommand{ES: requireAdapter(esClient, commandName), DB: dbClient, Config: config}
+	case "reingest":
+		checkSchemaVersion(esClient)
+		dbClient := connectDatabase()
+		serializeOptions.MemoRequired = memoRequiredAccounts(dbClient)
+		config := cmd.ReingestCommandConfig{
+			Start:            *cfg.ReingestStart,
+			Count:            *cfg.ReingestCount,
+			BatchSize:        *cfg.ReingestBatchSize,
+			RetryCount:       *cfg.ReingestRetries,
+			SerializeOptions: serializeOptions,
+		}
+		command = &cmd.ReingestCommand{ES: requireAdapter(esClient, commandName), DB: dbClient, Config: config}
+	case "reindex":
+		config := cmd.ReindexCommandConfig{
+			Indices:   *cfg.ReindexIndices,
+			Scripts:   *cfg.ReindexScripts,
+			DeleteOld: *cfg.ReindexDeleteOld,
+		}
+		command = &cmd.ReindexCommand{ES: requireAdapter(esClient, commandName), Config: config}
+	case "fill-gaps":
+		checkSchemaVersion(esClient)
+		dbClient := connectDatabase()
+		serializeOptions.MemoRequired = memoRequiredAccounts(dbClient)
+		config := cmd.FillGapsCommandConfig{
+			RetryCount:       *cfg.FillGapsRetries,
+			BatchSize:        *cfg.FillGapsBatchSize,
+			DryRun:           *cfg.FillGapsDryRun,
+			Concurrency:      *cfg.Concurrency,
+			Progress:         true,
+			SerializeOptions: serializeOptions,
+		}
+		command = &cmd.FillGapsCommand{ES: requireAdapter(esClient, commandName), DB: dbClient, Config: config}
+	case "replay-dlq":
+		config := cmd.ReplayDLQCommandConfig{File: *cfg.DLQ, RetryCount: *cfg.ReplayDLQRetries}
+		command = &cmd.ReplayDLQCommand{ES: esClient, Config: config}
+	case "rollup flows":
+		config := cmd.RollupFlowsCommandConfig{
+			AssetID:    *cfg.RollupFlowsAsset,
+			Interval:   *cfg.RollupFlowsInterval,
+			LabelsFile: *cfg.RollupFlowsLabels,
+		}
+		command = &cmd.RollupFlowsCommand{ES: requireAdapter(esClient, commandName), Config: config}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go cancelOnSignal(cancel)
+
+	if err := command.Run(ctx); err != nil {
+		exitOnError(err)
+	}
+}
+
+// cancelOnSignal cancels the command on SIGINT or SIGTERM, letting it finish running batches and print resume hints
+func cancelOnSignal(cancel context.CancelFunc) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	<-signals
+	log.Println("Interrupted, stopping")
+	cancel()
+}
+
+// exitOnError exits with the code of the command error
+func exitOnError(err error) {
+	if exit, ok := err.(*cmd.ExitError); ok {
+		log.Println(exit)
+		os.Exit(exit.Code)
+	}
+
+	if err == context.Canceled {
+		os.Exit(ExitCodeInterrupted)
+	}
+
+	log.Fatal(err)
+}
+
+// connectOutput returns the sink writing to --output destinations, ElasticSearch at --es-url by default
+func connectOutput() es.Sink {
+	outputs := *cfg.Output
+
+	if len(outputs) == 0 {
+		outputs = append(outputs, *cfg.EsURL)
+	}
+
+	options := es.SinkOptions{
+		MaxFileSize: int64(*cfg.OutputMaxSize),
+		KafkaFormat: *cfg.KafkaFormat,
+		APIKey:      *cfg.EsAPIKey,
+		CloudID:     *cfg.EsCloudID,
+
+		Username:           *cfg.EsUsername,
+		Password:           *cfg.EsPassword,
+		CACert:             *cfg.EsCACert,
+		ClientCert:         *cfg.EsClientCert,
+		ClientKey:          *cfg.EsClientKey,
+		InsecureSkipVerify: *cfg.EsInsecureSkipVerify,
+
+		Gzip: *cfg.EsGzip,
+	}
+
+	if options.ClientCert != "" && options.ClientKey == "" {
+		log.Fatal("--es-client-key is required with --es-client-cert")
+	}
+
+	sinks := make([]es.Sink, len(outputs))
+
+	for n, output := range outputs {
+		sink, err := es.OpenSink(output, options)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		sinks[n] = sink
+	}
+
+	var sink es.Sink = sinks[0]
+
+	if len(sinks) > 1 {
+		sink = es.NewFanOut(sinks[0], sinks[1:]...)
+	}
+
+	if *cfg.InjectFailures > 0 {
+		sink = es.NewFailureInjector(sink, *cfg.InjectFailures)
+	}
+
+	return sink
+}
+
+// requireAdapter returns ElasticSearch adapter of the output, exits if the command is run against write-only output
+func requireAdapter(sink es.Sink, commandName string) es.Adapter {
+	adapter, ok := es.AsAdapter(sink)
+	if !ok {
+		log.Fatalf("%s requires ElasticSearch output", commandName)
+	}
+
+	return adapter
+}
+
+// connectSource returns ledger source of export, the core database by default
+func connectSource() db.Adapter {
+	source := *cfg.ExportSource
+
+	if source == nil {
+		return connectDatabase()
+	}
+
+	if strings.HasPrefix(source.Scheme, "archive") {
+		client, err := archive.Connect(source, *cfg.NetworkPassphrase)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return client
+	}
+
+	client, err := db.Connect(source)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client.SetRetries(*cfg.DBRetries)
+	client.SetMaxQPS(*cfg.DBMaxQPS)
+
+	return client
+}
+
+// connectDatabase connects to the core database, queries are retried --db-retries times on connection errors
+func connectDatabase() *db.Client {
+	client, err := db.Connect(*cfg.DatabaseURL...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client.SetRetries(*cfg.DBRetries)
+	client.SetMaxQPS(*cfg.DBMaxQPS)
+
+	return client
+}
+
+// memoRequiredAccounts returns accounts requiring memo as configured in the database and --memo-required-accounts file
+func memoRequiredAccounts(dbClient db.Adapter) map[string]bool {
+	if !*cfg.MemoRequired && *cfg.MemoRequiredAccountsFile == "" {
+		return nil
+	}
+
+	accounts := make(map[string]bool)
+
+	fromDB, err := dbClient.MemoRequiredAccounts()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, id := range fromDB {
+		accounts[id] = true
+	}
+
+	if *cfg.MemoRequiredAccountsFile != "" {
+		ids, err := cfg.ReadAccountsFile(*cfg.MemoRequiredAccountsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, id := range ids {
+			accounts[id] = true
+		}
+	}
+
+	log.Println(len(accounts), "accounts require memo")
+
+	return accounts
+}
+
+// accountSet validates account IDs given by flags, nil means no filter
+func accountSet(ids []string) map[string]bool {
+	accounts, err := cmd.AccountSet(ids)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return accounts
+}
+
+// assetSet converts assets given by flags to asset keys, nil means no filter
+func assetSet(values []string) map[string]bool {
+	assets, err := cmd.AssetSet(values)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return assets
+}
+
+// opTypeSet converts operation types given by flags to types of operation documents, nil means no filter
+func opTypeSet(names []string) map[string]bool {
+	types, err := cmd.OpTypeSet(names)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return types
+}
+
+// settingsOverrides returns index settings given by create-index flags and the settings file,
+// flags apply to every index, settings of the index in the file override them
+func settingsOverrides() map[es.IndexName]map[string]interface{} {
+	overrides := make(map[es.IndexName]map[string]interface{})
+
+	if *cfg.CreateIndexSettings != "" {
+		var err error
+
+		if overrides, err = es.ReadSettingsOverrides(*cfg.CreateIndexSettings); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	flags := map[string]string{
+		"number_of_shards":   *cfg.CreateIndexShards,
+		"number_of_replicas": *cfg.CreateIndexReplicas,
+		"refresh_interval":   *cfg.CreateIndexRefreshInterval,
+		"codec":              *cfg.CreateIndexCodec,
+	}
+
+	for key, value := range flags {
+		if value == "" {
+			continue
+		}
+
+		if overrides[es.AllIndices] == nil {
+			overrides[es.AllIndices] = make(map[string]interface{})
+		}
+
+		overrides[es.AllIndices][key] = value
+	}
+
+	return overrides
+}
+
+// checkSchemaVersion prevents writing documents of the new schema into indices created by the old one,
+// write-only outputs have no schema to check
+func checkSchemaVersion(esClient es.Sink) {
+	adapter, ok := es.AsAdapter(esClient)
+	if !ok {
+		return
+	}
+
+	err := adapter.CheckSchemaVersion()
+
+	if err == nil {
+		return
+	}
+
+	if *cfg.AllowSchemaDrift {
+		log.Println("Warning:", err)
+		return
 	}
 
-	command.Execute()
+	log.Fatal(err, ", or pass --allow-schema-drift to continue anyway")
 }
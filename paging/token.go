@@ -0,0 +1,164 @@
+// Package paging provides the ordering scheme used for Astrologer document IDs and paging tokens.
+//
+// Every indexed document is ordered by ledger sequence, transaction order within the ledger,
+// operation order within the transaction and effect index within the operation. The token string
+// form ("000023269090-0001-0002-0000") sorts lexicographically in the same order, so it is used as
+// document ID and as a range cursor for the paging_token field in all indices.
+package paging
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	toidLedgerShift      = 32
+	toidTransactionShift = 12
+	toidTransactionMask  = (1 << 20) - 1
+	toidOperationMask    = (1 << 12) - 1
+
+	versionLedgerShift      = 32
+	versionTransactionShift = 20
+	versionOperationShift   = 8
+	versionLedgerMask       = (1 << 31) - 1
+	versionTransactionMask  = (1 << 12) - 1
+	versionOperationMask    = (1 << 12) - 1
+	versionEffectMask       = (1 << 8) - 1
+)
+
+// Token represents numerical order / id of objects.
+// Transaction 0 of the ledger 1 and the ledger itself will have the same order, so, start orders from 1.
+type Token struct {
+	LedgerSeq        int
+	TransactionOrder int
+	OperationOrder   int
+	EffectIndex      int
+}
+
+var (
+	ledgerFormat      = "%012d"
+	transactionFormat = "%04d"
+	operationFormat   = "%04d"
+	effectIndexFormat = "%04d"
+
+	tokenFormat = ledgerFormat + "-" + transactionFormat + "-" + operationFormat + "-" + effectIndexFormat
+)
+
+// ForLedger returns token of the ledger header document
+func ForLedger(seq int) Token {
+	return Token{LedgerSeq: seq}
+}
+
+// ForTransaction returns token of the transaction document, order starts from 1
+func ForTransaction(seq int, order int) Token {
+	return Token{LedgerSeq: seq, TransactionOrder: order}
+}
+
+// ForOperation returns token of the operation document, orders start from 1
+func ForOperation(seq int, txOrder int, opOrder int) Token {
+	return Token{LedgerSeq: seq, TransactionOrder: txOrder, OperationOrder: opOrder}
+}
+
+// Parse parses string representation of the token
+func Parse(s string) (t Token, err error) {
+	var rest string
+
+	n, _ := fmt.Sscanf(s, "%d-%d-%d-%d%s", &t.LedgerSeq, &t.TransactionOrder, &t.OperationOrder, &t.EffectIndex, &rest)
+
+	if n != 4 || t.String() != s {
+		return Token{}, fmt.Errorf("invalid paging token %q", s)
+	}
+
+	return t, nil
+}
+
+// FromTOID converts Horizon total order ID to the token
+func FromTOID(id int64) Token {
+	return Token{
+		LedgerSeq:        int(id >> toidLedgerShift),
+		TransactionOrder: int((id >> toidTransactionShift) & toidTransactionMask),
+		OperationOrder:   int(id & toidOperationMask),
+	}
+}
+
+// TOID converts the token to Horizon total order ID, effect index is not representable and is dropped
+func (o Token) TOID() (int64, error) {
+	if o.TransactionOrder > toidTransactionMask || o.OperationOrder > toidOperationMask {
+		return 0, fmt.Errorf("paging token %s can not be represented as TOID", o)
+	}
+
+	return int64(o.LedgerSeq)<<toidLedgerShift |
+		int64(o.TransactionOrder)<<toidTransactionShift |
+		int64(o.OperationOrder), nil
+}
+
+// String returns string representation of order
+func (o Token) String() (result string) {
+	return fmt.Sprintf(tokenFormat, o.LedgerSeq, o.TransactionOrder, o.OperationOrder, o.EffectIndex)
+}
+
+// MarshalJSON marshals to string
+func (o Token) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.String())
+}
+
+// UnmarshalJSON parses token from string
+func (o *Token) UnmarshalJSON(data []byte) error {
+	var s string
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	t, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*o = t
+
+	return nil
+}
+
+// Merge merges with other order
+func (o Token) Merge(n Token) (result Token) {
+	if o.LedgerSeq != 0 {
+		result.LedgerSeq = o.LedgerSeq
+	} else {
+		result.LedgerSeq = n.LedgerSeq
+	}
+
+	if o.TransactionOrder != 0 {
+		result.TransactionOrder = o.TransactionOrder
+	} else {
+		result.TransactionOrder = n.TransactionOrder
+	}
+
+	if o.OperationOrder != 0 {
+		result.OperationOrder = o.OperationOrder
+	} else {
+		result.OperationOrder = n.OperationOrder
+	}
+
+	if o.EffectIndex != 0 {
+		result.EffectIndex = o.EffectIndex
+	} else {
+		result.EffectIndex = n.EffectIndex
+	}
+
+	return result
+}
+
+// Version returns monotonic numeric representation of the token suitable for ES external versioning.
+// Bits are laid out like TOID with the effect index in the lowest byte: seq<<32 | tx<<20 | op<<8 | effect.
+func (o Token) Version() (int64, error) {
+	if o.LedgerSeq > versionLedgerMask || o.TransactionOrder > versionTransactionMask ||
+		o.OperationOrder > versionOperationMask || o.EffectIndex > versionEffectMask {
+		return 0, fmt.Errorf("paging token %s can not be represented as version", o)
+	}
+
+	return int64(o.LedgerSeq)<<versionLedgerShift |
+		int64(o.TransactionOrder)<<versionTransactionShift |
+		int64(o.OperationOrder)<<versionOperationShift |
+		int64(o.EffectIndex), nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+)
+
+const profileTopFunctions = "20"
+
+// startProfile starts the profiler of the given kind, returned function writes the profile
+func startProfile(kind string, dir string) (stop func()) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatal(err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("astrologer-%s-%s.pprof", kind, time.Now().Format("20060102-150405")))
+
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch kind {
+	case "cpu":
+		if err := pprof.StartCPUProfile(file); err != nil {
+			log.Fatal(err)
+		}
+	case "block":
+		runtime.SetBlockProfileRate(1)
+	}
+
+	return func() {
+		switch kind {
+		case "cpu":
+			pprof.StopCPUProfile()
+		case "mem":
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(file); err != nil {
+				log.Println("Failed to write memory profile:", err)
+			}
+		case "block":
+			if err := pprof.Lookup("block").WriteTo(file, 0); err != nil {
+				log.Println("Failed to write block profile:", err)
+			}
+		}
+
+		file.Close()
+
+		log.Println("Profile written to", path)
+		log.Println("View flame graph with: go tool pprof -http=:8080", path)
+
+		summarizeProfile(path)
+	}
+}
+
+// summarizeProfile logs hot functions if go toolchain is available
+func summarizeProfile(path string) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		return
+	}
+
+	out, err := exec.Command(goBin, "tool", "pprof", "-top", "-nodecount="+profileTopFunctions, path).CombinedOutput()
+	if err != nil {
+		log.Println("Failed to summarize profile:", err)
+		return
+	}
+
+	log.Printf("Hot functions:\n%s", out)
+}
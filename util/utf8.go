@@ -0,0 +1,23 @@
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ScrubUTF8 replaces invalid UTF-8 sequences with the replacement rune, valid strings are returned as is
+func ScrubUTF8(in string) string {
+	if utf8.ValidString(in) {
+		return in
+	}
+
+	var b strings.Builder
+
+	for len(in) > 0 {
+		r, n := utf8.DecodeRuneInString(in)
+		b.WriteRune(r)
+		in = in[n:]
+	}
+
+	return b.String()
+}